@@ -1,6 +1,9 @@
 package main
 
 import (
+	"context"
+	"flag"
+
 	"butterfly.orx.me/core"
 	"butterfly.orx.me/core/app"
 	"github.com/gin-gonic/gin"
@@ -10,9 +13,23 @@ import (
 
 var router func(*gin.Engine)
 
+// configSource -config 参数，接受文件路径或 http(s) URL
+var configSource = configFlag()
+
+// configFlag 注册 -config 参数，框架已定义同名参数时复用其值
+func configFlag() func() string {
+	if f := flag.Lookup("config"); f != nil {
+		return f.Value.String
+	}
+	source := flag.String("config", "", "config file path or http(s) URL")
+	return func() string { return *source }
+}
+
 func main() {
 	app := NewApp()
 	app.Run()
+	// 服务退出后停止调度任务，等待进行中的更新写完
+	http.Shutdown()
 }
 
 func NewApp() *app.App {
@@ -20,7 +37,21 @@ func NewApp() *app.App {
 		Config:   conf.Conf,
 		Service:  "unifeed",
 		Router:   http.Router,
-		InitFunc: []func() error{},
+		InitFunc: []func() error{loadConfig},
 	})
 	return app
 }
+
+// loadConfig 指定 -config 时从文件或 URL 加载配置，覆盖框架加载的配置
+func loadConfig() error {
+	source := configSource()
+	if source == "" {
+		return nil
+	}
+	cfg, err := conf.LoadConfig(context.Background(), source)
+	if err != nil {
+		return err
+	}
+	conf.Replace(cfg)
+	return nil
+}
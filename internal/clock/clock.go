@@ -0,0 +1,57 @@
+// Package clock 提供可替换的时间源，测试中可使用 Fake 精确控制定时器和等待
+package clock
+
+import (
+	"context"
+	"time"
+)
+
+// Clock 时间源
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	After(d time.Duration) <-chan time.Time
+	Sleep(d time.Duration)
+}
+
+// Timer 定时器，语义与 time.Timer 一致
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// SleepContext 等待 d 或直到 ctx 取消，取消时返回 ctx.Err()
+func SleepContext(ctx context.Context, c Clock, d time.Duration) error {
+	t := c.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Real 返回使用系统时间的时间源
+func Real() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct {
+	t *time.Timer
+}
+
+func (r realTimer) C() <-chan time.Time        { return r.t.C }
+func (r realTimer) Stop() bool                 { return r.t.Stop() }
+func (r realTimer) Reset(d time.Duration) bool { return r.t.Reset(d) }
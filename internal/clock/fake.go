@@ -0,0 +1,127 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake 手动推进的时间源，定时器只在 Advance 时触发
+type Fake struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFake 创建从 now 开始的手动时间源
+func NewFake(now time.Time) *Fake {
+	f := &Fake{now: now}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+// Now 返回当前的模拟时间
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// NewTimer 创建在模拟时间经过 d 后触发的定时器，d 不大于 0 时立即触发
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{f: f, ch: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+// After 等价于 NewTimer(d).C()
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+// Sleep 阻塞直到模拟时间经过 d
+func (f *Fake) Sleep(d time.Duration) {
+	<-f.After(d)
+}
+
+// Advance 推进模拟时间，并按到期顺序触发到期的定时器
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+	sort.SliceStable(f.timers, func(i, j int) bool {
+		return f.timers[i].deadline.Before(f.timers[j].deadline)
+	})
+	var pending []*fakeTimer
+	for _, t := range f.timers {
+		if t.deadline.After(f.now) {
+			pending = append(pending, t)
+			continue
+		}
+		t.fire(f.now)
+	}
+	f.timers = pending
+	f.cond.Broadcast()
+}
+
+// BlockUntil 阻塞直到至少有 n 个等待中的定时器，用于确认被测代码已进入等待
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.timers) < n {
+		f.cond.Wait()
+	}
+}
+
+// remove 移除等待中的定时器，调用方需持有锁
+func (f *Fake) remove(t *fakeTimer) bool {
+	for i, other := range f.timers {
+		if other == t {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+type fakeTimer struct {
+	f        *Fake
+	ch       chan time.Time
+	deadline time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+// fire 非阻塞地发送触发时间，通道中已有未读取的值时丢弃
+func (t *fakeTimer) fire(now time.Time) {
+	select {
+	case t.ch <- now:
+	default:
+	}
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.f.mu.Lock()
+	defer t.f.mu.Unlock()
+	active := t.f.remove(t)
+	t.f.cond.Broadcast()
+	return active
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.f.mu.Lock()
+	defer t.f.mu.Unlock()
+	active := t.f.remove(t)
+	if d <= 0 {
+		t.fire(t.f.now)
+	} else {
+		t.deadline = t.f.now.Add(d)
+		t.f.timers = append(t.f.timers, t)
+	}
+	t.f.cond.Broadcast()
+	return active
+}
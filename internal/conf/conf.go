@@ -1,42 +1,237 @@
 package conf
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
+	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
 	"time"
+
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"gopkg.in/yaml.v3"
 )
 
 var (
+	// Conf 框架启动时加载配置的目标，运行期间请通过 Current 读取配置
 	Conf = new(Config)
+	// current Replace 设置的配置，为 nil 时使用 Conf
+	current atomic.Pointer[Config]
 )
 
+// Current 返回当前生效的配置，调用方不应修改返回的配置
+func Current() *Config {
+	if c := current.Load(); c != nil {
+		return c
+	}
+	return Conf
+}
+
+// Replace 原子地替换当前配置，用于重新加载；替换后不应再修改 cfg
+func Replace(cfg *Config) {
+	current.Store(cfg)
+}
+
+// updateMu 串行化 Update，避免并发修改互相覆盖
+var updateMu sync.Mutex
+
+// Update 复制当前配置交给 fn 修改，fn 成功后原子替换当前配置，用于运行期间增加 Feed；
+// 副本只深拷贝 Feeds，fn 不应修改其他切片或 map 字段的内容
+func Update(fn func(cfg *Config) error) error {
+	updateMu.Lock()
+	defer updateMu.Unlock()
+
+	cfg := *Current()
+	cfg.Feeds = slices.Clone(cfg.Feeds)
+	if err := fn(&cfg); err != nil {
+		return err
+	}
+	Replace(&cfg)
+	return nil
+}
+
 type Config struct {
 	Feeds     []Feed          `json:"feeds" yaml:"feeds"`
 	S3        S3Config        `json:"s3" yaml:"s3"`
 	AI        AIConfig        `json:"ai" yaml:"ai"`
 	Scheduler SchedulerConfig `json:"scheduler" yaml:"scheduler"`
+	HTTP      HTTPConfig      `json:"http" yaml:"http"`
+	Social    SocialConfig    `json:"social" yaml:"social"`
+	Storage   StorageConfig   `json:"storage" yaml:"storage"`
+	WebSub    WebSubConfig    `json:"websub" yaml:"websub"`
+	Preview   PreviewConfig   `json:"preview" yaml:"preview"`
+}
+
+type PreviewConfig struct {
+	// MaxBytes /preview 拉取上游的最大字节数，默认 5MiB
+	MaxBytes int64 `json:"max_bytes" yaml:"max_bytes"`
+	// MaxSummaries 单次预览最多生成的摘要数，请求的 summaries 参数超过时截断，默认 3
+	MaxSummaries int `json:"max_summaries" yaml:"max_summaries"`
+	// Timeout 拉取上游的超时时间，默认 15s
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+	// AllowPrivate 允许预览内网、回环等地址，仅用于本地调试
+	AllowPrivate bool `json:"allow_private" yaml:"allow_private"`
+}
+
+type WebSubConfig struct {
+	// Enabled 为声明了 WebSub hub 的 RSS Feed 订阅推送，需要配置 http.base_url 作为回调地址
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// Lease 向 hub 请求的订阅时长，默认 24h
+	Lease time.Duration `json:"lease" yaml:"lease"`
+}
+
+type StorageConfig struct {
+	// Profiles 命名的存储配置，Feed 通过 storage_profile 选择，未选择时使用 s3 配置
+	Profiles map[string]S3Config `json:"profiles" yaml:"profiles"`
+	// ItemKeyTemplate 条目存储路径模板，支持 {feed}、{id}、{yyyy}、{mm}、{dd}（按条目发布日期分区），
+	// 默认 feeds/{feed}/items/{id}.json
+	ItemKeyTemplate string `json:"item_key_template" yaml:"item_key_template"`
 }
 
 type Mastodon struct {
 	Host  string `json:"host" yaml:"host"`
 	Token string `json:"token" yaml:"token"`
+	// IncludeReplies 是否包含回复，未设置时包含
+	IncludeReplies *bool `json:"include_replies" yaml:"include_replies"`
+	// Pages 拉取的时间线页数，默认 1；设置 MaxItems 时为页数上限，0 表示不限
+	Pages int `json:"pages" yaml:"pages"`
+	// MaxItems 拉取的最多条目数，0 表示不限
+	MaxItems int `json:"max_items" yaml:"max_items"`
+	// StrictPagination 为 true 时任意一页失败即整体失败，否则返回已获取的内容
+	StrictPagination bool `json:"strict_pagination" yaml:"strict_pagination"`
+	// Timeline 拉取的时间线：home、public、local 或 tag:<话题>，默认 home
+	Timeline string `json:"timeline" yaml:"timeline"`
+}
+
+// Mastodon 时间线类型
+const (
+	MastodonTimelineHome   = "home"
+	MastodonTimelinePublic = "public"
+	MastodonTimelineLocal  = "local"
+	MastodonTimelineTag    = "tag"
+)
+
+// mastodonTagPrefix 话题时间线配置的前缀
+const mastodonTagPrefix = "tag:"
+
+// RepliesIncluded 是否保留回复
+func (m Mastodon) RepliesIncluded() bool {
+	return m.IncludeReplies == nil || *m.IncludeReplies
+}
+
+// ParseTimeline 解析 Timeline 配置，返回时间线类型和话题（仅 tag 类型），未知的值返回错误
+func (m Mastodon) ParseTimeline() (kind, tag string, err error) {
+	switch m.Timeline {
+	case "", MastodonTimelineHome:
+		return MastodonTimelineHome, "", nil
+	case MastodonTimelinePublic, MastodonTimelineLocal:
+		return m.Timeline, "", nil
+	}
+	if tag, ok := strings.CutPrefix(m.Timeline, mastodonTagPrefix); ok {
+		tag = strings.TrimPrefix(tag, "#")
+		if tag == "" {
+			return "", "", fmt.Errorf("mastodon timeline %q: tag name required", m.Timeline)
+		}
+		return MastodonTimelineTag, tag, nil
+	}
+	return "", "", fmt.Errorf("unknown mastodon timeline %q: expected home, public, local or tag:<name>", m.Timeline)
 }
 
 type Bluesky struct {
-	Host      string `json:"host" yaml:"host"`
-	Handle    string `json:"handle" yaml:"handle"`
-	AppKey    string `json:"app_key" yaml:"app_key"`
+	Host string `json:"host" yaml:"host"`
+	// Handle 账号 handle（如 alice.bsky.social）或 DID（如 did:plc:xxx）
+	Handle string `json:"handle" yaml:"handle"`
+	// AppKey 访问令牌，配置 AppSecret 时不使用
+	AppKey string `json:"app_key" yaml:"app_key"`
+	// AppSecret app password，配置后通过 createSession 登录并在令牌失效时刷新
 	AppSecret string `json:"app_secret" yaml:"app_secret"`
+	// IncludeReplies 是否包含回复，未设置时包含
+	IncludeReplies *bool `json:"include_replies" yaml:"include_replies"`
+	// Pages 拉取的时间线页数，默认 1
+	Pages int `json:"pages" yaml:"pages"`
+	// StrictPagination 为 true 时任意一页失败即整体失败，否则返回已获取的内容
+	StrictPagination bool `json:"strict_pagination" yaml:"strict_pagination"`
+}
+
+// RepliesIncluded 是否保留回复
+func (b Bluesky) RepliesIncluded() bool {
+	return b.IncludeReplies == nil || *b.IncludeReplies
 }
 
 type Feed struct {
-	Name     string   `json:"name" yaml:"name"`
-	Title    string   `json:"title" yaml:"title"`
-	Mastodon Mastodon `json:"mastodon" yaml:"mastodon"`
-	Bluesky  Bluesky  `json:"bluesky" yaml:"bluesky"`
-	RssFeed  string   `json:"rss_feed" yaml:"rss_feed"`
+	Name        string   `json:"name" yaml:"name"`
+	Title       string   `json:"title" yaml:"title"`
+	Description string   `json:"description" yaml:"description"`
+	Mastodon    Mastodon `json:"mastodon" yaml:"mastodon"`
+	Bluesky     Bluesky  `json:"bluesky" yaml:"bluesky"`
+	RssFeed     string   `json:"rss_feed" yaml:"rss_feed"`
+	// MaxFetchItems 每次更新处理的最新条目上限，0 表示不限制
+	MaxFetchItems int `json:"max_fetch_items" yaml:"max_fetch_items"`
+	// MaxStoredItems 保留的存储条目上限，每次更新后删除发布时间最早的多余条目，0 表示不限制
+	MaxStoredItems int `json:"max_stored_items" yaml:"max_stored_items"`
+	// RemoveMissing 删除上游已不再包含的存储条目，默认保留并标记 archived_at
+	RemoveMissing bool `json:"remove_missing" yaml:"remove_missing"`
+	// Transforms 存储前按顺序应用的条目转换
+	Transforms []Transform `json:"transforms" yaml:"transforms"`
+	// Groups Feed 所属分组，可通过 /groups/:group 获取合并后的内容
+	Groups []string `json:"groups" yaml:"groups"`
+	// StorageProfile 使用的存储配置名称，为空时使用默认 s3 配置
+	StorageProfile string `json:"storage_profile" yaml:"storage_profile"`
+	// ContentTemplate 渲染条目正文的 text/template 模板，为空时使用默认拼接方式
+	ContentTemplate string `json:"content_template" yaml:"content_template"`
+	// IncludeAuthors 只保留这些作者的条目，匹配条目作者或账号，忽略大小写和开头的 @
+	IncludeAuthors []string `json:"include_authors" yaml:"include_authors"`
+	// ExcludeAuthors 丢弃这些作者的条目
+	ExcludeAuthors []string `json:"exclude_authors" yaml:"exclude_authors"`
+	// AI 覆盖全局 AI 配置的接口地址，为空时使用全局配置
+	AI FeedAIConfig `json:"ai" yaml:"ai"`
+	// Link 频道的网站链接，为空时使用上游地址
+	Link string `json:"link" yaml:"link"`
+	// UpdateInterval 覆盖调度器的更新间隔，为 0 时使用 scheduler.update_interval
+	UpdateInterval time.Duration `json:"update_interval" yaml:"update_interval"`
+}
+
+// FeedAIConfig Feed 级别的 AI 接口配置
+type FeedAIConfig struct {
+	Endpoint string `json:"endpoint" yaml:"endpoint"`
+	// APIKey 为空时使用全局 api_key
+	APIKey string `json:"api_key" yaml:"api_key"`
+	// Model 为空时使用全局 model
+	Model string `json:"model" yaml:"model"`
+	// DailyTokenBudget 每天（UTC）最多消耗的 token 数，达到后当天不再总结，0 表示不限制
+	DailyTokenBudget int `json:"daily_token_budget" yaml:"daily_token_budget"`
+	// DailyRequestBudget 每天（UTC）最多发出的 AI 请求数，0 表示不限制
+	DailyRequestBudget int `json:"daily_request_budget" yaml:"daily_request_budget"`
+}
+
+// InGroup 判断 Feed 是否属于指定分组
+func (f Feed) InGroup(group string) bool {
+	for _, g := range f.Groups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+// Transform 条目转换配置
+type Transform struct {
+	// Type 转换类型：regex_replace、strip_tracking_params、unescape_html
+	Type string `json:"type" yaml:"type"`
+	// Field 作用字段：title、link、description、content，为空时作用于 description 和 content
+	Field       string `json:"field" yaml:"field"`
+	Pattern     string `json:"pattern" yaml:"pattern"`
+	Replacement string `json:"replacement" yaml:"replacement"`
 }
 
 type S3Config struct {
@@ -45,20 +240,132 @@ type S3Config struct {
 	SecretAccessKey string `json:"secret_access_key" yaml:"secret_access_key"`
 	UseSSL          bool   `json:"use_ssl" yaml:"use_ssl"`
 	BucketName      string `json:"bucket_name" yaml:"bucket_name"`
+	// SkipProbe 跳过启动时的写入自检
+	SkipProbe bool `json:"skip_probe" yaml:"skip_probe"`
+}
+
+// Complete 是否填写了连接所需的全部字段
+func (c S3Config) Complete() bool {
+	return c.Endpoint != "" && c.AccessKeyID != "" && c.SecretAccessKey != "" && c.BucketName != ""
 }
 
 type AIConfig struct {
-	Endpoint    string  `json:"endpoint" yaml:"endpoint"`
-	APIKey      string  `json:"api_key" yaml:"api_key"`
-	Model       string  `json:"model" yaml:"model"`
-	MaxTokens   int     `json:"max_tokens" yaml:"max_tokens"`
-	Temperature float32 `json:"temperature" yaml:"temperature"`
+	Endpoint  string `json:"endpoint" yaml:"endpoint"`
+	APIKey    string `json:"api_key" yaml:"api_key"`
+	Model     string `json:"model" yaml:"model"`
+	MaxTokens int    `json:"max_tokens" yaml:"max_tokens"`
+	// FallbackModels 主模型重试后仍失败时依次尝试的备用模型
+	FallbackModels []string `json:"fallback_models" yaml:"fallback_models"`
+	// Temperature 采样温度，未设置时为 0.7，显式设置为 0 时输出确定性结果
+	Temperature *float32 `json:"temperature" yaml:"temperature"`
+	// Seed 随机种子，服务端支持时可配合 temperature 0 获得可复现的摘要
+	Seed *int `json:"seed" yaml:"seed"`
+	// BatchSize 单次请求合并总结的条目数，小于等于 1 时逐条总结
+	BatchSize int `json:"batch_size" yaml:"batch_size"`
+	// MaxRetries 可重试错误的最大尝试次数
+	MaxRetries int `json:"max_retries" yaml:"max_retries"`
+	// RetryDelay 重试间隔
+	RetryDelay time.Duration `json:"retry_delay" yaml:"retry_delay"`
+	// RateLimitDelay 被限流时的重试间隔，未设置时按 RetryDelay 指数退避
+	RateLimitDelay time.Duration `json:"rate_limit_delay" yaml:"rate_limit_delay"`
+	// RetryJitter 重试等待时间的随机浮动比例（0~1），避免多个请求同时重试，0 表示不浮动
+	RetryJitter float64 `json:"retry_jitter" yaml:"retry_jitter"`
+	// SummaryCacheSize 内存中缓存的摘要数量，默认 1000，负数表示关闭缓存
+	SummaryCacheSize int `json:"summary_cache_size" yaml:"summary_cache_size"`
+	// GlobalConcurrency 所有 Feed 同时进行的 AI 调用上限，0 表示不限制
+	GlobalConcurrency int `json:"global_concurrency" yaml:"global_concurrency"`
+	// Disabled 关闭 AI 总结，改用正文前几句作为摘要，此时不需要 API key
+	Disabled bool `json:"disabled" yaml:"disabled"`
+	// FallbackSentences 关闭 AI 时摘要包含的句子数，默认 3
+	FallbackSentences int `json:"fallback_sentences" yaml:"fallback_sentences"`
+	// DryRun 只记录将要发送的提示词并返回占位摘要，不调用 API
+	DryRun bool `json:"dry_run" yaml:"dry_run"`
+	// MaxContentLength 发送给 AI 的正文字节数上限，默认 4000
+	MaxContentLength int `json:"max_content_length" yaml:"max_content_length"`
+	// TruncateMode 正文超过上限时的处理方式：silent（默认，只记录指标）、warn（同时输出日志）、error（不截断，直接失败）
+	TruncateMode string `json:"truncate_mode" yaml:"truncate_mode"`
+	// CacheSummaries 在存储的 summaries/ 下按内容哈希缓存摘要，相同内容不再调用 AI
+	CacheSummaries bool `json:"cache_summaries" yaml:"cache_summaries"`
+	// Language 默认提示词的摘要语言：zh（默认）、en
+	Language string `json:"language" yaml:"language"`
+	// PromptTemplate 单篇总结的提示词模板，%s 为正文；为空时按 Language 使用默认提示词
+	PromptTemplate string `json:"prompt_template" yaml:"prompt_template"`
+	// ContinueTruncated 摘要因 max_tokens 被截断时再请求一次续写，否则在摘要末尾加省略号标记
+	ContinueTruncated bool `json:"continue_truncated" yaml:"continue_truncated"`
+}
+
+type HTTPConfig struct {
+	// RequestTimeout 单个请求的处理超时时间
+	RequestTimeout time.Duration `json:"request_timeout" yaml:"request_timeout"`
+	// AdminToken 调试接口使用的 Bearer 令牌，为空时调试接口不可用
+	AdminToken string `json:"admin_token" yaml:"admin_token"`
+	// RawMaxBytes /feeds/:name/raw 返回的最大字节数
+	RawMaxBytes int64 `json:"raw_max_bytes" yaml:"raw_max_bytes"`
+	// BaseURL 服务对外的访问地址，用于生成订阅的 self 链接，为空时根据请求推断
+	BaseURL string `json:"base_url" yaml:"base_url"`
+	// BasePath 所有路由的路径前缀，例如通过反向代理部署在 /unifeed 下时；同时用于生成 self 链接
+	BasePath string `json:"base_path" yaml:"base_path"`
+	// ReadyCheckAI /readyz 是否同时检查 AI 接口
+	ReadyCheckAI bool `json:"ready_check_ai" yaml:"ready_check_ai"`
+	// AccessLog 为每个请求输出 JSON 访问日志
+	AccessLog bool `json:"access_log" yaml:"access_log"`
+	// PartialResults 部分存储条目读取失败时仍输出成功读取的条目，否则整个请求失败
+	PartialResults bool `json:"partial_results" yaml:"partial_results"`
+}
+
+type SocialConfig struct {
+	// CacheTTL Mastodon/Bluesky 渲染结果的缓存时间
+	CacheTTL time.Duration `json:"cache_ttl" yaml:"cache_ttl"`
+	// ResolveEnclosures 是否通过 HEAD 请求补全附件的长度和类型
+	ResolveEnclosures bool `json:"resolve_enclosures" yaml:"resolve_enclosures"`
+	// EnclosureTimeout 单个 HEAD 请求的超时时间
+	EnclosureTimeout time.Duration `json:"enclosure_timeout" yaml:"enclosure_timeout"`
+	// Timeout 拉取一次 Mastodon/Bluesky 时间线的超时时间，默认 20s
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
 }
 
 type SchedulerConfig struct {
 	UpdateInterval time.Duration `json:"update_interval" yaml:"update_interval"`
 	MaxRetries     int           `json:"max_retries" yaml:"max_retries"`
 	RetryDelay     time.Duration `json:"retry_delay" yaml:"retry_delay"`
+	// MinUpdateInterval 更新间隔的下限，全局和 Feed 的 update_interval 低于下限时提升到下限，默认 1 分钟
+	MinUpdateInterval time.Duration `json:"min_update_interval" yaml:"min_update_interval"`
+	// RetryJitter 读取存储条目重试等待时间的随机浮动比例（0~1），0 表示不浮动
+	RetryJitter float64 `json:"retry_jitter" yaml:"retry_jitter"`
+	// FailureBackoff 更新失败后首次重试的间隔，之后指数增长直至 UpdateInterval
+	FailureBackoff time.Duration `json:"failure_backoff" yaml:"failure_backoff"`
+	// SkipUnchanged 上游内容哈希与上次更新相同时跳过摘要和存储
+	SkipUnchanged bool `json:"skip_unchanged" yaml:"skip_unchanged"`
+	// ErrorLogWindow 同一 Feed 相同错误的日志采样窗口，为 0 时不采样
+	ErrorLogWindow time.Duration `json:"error_log_window" yaml:"error_log_window"`
+	// ErrorLogEvery 采样窗口内每 N 次相同错误输出一次日志，为 0 时窗口内只输出首次
+	ErrorLogEvery int `json:"error_log_every" yaml:"error_log_every"`
+	// FastStart 首次更新只存储条目不生成摘要，之后每次更新逐步补全
+	FastStart bool `json:"fast_start" yaml:"fast_start"`
+	// BackfillBatch 快速启动后每次更新补全摘要的条目数，默认 5
+	BackfillBatch int `json:"backfill_batch" yaml:"backfill_batch"`
+	// BootConcurrency 启动时同时进行首次更新的 Feed 数上限，0 表示不限制
+	BootConcurrency int `json:"boot_concurrency" yaml:"boot_concurrency"`
+	// BootStagger 启动时将各 Feed 的首次更新均匀分散到该时间窗口内
+	BootStagger time.Duration `json:"boot_stagger" yaml:"boot_stagger"`
+	// FetchTimeout 拉取上游 RSS Feed 的超时时间，默认 30s
+	FetchTimeout time.Duration `json:"fetch_timeout" yaml:"fetch_timeout"`
+	// DeadLetterAfter 条目连续存储或总结失败达到该次数后写入 deadletter/<feed>/，0 表示关闭
+	DeadLetterAfter int `json:"dead_letter_after" yaml:"dead_letter_after"`
+	// ItemCountAlarmRatio 上游条目数低于最近平均值的该比例时告警（如 0.2），0 表示关闭
+	ItemCountAlarmRatio float64 `json:"item_count_alarm_ratio" yaml:"item_count_alarm_ratio"`
+	// ItemCountWindow 平均条目数统计的最近更新次数，默认 10
+	ItemCountWindow int `json:"item_count_window" yaml:"item_count_window"`
+	// MaxConcurrency 读取或写入一个 Feed 的条目时同时进行的存储请求数上限，默认 16
+	MaxConcurrency int `json:"max_concurrency" yaml:"max_concurrency"`
+	// StoreConcurrency 写入条目时同时进行的存储请求数上限，默认与 MaxConcurrency 相同
+	StoreConcurrency int `json:"store_concurrency" yaml:"store_concurrency"`
+	// StoreBatchSize 每个写入任务依次写入的条目数，默认 1
+	StoreBatchSize int `json:"store_batch_size" yaml:"store_batch_size"`
+	// DependencyBackoff 更新失败且 S3 或 AI 检查不可用时全局暂停更新的初始间隔，之后指数增长直至 UpdateInterval，0 表示关闭
+	DependencyBackoff time.Duration `json:"dependency_backoff" yaml:"dependency_backoff"`
+	// ShutdownTimeout 停止所有任务时等待进行中的更新完成的最长时间，默认 30 秒
+	ShutdownTimeout time.Duration `json:"shutdown_timeout" yaml:"shutdown_timeout"`
 }
 
 func (c *Config) Print() {
@@ -77,21 +384,166 @@ func (c *Config) Print() {
 }
 
 func LoadConfigFromFile(path string) (*Config, error) {
-	f, err := os.Open(path)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("open config: %w", err)
 	}
-	defer f.Close()
-	var cfg Config
-	if err := json.NewDecoder(f).Decode(&cfg); err != nil {
-		return nil, fmt.Errorf("decode config: %w", err)
+	return parseConfig(path, data)
+}
+
+// remoteConfigTimeout 拉取远程配置的超时时间
+const remoteConfigTimeout = 30 * time.Second
+
+// maxRemoteConfigBytes 远程配置的大小上限
+const maxRemoteConfigBytes = 10 << 20
+
+// LoadConfigFromURL 通过 HTTP(S) 拉取配置，格式按 URL 路径的扩展名判断
+func LoadConfigFromURL(ctx context.Context, rawURL string) (*Config, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse config url: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, remoteConfigTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create config request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch config: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch config: unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteConfigBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	if len(data) > maxRemoteConfigBytes {
+		return nil, fmt.Errorf("config exceeds %d bytes", maxRemoteConfigBytes)
+	}
+	return parseConfig(u.Path, data)
+}
+
+// LoadConfig 从文件路径或 http(s) URL 加载配置
+func LoadConfig(ctx context.Context, source string) (*Config, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return LoadConfigFromURL(ctx, source)
+	}
+	return LoadConfigFromFile(source)
+}
+
+// parseConfig 解码配置，替换环境变量引用并校验
+func parseConfig(name string, data []byte) (*Config, error) {
+	cfg, err := decodeConfig(name, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.resolveEnv(); err != nil {
+		return nil, err
 	}
 	if err := cfg.Validate(); err != nil {
 		return nil, err
 	}
+	return cfg, nil
+}
+
+// decodeConfig 按扩展名选择 YAML 或 JSON 解码，未知扩展名先尝试 YAML 再尝试 JSON
+func decodeConfig(path string, data []byte) (*Config, error) {
+	var cfg Config
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("decode json config: %w", err)
+		}
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("decode yaml config: %w", err)
+		}
+	default:
+		yamlErr := yaml.Unmarshal(data, &cfg)
+		if yamlErr == nil {
+			break
+		}
+		cfg = Config{}
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("decode config as yaml (%v) or json: %w", yamlErr, err)
+		}
+	}
 	return &cfg, nil
 }
 
+// envRef 匹配 ${ENV_VAR} 形式的环境变量引用
+var envRef = regexp.MustCompile(`^\$\{([A-Za-z_][A-Za-z0-9_]*)\}$`)
+
+// expandEnv 将 ${ENV_VAR} 形式的值替换为环境变量，变量未设置时返回错误
+func expandEnv(field string, value *string) error {
+	m := envRef.FindStringSubmatch(*value)
+	if m == nil {
+		return nil
+	}
+	v, ok := os.LookupEnv(m[1])
+	if !ok {
+		return fmt.Errorf("%s references unset environment variable %s", field, m[1])
+	}
+	*value = v
+	return nil
+}
+
+// envField 可引用环境变量的配置字段
+type envField struct {
+	name  string
+	value *string
+}
+
+// s3EnvFields 返回 S3 配置中可引用环境变量的字段
+func s3EnvFields(prefix string, s3 *S3Config) []envField {
+	return []envField{
+		{prefix + ".endpoint", &s3.Endpoint},
+		{prefix + ".access_key_id", &s3.AccessKeyID},
+		{prefix + ".secret_access_key", &s3.SecretAccessKey},
+		{prefix + ".bucket_name", &s3.BucketName},
+	}
+}
+
+// resolveEnv 替换密钥等字段中的环境变量引用，便于容器部署时不在配置文件中存放明文
+func (c *Config) resolveEnv() error {
+	fields := s3EnvFields("s3", &c.S3)
+	fields = append(fields,
+		envField{"ai.endpoint", &c.AI.Endpoint},
+		envField{"ai.api_key", &c.AI.APIKey},
+		envField{"ai.model", &c.AI.Model},
+	)
+	for i := range c.Feeds {
+		feed := &c.Feeds[i]
+		fields = append(fields,
+			envField{fmt.Sprintf("feeds[%s].mastodon.token", feed.Name), &feed.Mastodon.Token},
+			envField{fmt.Sprintf("feeds[%s].bluesky.app_secret", feed.Name), &feed.Bluesky.AppSecret},
+			envField{fmt.Sprintf("feeds[%s].ai.endpoint", feed.Name), &feed.AI.Endpoint},
+			envField{fmt.Sprintf("feeds[%s].ai.api_key", feed.Name), &feed.AI.APIKey},
+		)
+	}
+	for _, f := range fields {
+		if err := expandEnv(f.name, f.value); err != nil {
+			return err
+		}
+	}
+
+	// map 中的值不可寻址，替换后写回
+	for name, profile := range c.Storage.Profiles {
+		for _, f := range s3EnvFields("storage.profiles."+name, &profile) {
+			if err := expandEnv(f.name, f.value); err != nil {
+				return err
+			}
+		}
+		c.Storage.Profiles[name] = profile
+	}
+	return nil
+}
+
 func (c *Config) Validate() error {
 	if len(c.Feeds) == 0 {
 		return fmt.Errorf("no feeds configured")
@@ -99,34 +551,275 @@ func (c *Config) Validate() error {
 
 	// 验证 Feed 配置
 	for _, feed := range c.Feeds {
-		if feed.Name == "" {
-			return fmt.Errorf("feed name required")
-		}
-		if feed.Mastodon.Host == "" && feed.Bluesky.Host == "" && feed.RssFeed == "" {
-			return fmt.Errorf("feed %s: at least one source required", feed.Name)
+		if err := c.ValidateFeed(feed); err != nil {
+			return err
 		}
 	}
 
 	// 验证 S3 配置
-	if c.S3.Endpoint == "" || c.S3.AccessKeyID == "" || c.S3.SecretAccessKey == "" || c.S3.BucketName == "" {
+	if !c.S3.Complete() {
 		return fmt.Errorf("S3 configuration incomplete")
 	}
+	for name, profile := range c.Storage.Profiles {
+		if !profile.Complete() {
+			return fmt.Errorf("storage profile %s: S3 configuration incomplete", name)
+		}
+	}
 
 	// 验证 AI 配置
-	if c.AI.APIKey == "" {
+	if c.AI.APIKey == "" && !c.AI.Disabled {
 		return fmt.Errorf("AI API key required")
 	}
 
+	if c.AI.GlobalConcurrency < 0 {
+		return fmt.Errorf("ai global_concurrency must not be negative")
+	}
+	if c.AI.MaxContentLength < 0 {
+		return fmt.Errorf("ai max_content_length must not be negative")
+	}
+	switch c.AI.TruncateMode {
+	case "", "silent", "warn", "error":
+	default:
+		return fmt.Errorf("ai truncate_mode must be one of silent, warn, error")
+	}
+	switch c.AI.Language {
+	case "", "zh", "en":
+	default:
+		return fmt.Errorf("ai language must be zh or en")
+	}
+	if c.AI.PromptTemplate != "" && !strings.Contains(c.AI.PromptTemplate, "%s") {
+		return fmt.Errorf("ai prompt_template must contain %%s for the content")
+	}
+	if c.AI.RetryJitter < 0 || c.AI.RetryJitter > 1 {
+		return fmt.Errorf("ai retry_jitter must be between 0 and 1")
+	}
+
 	// 验证调度器配置
 	if c.Scheduler.UpdateInterval == 0 {
 		c.Scheduler.UpdateInterval = time.Hour
 	}
+	if c.Scheduler.MinUpdateInterval < 0 {
+		return fmt.Errorf("scheduler min_update_interval must not be negative")
+	}
+	c.ApplyIntervalFloors()
 	if c.Scheduler.MaxRetries == 0 {
 		c.Scheduler.MaxRetries = 3
 	}
 	if c.Scheduler.RetryDelay == 0 {
 		c.Scheduler.RetryDelay = time.Second * 5
 	}
+	if c.Scheduler.ErrorLogWindow < 0 || c.Scheduler.ErrorLogEvery < 0 {
+		return fmt.Errorf("scheduler error log sampling must not be negative")
+	}
+	if c.Scheduler.BootConcurrency < 0 || c.Scheduler.BootStagger < 0 {
+		return fmt.Errorf("scheduler boot_concurrency and boot_stagger must not be negative")
+	}
+	if c.Scheduler.BackfillBatch < 0 {
+		return fmt.Errorf("scheduler backfill_batch must not be negative")
+	}
+	if c.Scheduler.FetchTimeout < 0 {
+		return fmt.Errorf("scheduler fetch_timeout must not be negative")
+	}
+	if c.Scheduler.DeadLetterAfter < 0 {
+		return fmt.Errorf("scheduler dead_letter_after must not be negative")
+	}
+	if c.Scheduler.ItemCountAlarmRatio < 0 || c.Scheduler.ItemCountAlarmRatio > 1 {
+		return fmt.Errorf("scheduler item_count_alarm_ratio must be between 0 and 1")
+	}
+	if c.Scheduler.ItemCountWindow < 0 {
+		return fmt.Errorf("scheduler item_count_window must not be negative")
+	}
+	if c.Scheduler.MaxConcurrency < 0 || c.Scheduler.StoreConcurrency < 0 || c.Scheduler.StoreBatchSize < 0 {
+		return fmt.Errorf("scheduler max_concurrency, store_concurrency and store_batch_size must not be negative")
+	}
+	if c.Scheduler.DependencyBackoff < 0 {
+		return fmt.Errorf("scheduler dependency_backoff must not be negative")
+	}
+	if c.Scheduler.ShutdownTimeout < 0 {
+		return fmt.Errorf("scheduler shutdown_timeout must not be negative")
+	}
+	if c.Scheduler.RetryJitter < 0 || c.Scheduler.RetryJitter > 1 {
+		return fmt.Errorf("scheduler retry_jitter must be between 0 and 1")
+	}
+	if c.Scheduler.FailureBackoff < 0 {
+		return fmt.Errorf("scheduler failure_backoff must not be negative")
+	}
+	if c.Scheduler.FailureBackoff == 0 {
+		c.Scheduler.FailureBackoff = time.Minute
+	}
+
+	if err := validateItemKeyTemplate(c.Storage.ItemKeyTemplate); err != nil {
+		return err
+	}
+
+	// 验证社交源配置
+	if c.Social.CacheTTL < 0 {
+		return fmt.Errorf("social cache_ttl must not be negative")
+	}
+	if c.Social.CacheTTL == 0 {
+		c.Social.CacheTTL = time.Minute * 5
+	}
+	if c.Social.EnclosureTimeout < 0 {
+		return fmt.Errorf("social enclosure_timeout must not be negative")
+	}
+	if c.Social.EnclosureTimeout == 0 {
+		c.Social.EnclosureTimeout = time.Second * 5
+	}
+	if c.Social.Timeout < 0 {
+		return fmt.Errorf("social timeout must not be negative")
+	}
+	if c.Social.Timeout == 0 {
+		c.Social.Timeout = time.Second * 20
+	}
+
+	// 验证 HTTP 配置
+	if c.HTTP.RequestTimeout < 0 {
+		return fmt.Errorf("http request_timeout must not be negative")
+	}
+	if c.HTTP.RequestTimeout == 0 {
+		c.HTTP.RequestTimeout = time.Second * 30
+	}
+	if c.HTTP.RawMaxBytes < 0 {
+		return fmt.Errorf("http raw_max_bytes must not be negative")
+	}
+	if c.HTTP.RawMaxBytes == 0 {
+		c.HTTP.RawMaxBytes = 1 << 20
+	}
+	if c.HTTP.BasePath != "" && !strings.HasPrefix(c.HTTP.BasePath, "/") {
+		return fmt.Errorf("http base_path must start with /")
+	}
+	c.HTTP.BasePath = strings.TrimSuffix(c.HTTP.BasePath, "/")
+
+	// 验证 WebSub 配置
+	if c.WebSub.Enabled && c.HTTP.BaseURL == "" {
+		return fmt.Errorf("websub requires http.base_url for the callback URL")
+	}
+	if c.WebSub.Lease < 0 {
+		return fmt.Errorf("websub lease must not be negative")
+	}
+	if c.WebSub.Lease == 0 {
+		c.WebSub.Lease = 24 * time.Hour
+	}
+
+	// 验证预览配置
+	if c.Preview.MaxBytes < 0 || c.Preview.MaxSummaries < 0 || c.Preview.Timeout < 0 {
+		return fmt.Errorf("preview max_bytes, max_summaries and timeout must not be negative")
+	}
+
+	return nil
+}
 
+// ApplyIntervalFloors 将全局和各 Feed 低于 scheduler.min_update_interval 的更新间隔提升到下限，
+// 下限未设置时使用 1 分钟；未设置的更新间隔保持不变。未经 Validate 的配置在创建调度器前也需要调用
+func (c *Config) ApplyIntervalFloors() {
+	if c.Scheduler.MinUpdateInterval <= 0 {
+		c.Scheduler.MinUpdateInterval = time.Minute
+	}
+	if c.Scheduler.UpdateInterval > 0 && c.Scheduler.UpdateInterval < c.Scheduler.MinUpdateInterval {
+		slog.Warn("Raised scheduler update_interval to the minimum",
+			"update_interval", c.Scheduler.UpdateInterval,
+			"min_update_interval", c.Scheduler.MinUpdateInterval,
+		)
+		c.Scheduler.UpdateInterval = c.Scheduler.MinUpdateInterval
+	}
+	for i := range c.Feeds {
+		c.Feeds[i] = c.ApplyIntervalFloor(c.Feeds[i])
+	}
+}
+
+// ApplyIntervalFloor 将低于 scheduler.min_update_interval 的 Feed 更新间隔提升到下限并输出警告，
+// 未设置间隔的 Feed 使用已提升过的全局间隔，不做处理
+func (c *Config) ApplyIntervalFloor(feed Feed) Feed {
+	if feed.UpdateInterval > 0 && feed.UpdateInterval < c.Scheduler.MinUpdateInterval {
+		slog.Warn("Raised feed update_interval to the minimum",
+			"feed_name", feed.Name,
+			"update_interval", feed.UpdateInterval,
+			"min_update_interval", c.Scheduler.MinUpdateInterval,
+		)
+		feed.UpdateInterval = c.Scheduler.MinUpdateInterval
+	}
+	return feed
+}
+
+// ValidateFeed 检查单个 Feed 的配置，存储配置等引用以 c 为准；运行期间增加 Feed 时也使用
+func (c *Config) ValidateFeed(feed Feed) error {
+	if feed.Name == "" {
+		return fmt.Errorf("feed name required")
+	}
+	if feed.Mastodon.Host == "" && feed.Bluesky.Host == "" && feed.RssFeed == "" {
+		return fmt.Errorf("feed %s: at least one source required", feed.Name)
+	}
+	if feed.MaxFetchItems < 0 {
+		return fmt.Errorf("feed %s: max_fetch_items must not be negative", feed.Name)
+	}
+	if feed.MaxStoredItems < 0 {
+		return fmt.Errorf("feed %s: max_stored_items must not be negative", feed.Name)
+	}
+	if feed.UpdateInterval < 0 {
+		return fmt.Errorf("feed %s: update_interval must not be negative", feed.Name)
+	}
+	if feed.StorageProfile != "" {
+		if _, ok := c.Storage.Profiles[feed.StorageProfile]; !ok {
+			return fmt.Errorf("feed %s: unknown storage_profile %s", feed.Name, feed.StorageProfile)
+		}
+	}
+	if feed.AI.DailyTokenBudget < 0 || feed.AI.DailyRequestBudget < 0 {
+		return fmt.Errorf("feed %s: ai daily budgets must not be negative", feed.Name)
+	}
+	if feed.AI.Endpoint == "" && (feed.AI.APIKey != "" || feed.AI.Model != "") {
+		return fmt.Errorf("feed %s: ai.api_key and ai.model require ai.endpoint", feed.Name)
+	}
+	if feed.Mastodon.Pages < 0 || feed.Bluesky.Pages < 0 {
+		return fmt.Errorf("feed %s: pages must not be negative", feed.Name)
+	}
+	if _, _, err := feed.Mastodon.ParseTimeline(); err != nil {
+		return fmt.Errorf("feed %s: %w", feed.Name, err)
+	}
+	if feed.Mastodon.MaxItems < 0 {
+		return fmt.Errorf("feed %s: mastodon.max_items must not be negative", feed.Name)
+	}
+	if feed.ContentTemplate != "" {
+		if _, err := template.New(feed.Name).Parse(feed.ContentTemplate); err != nil {
+			return fmt.Errorf("feed %s: invalid content_template: %w", feed.Name, err)
+		}
+	}
+	if feed.Bluesky.Host != "" {
+		if _, err := syntax.ParseAtIdentifier(strings.TrimPrefix(feed.Bluesky.Handle, "@")); err != nil {
+			return fmt.Errorf("feed %s: bluesky handle must be a handle or DID: %w", feed.Name, err)
+		}
+	}
+	return nil
+}
+
+// itemKeyPlaceholder 匹配存储路径模板中的占位符
+var itemKeyPlaceholder = regexp.MustCompile(`\{[^{}]*\}`)
+
+// validateItemKeyTemplate 检查条目存储路径模板：只能使用已知占位符，{id} 必须在最后一段，
+// 且第一个非 {feed} 占位符之前的部分包含 {feed} 并以 / 结尾，保证各 Feed 的条目前缀互不重叠
+func validateItemKeyTemplate(template string) error {
+	if template == "" {
+		return nil
+	}
+	for _, placeholder := range itemKeyPlaceholder.FindAllString(template, -1) {
+		switch placeholder {
+		case "{feed}", "{id}", "{yyyy}", "{mm}", "{dd}":
+		default:
+			return fmt.Errorf("storage item_key_template has unknown placeholder %s", placeholder)
+		}
+	}
+	idx := strings.Index(template, "{id}")
+	if idx < 0 || strings.Count(template, "{id}") > 1 || strings.Contains(template[idx:], "/") {
+		return fmt.Errorf("storage item_key_template must contain {id} once in its last path segment")
+	}
+	prefix := template[:idx]
+	for _, loc := range itemKeyPlaceholder.FindAllStringIndex(prefix, -1) {
+		if prefix[loc[0]:loc[1]] != "{feed}" {
+			prefix = prefix[:loc[0]]
+			break
+		}
+	}
+	if !strings.Contains(prefix, "{feed}") || !strings.HasSuffix(prefix, "/") {
+		return fmt.Errorf("storage item_key_template must start with a directory containing {feed}")
+	}
 	return nil
 }
@@ -0,0 +1,35 @@
+package dao
+
+import (
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// mediaTypes 常见媒体扩展名，部分系统的 MIME 表中缺少这些类型
+var mediaTypes = map[string]string{
+	".mp3":  "audio/mpeg",
+	".m4a":  "audio/mp4",
+	".aac":  "audio/aac",
+	".ogg":  "audio/ogg",
+	".opus": "audio/opus",
+	".wav":  "audio/wav",
+	".mp4":  "video/mp4",
+	".webm": "video/webm",
+	".mov":  "video/quicktime",
+}
+
+// DetectContentType 推断对象的 Content-Type，优先按扩展名，无法识别时按内容嗅探；S3Client.PutObject 未指定类型时调用
+func DetectContentType(objectName string, data []byte) string {
+	ext := strings.ToLower(path.Ext(objectName))
+	if ext != "" {
+		if t, ok := mediaTypes[ext]; ok {
+			return t
+		}
+		if t := mime.TypeByExtension(ext); t != "" {
+			return t
+		}
+	}
+	return http.DetectContentType(data)
+}
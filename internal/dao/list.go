@@ -0,0 +1,55 @@
+package dao
+
+import (
+	"context"
+	"sort"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// SortBy 对象列表的排序字段
+type SortBy string
+
+const (
+	SortByKey          SortBy = "key"
+	SortByLastModified SortBy = "last_modified"
+)
+
+// ListOptions 有序列举选项
+type ListOptions struct {
+	SortBy SortBy
+	// Desc 为 true 时倒序排列
+	Desc bool
+	// Limit 返回的最大数量，0 表示不限制
+	Limit int
+}
+
+// ListObjectsSorted 列出指定前缀的对象，并按选项排序和截取
+func ListObjectsSorted(ctx context.Context, store ObjectStore, prefix string, opts ListOptions) ([]minio.ObjectInfo, error) {
+	objects, err := store.ListObjects(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	SortObjects(objects, opts.SortBy, opts.Desc)
+	if opts.Limit > 0 && len(objects) > opts.Limit {
+		objects = objects[:opts.Limit]
+	}
+	return objects, nil
+}
+
+// SortObjects 按指定字段对对象排序，相同修改时间时按 key 排序保证结果稳定
+func SortObjects(objects []minio.ObjectInfo, by SortBy, desc bool) {
+	less := func(a, b minio.ObjectInfo) bool {
+		if by == SortByLastModified && !a.LastModified.Equal(b.LastModified) {
+			return a.LastModified.Before(b.LastModified)
+		}
+		return a.Key < b.Key
+	}
+	sort.SliceStable(objects, func(i, j int) bool {
+		if desc {
+			return less(objects[j], objects[i])
+		}
+		return less(objects[i], objects[j])
+	})
+}
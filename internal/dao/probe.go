@@ -0,0 +1,26 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ProbePrefix 自检对象的存储前缀
+const ProbePrefix = "healthcheck/"
+
+// ProbeWritable 写入并删除一个探测对象，用于在启动时确认存储可写
+func ProbeWritable(ctx context.Context, store ObjectStore) error {
+	objectName := fmt.Sprintf("%sprobe-%d.txt", ProbePrefix, time.Now().UnixNano())
+
+	if err := store.PutObject(ctx, objectName, []byte("ok"), PutOptions{
+		ContentType: "text/plain",
+		Tags:        map[string]string{TagType: "probe"},
+	}); err != nil {
+		return fmt.Errorf("failed to write probe object %s: %w", objectName, err)
+	}
+	if err := store.RemoveObject(ctx, objectName); err != nil {
+		return fmt.Errorf("failed to remove probe object %s: %w", objectName, err)
+	}
+	return nil
+}
@@ -6,22 +6,79 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/tags"
 	"go.orx.me/apps/unifeed/internal/conf"
 )
 
+// ObjectStore 对象存储接口，S3Client 为其默认实现
+type ObjectStore interface {
+	PutObject(ctx context.Context, objectName string, data []byte, opts PutOptions) error
+	GetObject(ctx context.Context, objectName string) (io.Reader, error)
+	ListObjects(ctx context.Context, prefix string) ([]minio.ObjectInfo, error)
+	RemoveObject(ctx context.Context, objectName string) error
+}
+
+// 对象标签的键，供存储生命周期规则匹配
+const (
+	TagFeed    = "feed"
+	TagType    = "type"
+	TagCreated = "created"
+)
+
+// maxTagValueLength S3 对象标签值的最大字符数
+const maxTagValueLength = 256
+
+// TagValue 将标签值转换为 S3 接受的形式：字母、数字和 +-._:/@ = 以外的字符替换为 _，超过 256 个字符时截断
+func TagValue(value string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range value {
+		if n == maxTagValueLength {
+			break
+		}
+		if !tagRuneAllowed(r) {
+			r = '_'
+		}
+		b.WriteRune(r)
+		n++
+	}
+	return b.String()
+}
+
+// tagRuneAllowed 字符是否可以出现在 S3 对象标签中
+func tagRuneAllowed(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	default:
+		return strings.ContainsRune("+-._:/@ =", r)
+	}
+}
+
+// PutOptions 写入对象的选项
+type PutOptions struct {
+	ContentType string
+	// Tags 对象标签，写入 S3 的 UserTags；值需经过 TagValue 转换，否则写入失败
+	Tags map[string]string
+}
+
 type S3Client struct {
 	client     *minio.Client
 	bucketName string
 }
 
-// NewS3Client 创建一个新的 S3 客户端实例
+// NewS3Client 使用默认 s3 配置创建 S3 客户端实例
 func NewS3Client() (*S3Client, error) {
+	return NewS3ClientWithConfig(conf.Current().S3)
+}
 
-	config := conf.Conf.S3
+// NewS3ClientWithConfig 使用指定配置创建 S3 客户端实例
+func NewS3ClientWithConfig(config conf.S3Config) (*S3Client, error) {
 	client, err := minio.New(config.Endpoint, &minio.Options{
 		Creds:  credentials.NewStaticV4(config.AccessKeyID, config.SecretAccessKey, ""),
 		Secure: config.UseSSL,
@@ -58,10 +115,20 @@ func NewS3Client() (*S3Client, error) {
 	return s3Client, nil
 }
 
-// PutObject 上传对象到 S3
-func (s *S3Client) PutObject(ctx context.Context, objectName string, data []byte, contentType string) error {
+// PutObject 上传对象到 S3，未指定 ContentType 时自动推断；标签无效时返回错误，
+// 避免 minio 丢弃整个标签头后写入没有标签的对象
+func (s *S3Client) PutObject(ctx context.Context, objectName string, data []byte, opts PutOptions) error {
+	if len(opts.Tags) > 0 {
+		if _, err := tags.NewTags(opts.Tags, true); err != nil {
+			return fmt.Errorf("invalid object tags for %s: %w", objectName, err)
+		}
+	}
+	if opts.ContentType == "" {
+		opts.ContentType = DetectContentType(objectName, data)
+	}
 	_, err := s.client.PutObject(ctx, s.bucketName, objectName, io.Reader(bytes.NewReader(data)), int64(len(data)), minio.PutObjectOptions{
-		ContentType: contentType,
+		ContentType: opts.ContentType,
+		UserTags:    opts.Tags,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to put object: %w", err)
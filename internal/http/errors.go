@@ -0,0 +1,114 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.orx.me/apps/unifeed/internal/logger"
+	"go.orx.me/apps/unifeed/internal/service"
+)
+
+// 错误响应中的错误码
+const (
+	codeBadRequest      = "bad_request"
+	codeUnauthorized    = "unauthorized"
+	codeForbidden       = "forbidden"
+	codeNotFound        = "not_found"
+	codeConflict        = "conflict"
+	codePayloadTooLarge = "payload_too_large"
+	codeNotImplemented  = "not_implemented"
+	codeTimeout         = "timeout"
+	codeUpstream        = "upstream_error"
+	codeInternal        = "internal_error"
+)
+
+// errorBody 错误响应的内容，所有接口都以 {"error": errorBody} 返回错误
+type errorBody struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// typedError 已知错误类型对应的状态码和错误码，message 为空时使用错误本身的信息
+type typedError struct {
+	err     error
+	status  int
+	code    string
+	message string
+}
+
+// typedErrors 可直接返回给客户端的错误类型，其余错误只返回通用信息
+var typedErrors = []typedError{
+	{service.ErrFeedNotFound, http.StatusNotFound, codeNotFound, "feed not found"},
+	{service.ErrItemNotFound, http.StatusNotFound, codeNotFound, "item not found"},
+	{service.ErrFeedExists, http.StatusConflict, codeConflict, ""},
+	{service.ErrJobExists, http.StatusConflict, codeConflict, "update job already exists"},
+	{service.ErrJobNotFound, http.StatusNotFound, codeNotFound, "job not found"},
+	{service.ErrInvalidFeed, http.StatusBadRequest, codeBadRequest, ""},
+	{service.ErrPreviewURL, http.StatusBadRequest, codeBadRequest, service.ErrPreviewURL.Error()},
+	{service.ErrPreviewBlocked, http.StatusForbidden, codeForbidden, service.ErrPreviewBlocked.Error()},
+	{service.ErrPreviewTooLarge, http.StatusRequestEntityTooLarge, codePayloadTooLarge, service.ErrPreviewTooLarge.Error()},
+}
+
+// respondError 以统一格式返回错误并中止后续处理
+func respondError(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, gin.H{"error": errorBody{
+		Code:      code,
+		Message:   message,
+		RequestID: requestIDFrom(c),
+	}})
+}
+
+// respondTyped 按已知错误类型返回错误，请求超时时返回 503；无法识别时返回 false
+func respondTyped(c *gin.Context, err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(c.Request.Context().Err(), context.DeadlineExceeded) {
+		respondError(c, http.StatusServiceUnavailable, codeTimeout, "request timed out")
+		return true
+	}
+	for _, t := range typedErrors {
+		if errors.Is(err, t.err) {
+			message := t.message
+			if message == "" {
+				message = err.Error()
+			}
+			respondError(c, t.status, t.code, message)
+			return true
+		}
+	}
+	return false
+}
+
+// publicError 返回可在响应中展示的错误信息：已知错误类型使用 typedErrors 中的信息，
+// 其余错误可能包含上游地址、存储端点等内部信息，只返回 fallback，详情写入日志
+func publicError(c *gin.Context, err error, fallback string) string {
+	for _, t := range typedErrors {
+		if errors.Is(err, t.err) {
+			if t.message != "" {
+				return t.message
+			}
+			return err.Error()
+		}
+	}
+	logger.Info("Error hidden from response", "path", c.Request.URL.Path, "request_id", requestIDFrom(c), "error", err)
+	return fallback
+}
+
+// internalError 返回 500，错误详情只写入日志
+func internalError(c *gin.Context, err error) {
+	if respondTyped(c, err) {
+		return
+	}
+	logger.Info("Request failed", "path", c.Request.URL.Path, "request_id", requestIDFrom(c), "error", err)
+	respondError(c, http.StatusInternalServerError, codeInternal, "internal error")
+}
+
+// upstreamError 返回下游调用错误，请求超时时返回 503，其余未知错误返回 502，错误详情只写入日志
+func upstreamError(c *gin.Context, err error) {
+	if respondTyped(c, err) {
+		return
+	}
+	logger.Info("Upstream request failed", "path", c.Request.URL.Path, "request_id", requestIDFrom(c), "error", err)
+	respondError(c, http.StatusBadGateway, codeUpstream, "upstream request failed")
+}
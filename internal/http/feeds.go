@@ -0,0 +1,78 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.orx.me/apps/unifeed/internal/conf"
+	"go.orx.me/apps/unifeed/internal/logger"
+)
+
+// feedEntry 订阅列表中的单个 Feed，runtime 表示运行期间增加
+type feedEntry struct {
+	conf.Feed
+	Runtime bool `json:"runtime"`
+}
+
+// redactFeed 清除 Feed 中的令牌和密钥
+func redactFeed(feed conf.Feed) conf.Feed {
+	feed.Mastodon.Token = ""
+	feed.Bluesky.AppKey = ""
+	feed.Bluesky.AppSecret = ""
+	feed.AI.APIKey = ""
+	return feed
+}
+
+// listFeeds 返回当前生效的全部 Feed，令牌和密钥不返回
+func (h *Handler) listFeeds(c *gin.Context) {
+	feeds := conf.Current().Feeds
+	entries := make([]feedEntry, 0, len(feeds))
+	for _, feed := range feeds {
+		entries = append(entries, feedEntry{Feed: redactFeed(feed), Runtime: h.feeds.IsRuntime(feed.Name)})
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// addFeed 增加请求体中的 Feed 并为 RSS Feed 启动更新任务
+func (h *Handler) addFeed(c *gin.Context) {
+	var feed conf.Feed
+	if err := c.ShouldBindJSON(&feed); err != nil {
+		respondError(c, http.StatusBadRequest, codeBadRequest, err.Error())
+		return
+	}
+
+	feed, err := h.feeds.Add(c.Request.Context(), feed)
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+
+	h.startFeedJob(c, feed)
+	c.JSON(http.StatusCreated, feedEntry{Feed: redactFeed(feed), Runtime: true})
+}
+
+// removeFeed 删除 Feed 并停止其更新任务，已存储的条目保留
+func (h *Handler) removeFeed(c *gin.Context) {
+	name := c.Param("name")
+	if err := h.feeds.Remove(c.Request.Context(), name); err != nil {
+		internalError(c, err)
+		return
+	}
+
+	// 社交源没有更新任务
+	if err := h.schedulerService.StopJob(name); err != nil {
+		logger.Debug("No job to stop for removed feed", "feed_name", name)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "feed removed"})
+}
+
+// startFeedJob 为运行期间增加的 RSS Feed 启动更新任务，任务不随请求结束
+func (h *Handler) startFeedJob(c *gin.Context, feed conf.Feed) {
+	if feed.RssFeed == "" {
+		return
+	}
+	if err := h.schedulerService.StartJob(context.WithoutCancel(c.Request.Context()), feed); err != nil {
+		logger.Warn("Failed to start job for added feed", "feed_name", feed.Name, "error", err)
+	}
+}
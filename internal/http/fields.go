@@ -0,0 +1,69 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.orx.me/apps/unifeed/internal/service"
+)
+
+// itemFields ?fields= 可选择的字段，与 json-items 的字段一致
+var itemFields = jsonFieldNames(reflect.TypeOf(service.FeedItem{}))
+
+// jsonFieldNames 返回结构体的 JSON 字段名
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// parseFields 解析逗号分隔的字段列表，参数为空时返回 nil
+func parseFields(raw string) ([]string, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var fields []string
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if !itemFields[field] {
+			return nil, fmt.Errorf("unknown field %q", field)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// projectItems 只保留条目中请求的字段，条目没有的字段不输出
+func projectItems(items []map[string]interface{}, fields []string) []map[string]interface{} {
+	projected := make([]map[string]interface{}, len(items))
+	for i, item := range items {
+		p := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			if v, ok := item[field]; ok {
+				p[field] = v
+			}
+		}
+		projected[i] = p
+	}
+	return projected
+}
+
+// itemMaps 将结构化条目转换为 map 以便投影
+func itemMaps(items []service.FeedItem) ([]map[string]interface{}, error) {
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, fmt.Errorf("marshal items: %w", err)
+	}
+	var maps []map[string]interface{}
+	if err := json.Unmarshal(data, &maps); err != nil {
+		return nil, fmt.Errorf("unmarshal items: %w", err)
+	}
+	return maps, nil
+}
@@ -0,0 +1,47 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.orx.me/apps/unifeed/internal/conf"
+	"go.orx.me/apps/unifeed/internal/logger"
+	"go.orx.me/apps/unifeed/internal/service"
+)
+
+// getGroup 合并分组内所有 RSS Feed 的条目，按发布时间倒序返回
+func (h *Handler) getGroup(c *gin.Context) {
+	group := c.Param("group")
+
+	var feeds []conf.Feed
+	for _, f := range conf.Current().Feeds {
+		if f.InGroup(group) {
+			feeds = append(feeds, f)
+		}
+	}
+	if len(feeds) == 0 {
+		respondError(c, http.StatusNotFound, codeNotFound, "group not found")
+		return
+	}
+
+	items := make([]map[string]interface{}, 0)
+	for _, feed := range feeds {
+		if feed.RssFeed == "" {
+			// 社交源没有存储的条目
+			logger.Debug("Skipping non-RSS feed in group", "group", group, "feed_name", feed.Name)
+			continue
+		}
+		feedItems, err := h.rssService.FormatFeedItems(c.Request.Context(), feed.Name)
+		if err != nil {
+			upstreamError(c, err)
+			return
+		}
+		for _, item := range feedItems {
+			item["feed"] = feed.Name
+			items = append(items, item)
+		}
+	}
+
+	service.SortItemsByPublished(items)
+	c.JSON(http.StatusOK, items)
+}
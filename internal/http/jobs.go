@@ -0,0 +1,50 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.orx.me/apps/unifeed/internal/service"
+)
+
+// jobStatus 单个任务的状态
+type jobStatus struct {
+	Name     string            `json:"name"`
+	Status   service.JobHealth `json:"status"`
+	LastRun  string            `json:"last_run,omitempty"`
+	Failures int               `json:"failures"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// getJobs 返回所有任务的状态，可通过 status 参数只返回指定健康状态的任务
+func (h *Handler) getJobs(c *gin.Context) {
+	filter := service.JobHealth(c.Query("status"))
+	switch filter {
+	case "", service.JobHealthy, service.JobFailing, service.JobStale:
+	default:
+		respondError(c, http.StatusBadRequest, codeBadRequest, "status must be one of healthy, failing, stale")
+		return
+	}
+
+	jobs := make([]jobStatus, 0)
+	for _, job := range h.schedulerService.GetAllJobs() {
+		health := h.schedulerService.Health(job)
+		if filter != "" && health != filter {
+			continue
+		}
+		status := jobStatus{
+			Name:     job.Feed.Name,
+			Status:   health,
+			Failures: job.Failures,
+		}
+		if !job.LastRun.IsZero() {
+			status.LastRun = job.LastRun.Format(time.RFC3339)
+		}
+		if job.Error != nil {
+			status.Error = publicError(c, job.Error, "update failed")
+		}
+		jobs = append(jobs, status)
+	}
+	c.JSON(http.StatusOK, jobs)
+}
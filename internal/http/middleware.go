@@ -0,0 +1,103 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.orx.me/apps/unifeed/internal/logger"
+)
+
+// requestIDHeader 请求 ID 的请求头和响应头
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength 沿用客户端请求 ID 的最大长度，超过时重新生成
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware 为每个请求分配请求 ID：沿用 X-Request-ID 请求头，没有或过长时生成，
+// 并写入响应头和 request_id context 值
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(requestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = newRequestID()
+		}
+		c.Header(requestIDHeader, requestID)
+		// logger.WithContext 按字符串键 request_id 读取
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), "request_id", requestID))
+		c.Next()
+	}
+}
+
+// requestIDFrom 返回 RequestIDMiddleware 分配的请求 ID
+func requestIDFrom(c *gin.Context) string {
+	requestID, _ := c.Request.Context().Value("request_id").(string)
+	return requestID
+}
+
+// AccessLogMiddleware 为每个请求输出一条 JSON 访问日志，包括方法、路径、状态码、耗时、响应字节数和请求 ID，
+// 需在 RequestIDMiddleware 之后使用
+func AccessLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		logger.Info("HTTP request",
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"route", c.FullPath(),
+			"status", c.Writer.Status(),
+			"latency_ms", float64(time.Since(start).Microseconds())/1000,
+			"bytes", max(c.Writer.Size(), 0),
+			"client_ip", c.ClientIP(),
+			"request_id", requestIDFrom(c),
+		)
+	}
+}
+
+// newRequestID 生成随机请求 ID
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// TimeoutMiddleware 为每个请求的 context 设置超时，超时且未写入响应时返回 503
+func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			respondError(c, http.StatusServiceUnavailable, codeTimeout, "request timed out")
+		}
+	}
+}
+
+// AdminAuth 校验 Authorization: Bearer 管理令牌，未配置令牌时拒绝所有请求
+func AdminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			respondError(c, http.StatusForbidden, codeForbidden, "admin token not configured")
+			return
+		}
+		got, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			respondError(c, http.StatusUnauthorized, codeUnauthorized, "unauthorized")
+			return
+		}
+		c.Next()
+	}
+}
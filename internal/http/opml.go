@@ -0,0 +1,169 @@
+package http
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.orx.me/apps/unifeed/internal/conf"
+	"go.orx.me/apps/unifeed/internal/logger"
+	"go.orx.me/apps/unifeed/internal/service"
+)
+
+// opml OPML 2.0 文档
+type opml struct {
+	XMLName xml.Name      `xml:"opml"`
+	Version string        `xml:"version,attr"`
+	Title   string        `xml:"head>title"`
+	Body    []opmlOutline `xml:"body>outline"`
+}
+
+// opmlOutline 指向单个订阅的 outline 元素，导入时没有 xmlUrl 的 outline 视为分类
+type opmlOutline struct {
+	Type     string        `xml:"type,attr"`
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr"`
+	XMLURL   string        `xml:"xmlUrl,attr"`
+	HTMLURL  string        `xml:"htmlUrl,attr,omitempty"`
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+// opmlMaxBytes 导入的 OPML 文件的最大字节数
+const opmlMaxBytes = 1 << 20
+
+// opmlImport OPML 导入结果
+type opmlImport struct {
+	Added   []string      `json:"added"`
+	Skipped []opmlSkipped `json:"skipped"`
+}
+
+// opmlSkipped 未导入的订阅及原因
+type opmlSkipped struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Reason string `json:"reason"`
+}
+
+// getOPML 以 OPML 2.0 导出所有配置的订阅，xmlUrl 指向本服务的 RSS 输出，便于导入其他阅读器
+func (h *Handler) getOPML(c *gin.Context) {
+	doc := opml{Version: "2.0", Title: "unifeed", Body: []opmlOutline{}}
+	for _, feed := range conf.Current().Feeds {
+		doc.Body = append(doc.Body, opmlOutline{
+			Type:    "rss",
+			Text:    feed.Name,
+			Title:   feed.Name,
+			XMLURL:  h.selfURL(c, feed, formatRSS),
+			HTMLURL: feed.Link,
+		})
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+	c.Header("Content-Type", "text/x-opml; charset=utf-8")
+	c.String(http.StatusOK, xml.Header+string(out))
+}
+
+// postOPML 导入上传的 OPML 文件（multipart 的 file 字段或请求体），将每个带 xmlUrl 的 outline
+// 通过 Feed 注册表增加为 RSS Feed 并启动更新任务；名称取 title、text 或地址的主机名，
+// 与已有 Feed 重名时跳过，所在分类作为 Feed 的分组
+func (h *Handler) postOPML(c *gin.Context) {
+	body, err := readOPMLUpload(c)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(c, http.StatusRequestEntityTooLarge, codePayloadTooLarge, "OPML file too large")
+			return
+		}
+		respondError(c, http.StatusBadRequest, codeBadRequest, err.Error())
+		return
+	}
+	var doc opml
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		respondError(c, http.StatusBadRequest, codeBadRequest, "invalid OPML")
+		return
+	}
+
+	result := opmlImport{Added: []string{}, Skipped: []opmlSkipped{}}
+	for _, feed := range opmlFeeds(doc.Body, "") {
+		skip := func(reason string) {
+			result.Skipped = append(result.Skipped, opmlSkipped{Name: feed.Name, URL: feed.RssFeed, Reason: reason})
+		}
+		if !isHTTPURL(feed.RssFeed) {
+			skip("invalid xmlUrl")
+			continue
+		}
+		added, err := h.feeds.Add(c.Request.Context(), feed)
+		switch {
+		case errors.Is(err, service.ErrFeedExists):
+			skip("duplicate name")
+		case err != nil:
+			skip(publicError(c, err, "failed to add feed"))
+		default:
+			h.startFeedJob(c, added)
+			result.Added = append(result.Added, added.Name)
+		}
+	}
+	logger.Info("Imported OPML",
+		"added", len(result.Added),
+		"skipped", len(result.Skipped),
+	)
+	c.JSON(http.StatusOK, result)
+}
+
+// readOPMLUpload 读取 multipart 上传的 file 字段，不是 multipart 请求时读取请求体
+func readOPMLUpload(c *gin.Context) ([]byte, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, opmlMaxBytes)
+	var r io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, err
+		}
+		f, err := header.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	return io.ReadAll(r)
+}
+
+// opmlFeeds 递归收集带 xmlUrl 的 outline，category 为所在分类的名称
+func opmlFeeds(outlines []opmlOutline, category string) []conf.Feed {
+	var feeds []conf.Feed
+	for _, o := range outlines {
+		name := strings.TrimSpace(o.Title)
+		if name == "" {
+			name = strings.TrimSpace(o.Text)
+		}
+		if o.XMLURL == "" {
+			feeds = append(feeds, opmlFeeds(o.Outlines, name)...)
+			continue
+		}
+		if name == "" {
+			if u, err := url.Parse(o.XMLURL); err == nil {
+				name = u.Hostname()
+			}
+		}
+		feed := conf.Feed{Name: name, Title: name, RssFeed: o.XMLURL, Link: o.HTMLURL}
+		if category != "" {
+			feed.Groups = []string{category}
+		}
+		feeds = append(feeds, feed)
+	}
+	return feeds
+}
+
+// isHTTPURL 是否为绝对的 http/https 地址
+func isHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,76 @@
+package http
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.orx.me/apps/unifeed/internal/service"
+)
+
+// totalCountHeader 分页前的条目总数
+const totalCountHeader = "X-Total-Count"
+
+// page ?limit= 和 ?offset= 分页参数，limit 为 0 时不限制条数
+type page struct {
+	limit  int
+	offset int
+}
+
+// set 是否指定了分页参数
+func (p page) set() bool {
+	return p.limit > 0 || p.offset > 0
+}
+
+// parsePage 解析分页参数，limit 必须为正整数，offset 必须为非负整数
+func parsePage(c *gin.Context) (page, error) {
+	var p page
+	if raw, ok := c.GetQuery("limit"); ok {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			return page{}, fmt.Errorf("limit must be a positive integer")
+		}
+		p.limit = limit
+	}
+	if raw, ok := c.GetQuery("offset"); ok {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			return page{}, fmt.Errorf("offset must be a non-negative integer")
+		}
+		p.offset = offset
+	}
+	return p, nil
+}
+
+// bounds 返回 total 个条目中当前页的起止下标，offset 超出范围时返回空页
+func (p page) bounds(total int) (int, int) {
+	start := min(p.offset, total)
+	end := total
+	if p.limit > 0 {
+		end = min(start+p.limit, total)
+	}
+	return start, end
+}
+
+// pageItems 按发布时间倒序排列条目后返回当前页，并在响应头中设置总数
+func pageItems(c *gin.Context, items []map[string]interface{}, p page) []map[string]interface{} {
+	c.Header(totalCountHeader, strconv.Itoa(len(items)))
+	service.SortItemsByPublished(items)
+	start, end := p.bounds(len(items))
+	return items[start:end]
+}
+
+// pageFeedItems 按发布时间倒序排列结构化条目后返回当前页，并在响应头中设置总数
+func pageFeedItems(c *gin.Context, items []service.FeedItem, p page) []service.FeedItem {
+	c.Header(totalCountHeader, strconv.Itoa(len(items)))
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i].Published, items[j].Published
+		if a.IsZero() || b.IsZero() {
+			return !a.IsZero() && b.IsZero()
+		}
+		return a.After(b)
+	})
+	start, end := p.bounds(len(items))
+	return items[start:end]
+}
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.orx.me/apps/unifeed/internal/logger"
+	"go.orx.me/apps/unifeed/internal/service"
+)
+
+// getPreview 拉取 ?url= 指定的 Feed 并按 ?format= 渲染，不写入存储也不创建调度任务；
+// ?summaries= 为生成摘要的条目数，超过 preview.max_summaries 时截断
+func (h *Handler) getPreview(c *gin.Context) {
+	target := c.Query("url")
+	if target == "" {
+		respondError(c, http.StatusBadRequest, codeBadRequest, "url is required")
+		return
+	}
+	format := c.DefaultQuery("format", formatRSS)
+	if format == "json" {
+		format = formatJSONFeed
+	}
+	if !isFeedFormat(format) {
+		respondError(c, http.StatusBadRequest, codeBadRequest, "unsupported format")
+		return
+	}
+	summaries := 0
+	if raw, ok := c.GetQuery("summaries"); ok {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			respondError(c, http.StatusBadRequest, codeBadRequest, "summaries must be a non-negative integer")
+			return
+		}
+		summaries = min(n, h.previewSummaries)
+	}
+
+	channel, err := h.rssService.Preview(c.Request.Context(), h.previewClient, target, summaries, h.previewMaxBytes)
+	if err != nil {
+		if errors.Is(err, service.ErrPreviewBlocked) {
+			logger.Warn("Rejected preview of blocked address", "url", target, "error", err)
+		}
+		upstreamError(c, err)
+		return
+	}
+
+	out, err := renderChannel(channel, format)
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+	c.Header("Content-Type", feedContentType(format))
+	c.String(http.StatusOK, out)
+}
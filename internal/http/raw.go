@@ -0,0 +1,36 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.orx.me/apps/unifeed/internal/logger"
+	"go.orx.me/apps/unifeed/internal/service"
+)
+
+// getRaw 绕过缓存重新请求上游，原样返回响应内容
+func (h *Handler) getRaw(c *gin.Context) {
+	feed := findFeed(c.Param("name"))
+	if feed == nil {
+		respondError(c, http.StatusNotFound, codeNotFound, "feed not found")
+		return
+	}
+
+	raw, err := service.FetchRaw(c.Request.Context(), h.rawClient, h.blueskyService, *feed, h.rawMaxBytes)
+	if err != nil {
+		logger.Warn("Failed to fetch raw upstream", "feed_name", feed.Name, "error", err)
+		upstreamError(c, err)
+		return
+	}
+
+	c.Header("X-Upstream-Status", strconv.Itoa(raw.StatusCode))
+	if raw.Truncated {
+		c.Header("X-Truncated", "true")
+	}
+	contentType := raw.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Data(http.StatusOK, contentType, raw.Body)
+}
@@ -2,8 +2,11 @@ package http
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -13,44 +16,112 @@ import (
 )
 
 func Router(r *gin.Engine) {
+	// 框架加载的配置未经 Validate，创建调度器前同样应用更新间隔下限
+	_ = conf.Update(func(cfg *conf.Config) error {
+		cfg.ApplyIntervalFloors()
+		return nil
+	})
+	cfg := conf.Current()
 
 	s3Client, err := dao.NewS3Client()
 	if err != nil {
 		log.Fatalf("Failed to initialize S3 client: %v", err)
 	}
 
+	// 启动自检，确认存储可写
+	if !cfg.S3.SkipProbe {
+		if err := dao.ProbeWritable(context.Background(), s3Client); err != nil {
+			log.Fatalf("S3 storage is not writable: %v", err)
+		}
+	}
+
+	// 每个存储配置创建独立的客户端
+	profiles := make(map[string]*dao.S3Client, len(cfg.Storage.Profiles))
+	for name, profile := range cfg.Storage.Profiles {
+		client, err := dao.NewS3ClientWithConfig(profile)
+		if err != nil {
+			log.Fatalf("Failed to initialize S3 client for storage profile %s: %v", name, err)
+		}
+		if !profile.SkipProbe {
+			if err := dao.ProbeWritable(context.Background(), client); err != nil {
+				log.Fatalf("Storage profile %s is not writable: %v", name, err)
+			}
+		}
+		profiles[name] = client
+	}
+
 	// 初始化 AI 服务
-	aiService := service.NewAIService(conf.Conf.AI)
+	aiService := service.NewAIService(cfg.AI)
 
 	// 初始化 RSS 服务
 	rssConfig := service.RssConfig{
-		MaxRetries: 3,
-		RetryDelay: time.Second * 5,
+		MaxRetries:          3,
+		RetryDelay:          time.Second * 5,
+		SkipUnchanged:       cfg.Scheduler.SkipUnchanged,
+		FastStart:           cfg.Scheduler.FastStart,
+		BackfillBatch:       cfg.Scheduler.BackfillBatch,
+		HTTPTimeout:         cfg.Scheduler.FetchTimeout,
+		CacheSummaries:      cfg.AI.CacheSummaries,
+		DeadLetterAfter:     cfg.Scheduler.DeadLetterAfter,
+		ItemCountAlarmRatio: cfg.Scheduler.ItemCountAlarmRatio,
+		ItemCountWindow:     cfg.Scheduler.ItemCountWindow,
+		MaxConcurrency:      cfg.Scheduler.MaxConcurrency,
+		StoreConcurrency:    cfg.Scheduler.StoreConcurrency,
+		StoreBatchSize:      cfg.Scheduler.StoreBatchSize,
+		ItemKeyTemplate:     cfg.Storage.ItemKeyTemplate,
+		RetryJitter:         cfg.Scheduler.RetryJitter,
+		PartialResults:      cfg.HTTP.PartialResults,
 	}
 	rssService := service.NewRssService(aiService, s3Client, rssConfig)
+	configureFeed := func(feed conf.Feed) {
+		if feed.StorageProfile != "" {
+			rssService.SetFeedStore(feed.Name, profiles[feed.StorageProfile])
+		}
+		if feed.AI.Endpoint != "" {
+			rssService.SetFeedAIService(feed.Name, aiService.ForFeed(feed))
+		}
+		if feed.AI.DailyTokenBudget > 0 || feed.AI.DailyRequestBudget > 0 {
+			rssService.SetFeedAIBudget(feed.Name, feed.AI.DailyTokenBudget, feed.AI.DailyRequestBudget)
+		}
+	}
+
+	// 恢复运行期间增删的 Feed
+	feedRegistry := service.NewFeedRegistry(s3Client, configureFeed)
+	if err := feedRegistry.Load(context.Background()); err != nil {
+		log.Printf("Failed to load runtime feeds: %v", err)
+	}
+	cfg = conf.Current()
+	for _, feed := range cfg.Feeds {
+		configureFeed(feed)
+	}
 
 	// 初始化调度器服务
 	schedulerConfig := service.SchedulerConfig{
-		UpdateInterval: conf.Conf.Scheduler.UpdateInterval,
-		MaxRetries:     conf.Conf.Scheduler.MaxRetries,
-		RetryDelay:     conf.Conf.Scheduler.RetryDelay,
+		UpdateInterval:    cfg.Scheduler.UpdateInterval,
+		MaxRetries:        cfg.Scheduler.MaxRetries,
+		RetryDelay:        cfg.Scheduler.RetryDelay,
+		FailureBackoff:    cfg.Scheduler.FailureBackoff,
+		ErrorLogWindow:    cfg.Scheduler.ErrorLogWindow,
+		ErrorLogEvery:     cfg.Scheduler.ErrorLogEvery,
+		BootConcurrency:   cfg.Scheduler.BootConcurrency,
+		BootStagger:       cfg.Scheduler.BootStagger,
+		DependencyBackoff: cfg.Scheduler.DependencyBackoff,
+		ShutdownTimeout:   cfg.Scheduler.ShutdownTimeout,
 	}
 	schedulerService := service.NewSchedulerService(rssService, schedulerConfig)
+	activeScheduler.Store(schedulerService)
 
 	// 初始化 HTTP 处理器
 	handler := NewHandler(rssService, schedulerService)
+	handler.SetFeedRegistry(feedRegistry)
 	handler.Router(r)
 
 	// 启动调度器
 	ctx := context.Background()
 
 	// 为每个 RSS feed 启动调度任务
-	for _, feed := range conf.Conf.Feeds {
-		if feed.RssFeed != "" {
-			if err := schedulerService.StartJob(ctx, feed); err != nil {
-				log.Printf("Failed to start job for feed %s: %v", feed.Name, err)
-			}
-		}
+	if err := schedulerService.StartAllJobs(ctx, cfg.Feeds); err != nil {
+		log.Printf("Failed to start jobs: %v", err)
 	}
 
 }
@@ -58,59 +129,309 @@ func Router(r *gin.Engine) {
 type Handler struct {
 	rssService       *service.RssService
 	schedulerService *service.SchedulerService
+	mastodonService  *service.MastodonService
+	blueskyService   *service.BlueskyService
+	socialCache      *service.RenderCache
+	requestTimeout   time.Duration
+	adminToken       string
+	rawMaxBytes      int64
+	rawClient        *http.Client
+	baseURL          string
+	basePath         string
+	webSub           *service.WebSubscriber
+	previewClient    *http.Client
+	previewMaxBytes  int64
+	previewSummaries int
+	accessLog        bool
+	readyCheckAI     bool
+	feeds            *service.FeedRegistry
 }
 
 func NewHandler(rssService *service.RssService, schedulerService *service.SchedulerService) *Handler {
+	cfg := conf.Current()
+	requestTimeout := cfg.HTTP.RequestTimeout
+	if requestTimeout <= 0 {
+		requestTimeout = time.Second * 30
+	}
+	socialCacheTTL := cfg.Social.CacheTTL
+	if socialCacheTTL <= 0 {
+		socialCacheTTL = time.Minute * 5
+	}
+	rawMaxBytes := cfg.HTTP.RawMaxBytes
+	if rawMaxBytes <= 0 {
+		rawMaxBytes = 1 << 20
+	}
+	mastodonService := service.NewMastodonService()
+	blueskyService := service.NewBlueskyService()
+	mastodonService.SetTimeout(cfg.Social.Timeout)
+	blueskyService.SetTimeout(cfg.Social.Timeout)
+	if cfg.Social.ResolveEnclosures {
+		resolver := service.NewEnclosureResolver(cfg.Social.EnclosureTimeout)
+		mastodonService.SetEnclosureResolver(resolver)
+		blueskyService.SetEnclosureResolver(resolver)
+	}
+	basePath := strings.TrimSuffix(cfg.HTTP.BasePath, "/")
+	// 订阅声明了 hub 的 Feed，hub 需要通过 base_url 回调
+	var webSub *service.WebSubscriber
+	if cfg.WebSub.Enabled && cfg.HTTP.BaseURL != "" {
+		lease := cfg.WebSub.Lease
+		if lease <= 0 {
+			lease = 24 * time.Hour
+		}
+		webSub = service.NewWebSubscriber(strings.TrimSuffix(cfg.HTTP.BaseURL, "/")+basePath, lease)
+		rssService.SetHubHandler(webSub.Discovered)
+	}
+	previewMaxBytes := cfg.Preview.MaxBytes
+	if previewMaxBytes <= 0 {
+		previewMaxBytes = 5 << 20
+	}
+	previewSummaries := cfg.Preview.MaxSummaries
+	if previewSummaries <= 0 {
+		previewSummaries = 3
+	}
+	previewTimeout := cfg.Preview.Timeout
+	if previewTimeout <= 0 {
+		previewTimeout = 15 * time.Second
+	}
 	return &Handler{
 		rssService:       rssService,
 		schedulerService: schedulerService,
+		mastodonService:  mastodonService,
+		blueskyService:   blueskyService,
+		socialCache:      service.NewRenderCache(socialCacheTTL),
+		requestTimeout:   requestTimeout,
+		adminToken:       cfg.HTTP.AdminToken,
+		rawMaxBytes:      rawMaxBytes,
+		baseURL:          cfg.HTTP.BaseURL,
+		basePath:         basePath,
+		rawClient:        &http.Client{},
+		webSub:           webSub,
+		previewClient:    service.NewPreviewClient(previewTimeout, cfg.Preview.AllowPrivate),
+		previewMaxBytes:  previewMaxBytes,
+		previewSummaries: previewSummaries,
+		accessLog:        cfg.HTTP.AccessLog,
+		readyCheckAI:     cfg.HTTP.ReadyCheckAI,
+		feeds:            service.NewFeedRegistry(nil, nil),
+	}
+}
+
+// SetFeedRegistry 设置运行期间增删 Feed 使用的注册表，未设置时变更只保存在内存中
+func (h *Handler) SetFeedRegistry(registry *service.FeedRegistry) {
+	h.feeds = registry
+}
+
+// findFeed 按名称查找配置的 Feed
+func findFeed(name string) *conf.Feed {
+	for _, f := range conf.Current().Feeds {
+		if f.Name == name {
+			return &f
+		}
+	}
+	return nil
+}
+
+// isSocialFeed 是否为 Mastodon/Bluesky 社交源
+func isSocialFeed(feed conf.Feed) bool {
+	return feed.Mastodon.Host != "" || feed.Bluesky.Host != ""
+}
+
+// renderSocial 渲染社交源，优先使用缓存，refresh 为 true 时强制重新拉取；self 为输出中的订阅地址
+func (h *Handler) renderSocial(ctx context.Context, feed conf.Feed, format, self string, refresh bool) (string, error) {
+	key := socialCacheKey(feed.Name, format)
+	if !refresh {
+		if out, ok := h.socialCache.Get(key); ok {
+			return out, nil
+		}
+	}
+
+	var channel service.Channel
+	var err error
+	if feed.Mastodon.Host != "" {
+		channel, err = h.mastodonService.Timeline(ctx, feed)
+	} else {
+		channel, err = h.blueskyService.Timeline(ctx, feed)
+	}
+	if err != nil {
+		return "", err
+	}
+	channel.SelfLink = self
+	out, err := renderChannel(channel, format)
+	if err != nil {
+		return "", err
+	}
+
+	h.socialCache.Set(key, out)
+	return out, nil
+}
+
+// selfURL 返回本服务上该订阅指定格式的地址，未配置 http.base_url 时根据请求推断，路径包含 http.base_path
+func (h *Handler) selfURL(c *gin.Context, feed conf.Feed, format string) string {
+	base := h.baseURL
+	if base == "" {
+		scheme := "http"
+		if c.Request.TLS != nil {
+			scheme = "https"
+		}
+		if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+			scheme = proto
+		}
+		base = scheme + "://" + c.Request.Host
+	}
+	u := strings.TrimSuffix(base, "/") + h.basePath + "/feeds/" + url.PathEscape(feed.Name)
+	// 社交源默认输出 RSS，RSS 源默认输出 JSON
+	if isSocialFeed(feed) && format == formatRSS {
+		return u
+	}
+	return u + "?format=" + format
+}
+
+// socialCacheKey 社交源缓存键，不同输出格式分开缓存
+func socialCacheKey(name, format string) string {
+	if format == formatRSS {
+		return name
+	}
+	return name + "#" + format
+}
+
+const (
+	formatRSS       = "rss"
+	formatAtom      = "atom"
+	formatJSONFeed  = "jsonfeed"
+	formatJSONItems = "json-items"
+)
+
+// isFeedFormat 是否为可渲染的订阅格式
+func isFeedFormat(format string) bool {
+	return format == formatRSS || format == formatAtom || format == formatJSONFeed
+}
+
+// renderChannel 按输出格式渲染频道
+func renderChannel(channel service.Channel, format string) (string, error) {
+	switch format {
+	case formatAtom:
+		return service.RenderAtom(channel)
+	case formatJSONFeed:
+		return service.RenderJSONFeed(channel)
+	default:
+		return service.RenderRSS(channel)
+	}
+}
+
+// feedContentType 输出格式对应的 Content-Type
+func feedContentType(format string) string {
+	switch format {
+	case formatAtom:
+		return "application/atom+xml; charset=utf-8"
+	case formatJSONFeed:
+		return "application/feed+json; charset=utf-8"
+	default:
+		return "application/xml; charset=utf-8"
 	}
 }
 
 func (h *Handler) Router(r *gin.Engine) {
-	r.GET("/", func(c *gin.Context) {
+	r.Use(RequestIDMiddleware())
+	if h.accessLog {
+		r.Use(AccessLogMiddleware())
+	}
+	r.Use(TimeoutMiddleware(h.requestTimeout))
+
+	// 未知路径同样返回统一格式的错误
+	r.NoRoute(func(c *gin.Context) {
+		respondError(c, http.StatusNotFound, codeNotFound, "route not found")
+	})
+
+	// 所有路由位于 http.base_path 下
+	g := r.Group(h.basePath)
+
+	g.GET("/", func(c *gin.Context) {
 		c.JSON(200, gin.H{
 			"message": "Hello, World!",
 		})
 	})
 
 	// 获取 Feed 内容
-	r.GET("/feeds/:name", func(c *gin.Context) {
-		name := c.Param("name")
-		var feed *conf.Feed
-		for _, f := range conf.Conf.Feeds {
-			if f.Name == name {
-				feed = &f
-				break
-			}
-		}
+	g.GET("/feeds/:name", func(c *gin.Context) {
+		feed := findFeed(c.Param("name"))
 		if feed == nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "feed not found"})
+			respondError(c, http.StatusNotFound, codeNotFound, "feed not found")
+			return
+		}
+
+		// 字段投影只作用于 JSON 条目输出
+		fields, err := parseFields(c.Query("fields"))
+		if err != nil {
+			respondError(c, http.StatusBadRequest, codeBadRequest, err.Error())
+			return
+		}
+		if format := c.Query("format"); fields != nil && (isSocialFeed(*feed) || (format != "" && format != formatJSONItems)) {
+			respondError(c, http.StatusBadRequest, codeBadRequest, "fields is only supported for JSON item output")
+			return
+		}
+
+		// 分页同样只作用于 JSON 条目输出
+		pg, err := parsePage(c)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, codeBadRequest, err.Error())
+			return
+		}
+		if format := c.Query("format"); pg.set() && (isSocialFeed(*feed) || (format != "" && format != formatJSONItems)) {
+			respondError(c, http.StatusBadRequest, codeBadRequest, "limit and offset are only supported for JSON item output")
 			return
 		}
 
 		// 处理不同类型的 Feed
-		if feed.Mastodon.Host != "" {
-			svc := service.NewMastodonService()
-			rss, err := svc.TimelineToRSS(*feed)
+		if isSocialFeed(*feed) {
+			format := c.DefaultQuery("format", formatRSS)
+			if !isFeedFormat(format) {
+				respondError(c, http.StatusBadRequest, codeBadRequest, "unsupported format")
+				return
+			}
+			out, err := h.renderSocial(c.Request.Context(), *feed, format, h.selfURL(c, *feed, format), false)
 			if err != nil {
-				c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+				upstreamError(c, err)
 				return
 			}
-			c.Header("Content-Type", "application/xml; charset=utf-8")
-			c.String(http.StatusOK, rss)
+			c.Header("Content-Type", feedContentType(format))
+			c.String(http.StatusOK, out)
 			return
 		}
 
-		if feed.Bluesky.Host != "" {
-			svc := service.NewBlueskyService()
-			rss, err := svc.TimelineToRSS(*feed)
+		if feed.RssFeed != "" && c.Query("format") == formatJSONItems {
+			// 结构化输出，字段固定
+			items, err := h.rssService.GetFeedItems(c.Request.Context(), feed.Name)
 			if err != nil {
-				c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+				upstreamError(c, err)
 				return
 			}
-			c.Header("Content-Type", "application/xml; charset=utf-8")
-			c.String(http.StatusOK, rss)
+			items = pageFeedItems(c, items, pg)
+			if fields == nil {
+				c.JSON(http.StatusOK, items)
+				return
+			}
+			maps, err := itemMaps(items)
+			if err != nil {
+				internalError(c, err)
+				return
+			}
+			c.JSON(http.StatusOK, projectItems(maps, fields))
+			return
+		}
+
+		if format := c.Query("format"); feed.RssFeed != "" && isFeedFormat(format) {
+			channel, err := h.rssService.GetChannel(c.Request.Context(), *feed)
+			if err != nil {
+				upstreamError(c, err)
+				return
+			}
+			channel.SelfLink = h.selfURL(c, *feed, format)
+			out, err := renderChannel(channel, format)
+			if err != nil {
+				internalError(c, err)
+				return
+			}
+			c.Header("Content-Type", feedContentType(format))
+			c.String(http.StatusOK, out)
 			return
 		}
 
@@ -118,51 +439,138 @@ func (h *Handler) Router(r *gin.Engine) {
 			// 获取格式化的 Feed 项目，确保内容包含摘要
 			items, err := h.rssService.FormatFeedItems(c.Request.Context(), feed.Name)
 			if err != nil {
-				c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+				upstreamError(c, err)
 				return
 			}
+			items = pageItems(c, items, pg)
+			if fields != nil {
+				items = projectItems(items, fields)
+			}
 			c.JSON(http.StatusOK, items)
 			return
 		}
 
-		c.JSON(http.StatusNotImplemented, gin.H{"error": "unsupported feed type"})
+		respondError(c, http.StatusNotImplemented, codeNotImplemented, "unsupported feed type")
 	})
 
 	// 手动触发 Feed 更新
-	r.POST("/feeds/:name/update", func(c *gin.Context) {
-		name := c.Param("name")
-		var feed *conf.Feed
-		for _, f := range conf.Conf.Feeds {
-			if f.Name == name {
-				feed = &f
-				break
+	g.POST("/feeds/:name/update", func(c *gin.Context) {
+		feed := findFeed(c.Param("name"))
+		if feed == nil {
+			respondError(c, http.StatusNotFound, codeNotFound, "feed not found")
+			return
+		}
+
+		// 社交源直接刷新缓存
+		if isSocialFeed(*feed) {
+			h.socialCache.Invalidate(socialCacheKey(feed.Name, formatAtom))
+			h.socialCache.Invalidate(socialCacheKey(feed.Name, formatJSONFeed))
+			if _, err := h.renderSocial(c.Request.Context(), *feed, formatRSS, h.selfURL(c, *feed, formatRSS), true); err != nil {
+				upstreamError(c, err)
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"message": "feed refreshed"})
+			return
+		}
+
+		if feed.RssFeed == "" {
+			respondError(c, http.StatusBadRequest, codeBadRequest, "feed does not support updates")
+			return
+		}
+
+		// 已有任务时立即触发一次更新，否则启动任务，任务不随请求结束
+		if err := h.schedulerService.TriggerUpdate(feed.Name); err != nil {
+			if err := h.schedulerService.StartJob(context.WithoutCancel(c.Request.Context()), *feed); err != nil {
+				internalError(c, err)
+				return
 			}
 		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "update started"})
+	})
+
+	// 重放死信条目，会写入存储并调用 AI，需要管理令牌
+	g.POST("/feeds/:name/replay-deadletter", AdminAuth(h.adminToken), func(c *gin.Context) {
+		feed := findFeed(c.Param("name"))
 		if feed == nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "feed not found"})
+			respondError(c, http.StatusNotFound, codeNotFound, "feed not found")
 			return
 		}
-
 		if feed.RssFeed == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "feed does not support RSS updates"})
+			respondError(c, http.StatusBadRequest, codeBadRequest, "feed has no stored items")
 			return
 		}
 
-		// 启动更新任务
-		if err := h.schedulerService.StartJob(c.Request.Context(), *feed); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		result, err := h.rssService.ReplayDeadLetters(c.Request.Context(), feed.Name)
+		if err != nil {
+			upstreamError(c, err)
 			return
 		}
+		c.JSON(http.StatusOK, result)
+	})
 
-		c.JSON(http.StatusOK, gin.H{"message": "update started"})
+	// 删除单个存储的条目，id 为条目的 GUID、链接或内容哈希（hash- 开头）
+	g.DELETE("/feeds/:name/items/:id", AdminAuth(h.adminToken), func(c *gin.Context) {
+		feed := findFeed(c.Param("name"))
+		if feed == nil {
+			respondError(c, http.StatusNotFound, codeNotFound, "feed not found")
+			return
+		}
+		if feed.RssFeed == "" {
+			respondError(c, http.StatusBadRequest, codeBadRequest, "feed has no stored items")
+			return
+		}
+
+		err := h.rssService.DeleteStoredItem(c.Request.Context(), feed.Name, c.Param("id"))
+		if errors.Is(err, service.ErrItemNotFound) {
+			respondError(c, http.StatusNotFound, codeNotFound, "item not found")
+			return
+		}
+		if err != nil {
+			upstreamError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "item deleted"})
 	})
 
+	// 获取上游原始响应，用于调试
+	g.GET("/feeds/:name/raw", AdminAuth(h.adminToken), h.getRaw)
+
+	// 汇总各子系统的健康状态
+	g.GET("/status", h.getStatus)
+
+	// Kubernetes 存活和就绪探针
+	g.GET("/healthz", h.getHealthz)
+	g.GET("/readyz", h.getReadyz)
+
+	// 获取分组合并后的 Feed 内容
+	g.GET("/groups/:group", h.getGroup)
+
+	// 批量获取任务状态，可按健康状态过滤
+	g.GET("/jobs", h.getJobs)
+
+	// 运行期间列出、增加和删除 Feed
+	g.GET("/feeds", AdminAuth(h.adminToken), h.listFeeds)
+	g.POST("/feeds", AdminAuth(h.adminToken), h.addFeed)
+	g.DELETE("/feeds/:name", AdminAuth(h.adminToken), h.removeFeed)
+
+	// 以 OPML 导出所有订阅，或导入 OPML 中的订阅并启动任务
+	g.GET("/opml", h.getOPML)
+	g.POST("/opml", AdminAuth(h.adminToken), h.postOPML)
+
+	// 预览任意地址的 Feed，不存储也不创建任务；会拉取任意地址并调用 AI，需要管理令牌
+	g.GET("/preview", AdminAuth(h.adminToken), h.getPreview)
+
+	// WebSub 订阅验证和内容通知
+	g.GET("/websub/:name", h.verifyWebSub)
+	g.POST("/websub/:name", h.notifyWebSub)
+
 	// 获取 Feed 更新状态
-	r.GET("/feeds/:name/status", func(c *gin.Context) {
+	g.GET("/feeds/:name/status", func(c *gin.Context) {
 		name := c.Param("name")
 		job, err := h.schedulerService.GetJobStatus(name)
 		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			respondError(c, http.StatusNotFound, codeNotFound, "job not found")
 			return
 		}
 
@@ -172,17 +580,17 @@ func (h *Handler) Router(r *gin.Engine) {
 			"is_active": true,
 		}
 		if job.Error != nil {
-			status["error"] = job.Error.Error()
+			status["error"] = publicError(c, job.Error, "update failed")
 		}
 
 		c.JSON(http.StatusOK, status)
 	})
 
 	// 停止 Feed 更新
-	r.POST("/feeds/:name/stop", func(c *gin.Context) {
+	g.POST("/feeds/:name/stop", func(c *gin.Context) {
 		name := c.Param("name")
 		if err := h.schedulerService.StopJob(name); err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			respondError(c, http.StatusNotFound, codeNotFound, "job not found")
 			return
 		}
 
@@ -0,0 +1,17 @@
+package http
+
+import (
+	"sync/atomic"
+
+	"go.orx.me/apps/unifeed/internal/service"
+)
+
+// activeScheduler Router 创建的调度器，供 Shutdown 停止
+var activeScheduler atomic.Pointer[service.SchedulerService]
+
+// Shutdown 停止所有 Feed 更新任务，最多等待 scheduler.shutdown_timeout 让进行中的更新完成；可重复调用
+func Shutdown() {
+	if scheduler := activeScheduler.Swap(nil); scheduler != nil {
+		scheduler.StopAllJobs()
+	}
+}
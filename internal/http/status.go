@@ -0,0 +1,108 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.orx.me/apps/unifeed/internal/metrics"
+)
+
+// statusCheckTimeout 单个子系统检查的超时时间
+const statusCheckTimeout = 5 * time.Second
+
+// readyCheckTimeout /readyz 依赖检查的超时时间，探针的超时应大于该值
+const readyCheckTimeout = 2 * time.Second
+
+const (
+	statusOK       = "ok"
+	statusError    = "error"
+	statusDisabled = "disabled"
+	statusDegraded = "degraded"
+)
+
+// checkResult 子系统检查结果
+type checkResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// runCheck 在超时时间内执行检查，失败时只返回通用信息，详情写入日志
+func runCheck(ctx context.Context, c *gin.Context, check func(context.Context) error) checkResult {
+	ctx, cancel := context.WithTimeout(ctx, statusCheckTimeout)
+	defer cancel()
+	if err := check(ctx); err != nil {
+		return checkResult{Status: statusError, Error: publicError(c, err, "check failed")}
+	}
+	return checkResult{Status: statusOK}
+}
+
+// getHealthz 存活探针，进程能处理请求即返回 200
+func (h *Handler) getHealthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": statusOK})
+}
+
+// getReadyz 就绪探针，并行检查 S3（以及开启 http.ready_check_ai 时的 AI 接口），
+// 任一依赖不可用时返回 503 并列出不可用的依赖
+func (h *Handler) getReadyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readyCheckTimeout)
+	defer cancel()
+
+	checks := map[string]func(context.Context) error{
+		"storage": h.rssService.CheckStorage,
+	}
+	if aiService := h.rssService.AIService(); h.readyCheckAI && !aiService.Disabled() && !aiService.DryRun() {
+		checks["ai"] = aiService.Ping
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	unhealthy := make(map[string]string)
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if result := runCheck(ctx, c, check); result.Status == statusError {
+				mu.Lock()
+				unhealthy[name] = result.Error
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(unhealthy) > 0 {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "unhealthy": unhealthy})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": statusOK})
+}
+
+// getStatus 汇总各子系统的健康状态，任一检查失败时返回 503
+func (h *Handler) getStatus(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	storage := runCheck(ctx, c, h.rssService.CheckStorage)
+	ai := checkResult{Status: statusDisabled}
+	if aiService := h.rssService.AIService(); !aiService.Disabled() && !aiService.DryRun() {
+		ai = runCheck(ctx, c, aiService.Ping)
+	}
+	scheduler := h.schedulerService.Stats()
+
+	overall, code := statusOK, http.StatusOK
+	if storage.Status == statusError || ai.Status == statusError {
+		overall, code = statusDegraded, http.StatusServiceUnavailable
+	}
+
+	c.JSON(code, gin.H{
+		"status":     overall,
+		"storage":    storage,
+		"ai":         ai,
+		"scheduler":  scheduler,
+		"cache":      gin.H{"hit_ratio": metrics.CacheHitRatio()},
+		"goroutines": runtime.NumGoroutine(),
+	})
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.orx.me/apps/unifeed/internal/logger"
+	"go.orx.me/apps/unifeed/internal/metrics"
+)
+
+// webSubMaxBody WebSub 通知正文的最大字节数
+const webSubMaxBody = 10 << 20
+
+// verifyWebSub 响应 hub 的订阅验证，原样返回 hub.challenge
+func (h *Handler) verifyWebSub(c *gin.Context) {
+	if h.webSub == nil {
+		respondError(c, http.StatusNotFound, codeNotFound, "websub not enabled")
+		return
+	}
+	lease, _ := strconv.Atoi(c.Query("hub.lease_seconds"))
+	if !h.webSub.Verify(c.Param("name"), c.Query("hub.mode"), c.Query("hub.topic"), lease) {
+		respondError(c, http.StatusNotFound, codeNotFound, "unknown subscription")
+		return
+	}
+	c.String(http.StatusOK, c.Query("hub.challenge"))
+}
+
+// notifyWebSub 处理 hub 推送的内容通知，签名正确时立即更新 Feed；
+// 按 WebSub 规范签名错误也返回 2xx，但忽略通知
+func (h *Handler) notifyWebSub(c *gin.Context) {
+	if h.webSub == nil {
+		respondError(c, http.StatusNotFound, codeNotFound, "websub not enabled")
+		return
+	}
+	feed := findFeed(c.Param("name"))
+	if feed == nil || feed.RssFeed == "" || !h.webSub.Subscribed(feed.Name) {
+		respondError(c, http.StatusNotFound, codeNotFound, "unknown subscription")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, webSubMaxBody))
+	if err != nil {
+		respondError(c, http.StatusRequestEntityTooLarge, codePayloadTooLarge, "notification too large")
+		return
+	}
+	if err := h.webSub.Authenticate(feed.Name, body, c.GetHeader("X-Hub-Signature")); err != nil {
+		metrics.WebSubNotifications.WithLabelValues(feed.Name, "rejected").Inc()
+		logger.Warn("Ignored WebSub notification", "feed_name", feed.Name, "error", err)
+		c.Status(http.StatusAccepted)
+		return
+	}
+	metrics.WebSubNotifications.WithLabelValues(feed.Name, "accepted").Inc()
+
+	// 丢弃解析缓存，确保立即拉取新内容
+	h.rssService.InvalidateFeedCache(feed.RssFeed)
+	if err := h.schedulerService.TriggerUpdate(feed.Name); err != nil {
+		// 没有轮询任务时启动任务，任务不随请求结束
+		if err := h.schedulerService.StartJob(context.WithoutCancel(c.Request.Context()), *feed); err != nil {
+			internalError(c, err)
+			return
+		}
+	}
+	c.Status(http.StatusAccepted)
+}
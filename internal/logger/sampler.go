@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"sync"
+	"time"
+)
+
+// Sampler suppresses repeated identical messages per key. The first
+// occurrence is logged, then every Nth repeat until the window expires.
+// A nil Sampler logs everything.
+type Sampler struct {
+	mu     sync.Mutex
+	window time.Duration
+	every  int
+	seen   map[string]*sampleState
+	now    func() time.Time
+}
+
+type sampleState struct {
+	signature  string
+	first      time.Time
+	count      int
+	suppressed int
+}
+
+// NewSampler creates a sampler with the given window and repeat interval
+func NewSampler(window time.Duration, every int) *Sampler {
+	return &Sampler{
+		window: window,
+		every:  every,
+		seen:   make(map[string]*sampleState),
+		now:    time.Now,
+	}
+}
+
+// Configure updates the window and repeat interval at runtime
+func (s *Sampler) Configure(window time.Duration, every int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.window = window
+	s.every = every
+	s.seen = make(map[string]*sampleState)
+}
+
+// Allow reports whether a message should be logged and how many identical
+// messages were suppressed since the last one that was
+func (s *Sampler) Allow(key, signature string) (bool, int) {
+	if s == nil {
+		return true, 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	st, ok := s.seen[key]
+	if !ok || st.signature != signature || now.Sub(st.first) >= s.window {
+		suppressed := 0
+		if ok && st.signature == signature {
+			suppressed = st.suppressed
+		}
+		s.seen[key] = &sampleState{signature: signature, first: now, count: 1}
+		return true, suppressed
+	}
+
+	st.count++
+	if s.every > 0 && st.count%s.every == 0 {
+		suppressed := st.suppressed
+		st.suppressed = 0
+		return true, suppressed
+	}
+	st.suppressed++
+	return false, 0
+}
+
+// Warn logs a warning unless an identical error for key was logged recently
+func (s *Sampler) Warn(key, msg string, err error, args ...any) {
+	allowed, suppressed := s.Allow(key, msg+"\x00"+err.Error())
+	if !allowed {
+		return
+	}
+	args = append(args, "error", err.Error())
+	if suppressed > 0 {
+		args = append(args, "suppressed", suppressed)
+	}
+	Log.Warn(msg, args...)
+}
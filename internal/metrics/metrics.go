@@ -42,11 +42,12 @@ var (
 		},
 	)
 
-	FeedCacheMisses = promauto.NewCounter(
+	FeedCacheMisses = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "feed_cache_misses_total",
-			Help: "Total number of cache misses",
+			Help: "Total number of cache misses by reason",
 		},
+		[]string{"reason"},
 	)
 
 	FeedCacheSize = promauto.NewGauge(
@@ -63,6 +64,14 @@ var (
 		},
 	)
 
+	FeedNotModified = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "feed_not_modified_total",
+			Help: "Total number of conditional feed requests answered with 304 Not Modified",
+		},
+		[]string{"feed_name"},
+	)
+
 	FeedCacheEvictions = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "feed_cache_evictions_total",
@@ -80,12 +89,99 @@ var (
 		[]string{"feed_name", "error_type"},
 	)
 
+	FeedDeadLetters = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "feed_dead_letters_total",
+			Help: "Total number of items written to the dead-letter prefix",
+		},
+		[]string{"feed_name", "stage"},
+	)
+
+	FeedItemCountAlarms = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "feed_item_count_alarms_total",
+			Help: "Total number of updates whose item count fell below the configured fraction of the recent average",
+		},
+		[]string{"feed_name"},
+	)
+
+	FeedItemsUnchanged = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "feed_items_unchanged_total",
+			Help: "Total number of items skipped on store because their content hash matched the stored copy",
+		},
+		[]string{"feed_name"},
+	)
+
+	FeedItemsPruned = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "feed_items_pruned_total",
+			Help: "Total number of stored items removed for exceeding the feed's max_stored_items",
+		},
+		[]string{"feed_name"},
+	)
+
+	FeedItemsMissing = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "feed_items_missing_total",
+			Help: "Total number of stored items no longer present upstream, labeled by action (archived or removed)",
+		},
+		[]string{"feed_name", "action"},
+	)
+
+	FeedAIDailyTokens = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "feed_ai_daily_tokens",
+			Help: "AI tokens used today (UTC) by feeds with a daily AI budget",
+		},
+		[]string{"feed_name"},
+	)
+
+	AIBudgetSkippedItems = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ai_budget_skipped_items_total",
+			Help: "Total number of items stored without a summary because the feed's daily AI budget was exhausted",
+		},
+		[]string{"feed_name"},
+	)
+
+	WebSubNotifications = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "websub_notifications_total",
+			Help: "Total number of WebSub content notifications by feed and status",
+		},
+		[]string{"feed_name", "status"},
+	)
+
 	FeedRetries = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "feed_retries_total",
-			Help: "Total number of retries by operation",
+			Help: "Total number of failed attempts by feed and operation",
+		},
+		[]string{"feed_name", "operation"},
+	)
+
+	FeedRetriesExhausted = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "feed_retries_exhausted_total",
+			Help: "Total number of update cycles that failed after all retries",
+		},
+		[]string{"feed_name"},
+	)
+
+	DependencyDown = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "scheduler_dependency_down",
+			Help: "Whether a shared dependency is down and update cycles are paused (1 = down)",
+		},
+		[]string{"dependency"},
+	)
+
+	SchedulerPausedCycles = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "scheduler_paused_cycles_total",
+			Help: "Total number of update cycles skipped while a shared dependency was down",
 		},
-		[]string{"operation"},
 	)
 
 	// 性能相关指标
@@ -111,9 +207,17 @@ var (
 	AISummaryTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "ai_summary_total",
-			Help: "Total number of AI summaries generated",
+			Help: "Total number of AI summary calls by the model that served them",
+		},
+		[]string{"model", "status"},
+	)
+
+	AISummaryTruncated = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ai_summary_truncated_total",
+			Help: "Total number of AI responses cut off by max_tokens, labeled by whether they were continued or flagged",
 		},
-		[]string{"status"},
+		[]string{"model", "status"},
 	)
 
 	AISummaryDuration = promauto.NewHistogramVec(
@@ -134,6 +238,13 @@ var (
 		[]string{"model"},
 	)
 
+	AIContentTruncated = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "ai_content_truncated_total",
+			Help: "Total number of contents exceeding the AI max content length",
+		},
+	)
+
 	AISummaryErrors = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "ai_summary_errors_total",
@@ -225,18 +336,39 @@ var (
 	cacheMisses atomic.Int64
 )
 
-// UpdateCacheStats 更新缓存统计信息
+// 缓存未命中的原因
+const (
+	CacheMissAbsent  = "absent"
+	CacheMissExpired = "expired"
+)
+
+// UpdateCacheStats 更新缓存统计信息，未命中按条目不存在计
 func UpdateCacheStats(hit bool) {
-	if hit {
-		cacheHits.Add(1)
-		FeedCacheHits.Inc()
-	} else {
-		cacheMisses.Add(1)
-		FeedCacheMisses.Inc()
+	if !hit {
+		RecordCacheMiss(CacheMissAbsent)
+		return
 	}
+	cacheHits.Add(1)
+	FeedCacheHits.Inc()
+	updateCacheHitRatio()
+}
+
+// RecordCacheMiss 记录一次缓存未命中，reason 区分条目不存在和已过期
+func RecordCacheMiss(reason string) {
+	cacheMisses.Add(1)
+	FeedCacheMisses.WithLabelValues(reason).Inc()
 	updateCacheHitRatio()
 }
 
+// CacheHitRatio 返回当前的缓存命中率，没有访问时为 0
+func CacheHitRatio() float64 {
+	total := float64(cacheHits.Load() + cacheMisses.Load())
+	if total == 0 {
+		return 0
+	}
+	return float64(cacheHits.Load()) / total
+}
+
 // updateCacheHitRatio 更新缓存命中率
 func updateCacheHitRatio() {
 	total := float64(cacheHits.Load() + cacheMisses.Load())
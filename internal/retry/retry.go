@@ -0,0 +1,94 @@
+// Package retry 提供 AI 和 RSS 等各层共用的重试策略：最大尝试次数、退避、抖动、可重试错误判断和每次尝试的回调
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"go.orx.me/apps/unifeed/internal/clock"
+)
+
+// ErrExhausted 达到最大尝试次数后仍失败，Do 返回的错误同时包装最后一次的错误
+var ErrExhausted = errors.New("retries exhausted")
+
+// Retrier 重试策略，零值只尝试一次
+type Retrier struct {
+	// MaxAttempts 最多尝试次数（含首次），小于 1 时按 1 处理
+	MaxAttempts int
+	// Backoff 返回第 attempt 次（从 1 开始）失败后的等待时间，err 为该次的错误；为 nil 时不等待
+	Backoff func(attempt int, err error) time.Duration
+	// Jitter 等待时间的随机浮动比例，取值 0~1，如 0.2 表示在 ±20% 内浮动
+	Jitter float64
+	// Retryable 判断错误是否可重试，为 nil 时除 ctx 取消和超时外都可重试
+	Retryable func(err error) bool
+	// OnAttempt 每次尝试结束后调用，err 为 nil 表示成功，可用于记录指标和日志
+	OnAttempt func(attempt int, err error)
+	// Clock 等待使用的时钟，为 nil 时使用系统时钟
+	Clock clock.Clock
+}
+
+// Constant 返回固定间隔的退避函数
+func Constant(d time.Duration) func(int, error) time.Duration {
+	return func(int, error) time.Duration { return d }
+}
+
+// Exponential 返回从 base 开始每次翻倍的退避函数
+func Exponential(base time.Duration) func(int, error) time.Duration {
+	return func(attempt int, _ error) time.Duration { return base << (attempt - 1) }
+}
+
+// Do 执行 fn 直到成功、遇到不可重试的错误、达到最大尝试次数或 ctx 结束。
+// 不可重试的错误原样返回；次数用完时返回包装 ErrExhausted 和最后一次错误的错误；
+// 等待期间 ctx 结束时返回包装 ctx.Err() 和最后一次错误的错误
+func (r Retrier) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	attempts := max(r.MaxAttempts, 1)
+	c := r.Clock
+	if c == nil {
+		c = clock.Real()
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		lastErr = fn(ctx)
+		if r.OnAttempt != nil {
+			r.OnAttempt(attempt, lastErr)
+		}
+		if lastErr == nil {
+			return nil
+		}
+		if !r.retryable(lastErr) {
+			return lastErr
+		}
+		if attempt == attempts {
+			break
+		}
+		if err := clock.SleepContext(ctx, c, r.delay(attempt, lastErr)); err != nil {
+			return fmt.Errorf("%w (last error: %w)", err, lastErr)
+		}
+	}
+	return fmt.Errorf("%w after %d attempts: %w", ErrExhausted, attempts, lastErr)
+}
+
+// retryable 判断错误是否可重试
+func (r Retrier) retryable(err error) bool {
+	if r.Retryable != nil {
+		return r.Retryable(err)
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// delay 返回第 attempt 次失败后的等待时间，按 Jitter 随机浮动
+func (r Retrier) delay(attempt int, err error) time.Duration {
+	if r.Backoff == nil {
+		return 0
+	}
+	d := r.Backoff(attempt, err)
+	if r.Jitter > 0 && d > 0 {
+		jitter := min(r.Jitter, 1)
+		d = time.Duration(float64(d) * (1 + jitter*(2*rand.Float64()-1)))
+	}
+	return d
+}
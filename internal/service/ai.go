@@ -2,13 +2,22 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"net/http"
 	"strings"
 	"time"
 
 	"github.com/sashabaranov/go-openai"
+	"go.orx.me/apps/unifeed/internal/clock"
 	"go.orx.me/apps/unifeed/internal/conf"
 	"go.orx.me/apps/unifeed/internal/logger"
+	"go.orx.me/apps/unifeed/internal/metrics"
+	"go.orx.me/apps/unifeed/internal/retry"
 )
 
 type AiConfig struct {
@@ -24,6 +33,12 @@ type AiService struct {
 	config     conf.AIConfig
 	maxRetries int
 	retryDelay time.Duration
+	// summaryCache 按提示词哈希缓存摘要，为 nil 时不缓存
+	summaryCache *lruCache[string, string]
+	// slots 限制所有 Feed 同时进行的 API 调用数，为 nil 时不限制
+	slots chan struct{}
+	// clock 重试等待使用的时间源
+	clock clock.Clock
 }
 
 // NewAIService 创建一个新的 AI 服务实例
@@ -44,22 +59,56 @@ func NewAIService(config conf.AIConfig) *AiService {
 	if config.MaxTokens == 0 {
 		config.MaxTokens = 500
 	}
-	if config.Temperature == 0 {
-		config.Temperature = 0.7
+	if config.Temperature == nil {
+		temperature := float32(0.7)
+		config.Temperature = &temperature
+	}
+	if config.SummaryCacheSize == 0 {
+		config.SummaryCacheSize = 1000
+	}
+	if config.FallbackSentences <= 0 {
+		config.FallbackSentences = 3
+	}
+	if config.MaxContentLength <= 0 {
+		config.MaxContentLength = 4000
+	}
+	if config.TruncateMode == "" {
+		config.TruncateMode = TruncateSilent
 	}
 
 	logger.Info("Initializing AI service",
+		"disabled", config.Disabled,
+		"dry_run", config.DryRun,
 		"model", config.Model,
+		"language", config.Language,
+		"custom_prompt", config.PromptTemplate != "",
+		"fallback_models", config.FallbackModels,
 		"max_tokens", config.MaxTokens,
-		"temperature", config.Temperature,
+		"temperature", *config.Temperature,
+		"batch_size", config.BatchSize,
+		"summary_cache_size", config.SummaryCacheSize,
+		"global_concurrency", config.GlobalConcurrency,
 	)
 
-	return &AiService{
+	svc := &AiService{
 		client:     client,
 		config:     config,
 		maxRetries: 3,
 		retryDelay: time.Second * 2,
+		clock:      clock.Real(),
+	}
+	svc.SetMaxRetries(config.MaxRetries)
+	svc.SetRetryDelay(config.RetryDelay)
+	if config.GlobalConcurrency > 0 {
+		svc.slots = make(chan struct{}, config.GlobalConcurrency)
+	}
+	// 演练模式不缓存，保证每次都记录提示词
+	if config.SummaryCacheSize > 0 && !config.DryRun {
+		svc.summaryCache = newLRUCache(config.SummaryCacheSize, func(string, string) {
+			metrics.FeedCacheEvictions.WithLabelValues("summary").Inc()
+		})
 	}
+	return svc
 }
 
 // SummarizeArticle 使用 OpenAI API 总结文章内容
@@ -67,37 +116,33 @@ func (s *AiService) SummarizeArticle(ctx context.Context, content string) (strin
 	if content == "" {
 		return "", fmt.Errorf("content cannot be empty")
 	}
-
-	// 如果内容太长，进行截断
-	if len(content) > 4000 {
-		content = content[:4000] + "..."
+	if s.config.Disabled {
+		return ExtractiveSummary(content, s.config.FallbackSentences), nil
 	}
 
-	// 构建提示词
-	prompt := fmt.Sprintf("请用中文总结以下文章的主要内容，突出关键点，并保持简洁：\n\n%s", content)
-
-	var result string
-	var err error
-	for i := 0; i < s.maxRetries; i++ {
-		result, err = s.callOpenAI(ctx, prompt)
-		if err == nil {
-			break
-		}
-		if i < s.maxRetries-1 {
-			time.Sleep(s.retryDelay)
-		}
-	}
+	content, err := s.truncateContent(content)
 	if err != nil {
-		return "", fmt.Errorf("failed to summarize after %d retries: %w", s.maxRetries, err)
+		return "", err
 	}
 
-	return result, nil
+	prompt := s.BuildPrompt(content)
+
+	return s.callWithRetry(ctx, prompt)
 }
 
-// callOpenAI 调用 OpenAI API
-func (s *AiService) callOpenAI(ctx context.Context, prompt string) (string, error) {
+// requestTemperature 转换请求温度，客户端会省略值为 0 的字段，
+// 因此显式的 0 以最小正数发送，效果等同于 0
+func requestTemperature(temperature float32) float32 {
+	if temperature == 0 {
+		return math.SmallestNonzeroFloat32
+	}
+	return temperature
+}
+
+// callOpenAI 使用指定模型调用 OpenAI API，指标按实际使用的模型记录
+func (s *AiService) callOpenAI(ctx context.Context, model, prompt string) (string, error) {
 	req := openai.ChatCompletionRequest{
-		Model: s.config.Model,
+		Model: model,
 		Messages: []openai.ChatCompletionMessage{
 			{
 				Role:    openai.ChatMessageRoleUser,
@@ -105,26 +150,43 @@ func (s *AiService) callOpenAI(ctx context.Context, prompt string) (string, erro
 			},
 		},
 		MaxTokens:   s.config.MaxTokens,
-		Temperature: s.config.Temperature,
+		Temperature: requestTemperature(*s.config.Temperature),
+		Seed:        s.config.Seed,
 	}
 
 	logger.Debug("Calling OpenAI API",
-		"model", s.config.Model,
+		"model", model,
 		"max_tokens", s.config.MaxTokens,
-		"temperature", s.config.Temperature,
+		"temperature", *s.config.Temperature,
 	)
 
+	if s.slots != nil {
+		select {
+		case s.slots <- struct{}{}:
+		case <-ctx.Done():
+			return "", fmt.Errorf("wait for AI concurrency slot: %w", ctx.Err())
+		}
+		defer func() { <-s.slots }()
+	}
+
+	start := time.Now()
 	resp, err := s.client.CreateChatCompletion(ctx, req)
+	metrics.AISummaryDuration.WithLabelValues(model).Observe(time.Since(start).Seconds())
 	if err != nil {
-		logger.Error("OpenAI API call failed", err)
+		metrics.AISummaryTotal.WithLabelValues(model, "error").Inc()
+		logger.Error("OpenAI API call failed", err, "model", model)
 		return "", fmt.Errorf("failed to create chat completion: %w", err)
 	}
 
 	if len(resp.Choices) == 0 {
+		metrics.AISummaryTotal.WithLabelValues(model, "error").Inc()
 		err := fmt.Errorf("no choices returned from OpenAI")
-		logger.Error("OpenAI API returned no choices", err)
+		logger.Error("OpenAI API returned no choices", err, "model", model)
 		return "", err
 	}
+	metrics.AISummaryTotal.WithLabelValues(model, "success").Inc()
+	metrics.AISummaryTokens.WithLabelValues(model).Observe(float64(resp.Usage.TotalTokens))
+	addAIUsage(ctx, resp.Usage.TotalTokens)
 
 	result := strings.TrimSpace(resp.Choices[0].Message.Content)
 	logger.Debug("OpenAI API call successful",
@@ -132,9 +194,50 @@ func (s *AiService) callOpenAI(ctx context.Context, prompt string) (string, erro
 		"usage", resp.Usage,
 	)
 
+	if resp.Choices[0].FinishReason == openai.FinishReasonLength {
+		result = s.handleTruncated(ctx, req, resp.Choices[0].Message.Content)
+	}
 	return result, nil
 }
 
+// truncatedMarker 无法补全的截断摘要末尾附加的标记
+const truncatedMarker = "…"
+
+// continuePrompt 请求模型续写被截断回复的提示词
+const continuePrompt = "Your previous reply was cut off. Continue exactly where it stopped, without repeating anything."
+
+// handleTruncated 处理因 max_tokens 被截断的回复：开启 ContinueTruncated 时请求一次续写并拼接，
+// 续写失败、仍被截断或未开启时在末尾加省略号标记
+func (s *AiService) handleTruncated(ctx context.Context, req openai.ChatCompletionRequest, partial string) string {
+	model := req.Model
+	if s.config.ContinueTruncated {
+		req.Messages = append(req.Messages,
+			openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: partial},
+			openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: continuePrompt},
+		)
+		resp, err := s.client.CreateChatCompletion(ctx, req)
+		switch {
+		case err != nil:
+			logger.Warn("Failed to continue truncated AI response", "model", model, "error", err)
+		case len(resp.Choices) > 0:
+			addAIUsage(ctx, resp.Usage.TotalTokens)
+			combined := strings.TrimSpace(partial + resp.Choices[0].Message.Content)
+			if resp.Choices[0].FinishReason != openai.FinishReasonLength {
+				metrics.AISummaryTruncated.WithLabelValues(model, "continued").Inc()
+				return combined
+			}
+			partial = combined
+		}
+	}
+
+	metrics.AISummaryTruncated.WithLabelValues(model, "flagged").Inc()
+	logger.Warn("AI response truncated by max_tokens",
+		"model", model,
+		"max_tokens", s.config.MaxTokens,
+	)
+	return strings.TrimSpace(partial) + truncatedMarker
+}
+
 // SetMaxRetries 设置最大重试次数
 func (s *AiService) SetMaxRetries(maxRetries int) {
 	if maxRetries > 0 {
@@ -149,6 +252,60 @@ func (s *AiService) SetRetryDelay(delay time.Duration) {
 	}
 }
 
+// SetClock 设置重试等待使用的时间源
+func (s *AiService) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// ForFeed 返回 Feed 使用的 AI 服务，配置了独立接口地址时创建新的客户端，
+// 重试、并发限制和时间源与当前服务共享；未配置时返回当前服务
+func (s *AiService) ForFeed(feed conf.Feed) *AiService {
+	if feed.AI.Endpoint == "" {
+		return s
+	}
+	config := s.config
+	config.Endpoint = feed.AI.Endpoint
+	if feed.AI.APIKey != "" {
+		config.APIKey = feed.AI.APIKey
+	}
+	if feed.AI.Model != "" {
+		config.Model = feed.AI.Model
+	}
+	cfg := openai.DefaultConfig(config.APIKey)
+	cfg.BaseURL = config.Endpoint
+
+	logger.Info("Using feed AI endpoint",
+		"feed_name", feed.Name,
+		"endpoint", config.Endpoint,
+		"model", config.Model,
+		"api_key", maskKey(config.APIKey),
+	)
+
+	svc := &AiService{
+		client:     openai.NewClientWithConfig(cfg),
+		config:     config,
+		maxRetries: s.maxRetries,
+		retryDelay: s.retryDelay,
+		slots:      s.slots,
+		clock:      s.clock,
+	}
+	// 不同接口的摘要不混用缓存
+	if s.summaryCache != nil {
+		svc.summaryCache = newLRUCache(config.SummaryCacheSize, func(string, string) {
+			metrics.FeedCacheEvictions.WithLabelValues("summary").Inc()
+		})
+	}
+	return svc
+}
+
+// maskKey 隐藏 API key，只保留末尾 4 位用于辨认
+func maskKey(key string) string {
+	if len(key) <= 8 {
+		return "****"
+	}
+	return "****" + key[len(key)-4:]
+}
+
 // GetModel 获取当前使用的模型
 func (s *AiService) GetModel() string {
 	return s.config.Model
@@ -167,52 +324,368 @@ func (s *AiService) Summarize(ctx context.Context, content string) (string, erro
 		logger.Error("Failed to summarize content", err)
 		return "", err
 	}
+	if s.config.Disabled {
+		return ExtractiveSummary(content, s.config.FallbackSentences), nil
+	}
+
+	content, err := s.truncateContent(content)
+	if err != nil {
+		return "", err
+	}
 
-	// 如果内容太长，进行截断
+	prompt := s.BuildPrompt(content)
+
+	key := s.cacheKey(prompt)
+	if summary, ok := s.cachedSummary(key); ok {
+		return summary, nil
+	}
+
+	result, err := s.callWithRetry(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+	s.cacheSummary(key, result)
+	return result, nil
+}
+
+// cacheKey 根据模型和提示词生成缓存键
+func (s *AiService) cacheKey(prompt string) string {
+	h := sha256.New()
+	h.Write([]byte(s.config.Model))
+	h.Write([]byte{0})
+	h.Write([]byte(prompt))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cacheNamespace 区分摘要缓存的命名空间：接口地址、模型和提示词任一变化后不再复用旧摘要
+func (s *AiService) cacheNamespace() string {
+	h := sha256.New()
+	for _, part := range []string{s.config.Endpoint, s.config.Model, s.prompts().single, s.config.PromptTemplate} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cachedSummary 从内存缓存读取摘要
+func (s *AiService) cachedSummary(key string) (string, bool) {
+	if s.summaryCache == nil {
+		return "", false
+	}
+	summary, ok := s.summaryCache.Get(key)
+	metrics.UpdateCacheStats(ok)
+	return summary, ok
+}
+
+// cacheSummary 将摘要写入内存缓存
+func (s *AiService) cacheSummary(key, summary string) {
+	if s.summaryCache != nil {
+		s.summaryCache.Add(key, summary)
+	}
+}
+
+// callWithRetry 依次使用主模型和备用模型调用 API，前一个模型重试后仍失败时切换到下一个
+func (s *AiService) callWithRetry(ctx context.Context, prompt string) (string, error) {
+	if s.config.DryRun {
+		s.logDryRun(prompt)
+		return DryRunSummary, nil
+	}
+
+	models := append([]string{s.config.Model}, s.config.FallbackModels...)
+	var lastErr error
+	for i, model := range models {
+		if i > 0 {
+			logger.Warn("Falling back to next model",
+				"model", model,
+				"failed_model", models[i-1],
+				"error", lastErr,
+			)
+		}
+
+		result, err := s.callModelWithRetry(ctx, model, prompt)
+		if err == nil {
+			return result, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return "", lastErr
+}
+
+// callModelWithRetry 使用指定模型调用 API，仅对可重试的错误进行重试
+func (s *AiService) callModelWithRetry(ctx context.Context, model, prompt string) (string, error) {
+	var result string
+	var lastErr error
+	err := s.retrier(model).Do(ctx, func(ctx context.Context) error {
+		result, lastErr = s.callOpenAI(ctx, model, prompt)
+		return lastErr
+	})
+	switch {
+	case err == nil:
+		return result, nil
+	case errors.Is(err, retry.ErrExhausted):
+		err = fmt.Errorf("failed to summarize with %s after %d retries: %w", model, s.maxRetries, lastErr)
+		logger.Error("Failed to summarize content after all retries", err, "model", model)
+		return "", err
+	case err != lastErr:
+		// 等待重试期间 ctx 结束
+		return "", fmt.Errorf("summarize with %s cancelled: %w", model, err)
+	default:
+		errorType, _ := classifyAIError(err)
+		err = fmt.Errorf("failed to summarize with %s (%s): %w", model, errorType, err)
+		logger.Error("Failed to summarize content with non-retryable error", err, "model", model)
+		return "", err
+	}
+}
+
+// retrier 返回调用指定模型的重试策略：按 classifyAIError 判断是否可重试，限流时使用更长的退避
+func (s *AiService) retrier(model string) retry.Retrier {
+	return retry.Retrier{
+		MaxAttempts: s.maxRetries,
+		Jitter:      s.config.RetryJitter,
+		Clock:       s.clock,
+		Retryable: func(err error) bool {
+			_, retryable := classifyAIError(err)
+			return retryable
+		},
+		Backoff: func(attempt int, err error) time.Duration {
+			errorType, _ := classifyAIError(err)
+			return s.backoff(errorType, attempt-1)
+		},
+		OnAttempt: func(attempt int, err error) {
+			if err == nil {
+				logger.Info("Successfully summarized content",
+					"model", model,
+					"attempt", attempt,
+				)
+				return
+			}
+			errorType, retryable := classifyAIError(err)
+			metrics.AISummaryErrors.WithLabelValues(errorType).Inc()
+			if retryable {
+				logger.Warn("Failed to summarize content, retrying",
+					"model", model,
+					"attempt", attempt,
+					"error_type", errorType,
+					"error", err,
+				)
+			}
+		},
+	}
+}
+
+// backoff 计算第 attempt 次失败后的等待时间，限流时使用更长的退避
+func (s *AiService) backoff(errorType string, attempt int) time.Duration {
+	if errorType != aiErrorRateLimit {
+		return s.retryDelay
+	}
+	if s.config.RateLimitDelay > 0 {
+		return s.config.RateLimitDelay
+	}
+	return s.retryDelay << attempt
+}
+
+const (
+	aiErrorRateLimit      = "rate_limit"
+	aiErrorInvalidRequest = "invalid_request"
+	aiErrorAuth           = "auth_error"
+	aiErrorNotFound       = "not_found"
+	aiErrorServer         = "server_error"
+	aiErrorAPI            = "api_error"
+	aiErrorNetwork        = "network_error"
+	aiErrorCanceled       = "canceled"
+)
+
+// classifyAIError 根据 OpenAI 返回的错误类型判断错误分类和是否可重试
+func classifyAIError(err error) (string, bool) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return aiErrorCanceled, false
+	}
+
+	statusCode := 0
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	switch {
+	case errors.As(err, &apiErr):
+		statusCode = apiErr.HTTPStatusCode
+	case errors.As(err, &reqErr):
+		statusCode = reqErr.HTTPStatusCode
+	default:
+		// 网络等非 API 错误
+		return aiErrorNetwork, true
+	}
+
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return aiErrorRateLimit, true
+	case statusCode == http.StatusBadRequest || statusCode == http.StatusUnprocessableEntity:
+		return aiErrorInvalidRequest, false
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return aiErrorAuth, false
+	case statusCode == http.StatusNotFound:
+		return aiErrorNotFound, false
+	case statusCode >= http.StatusInternalServerError:
+		return aiErrorServer, true
+	default:
+		return aiErrorAPI, true
+	}
+}
+
+// 正文超过 MaxContentLength 时的处理方式
+const (
+	TruncateSilent = "silent"
+	TruncateWarn   = "warn"
+	TruncateError  = "error"
+)
+
+// truncateContent 按 MaxContentLength 截断过长的内容，TruncateError 模式下返回错误
+func (s *AiService) truncateContent(content string) (string, error) {
 	originalLength := len(content)
-	if originalLength > 4000 {
-		content = content[:4000] + "..."
+	if originalLength <= s.config.MaxContentLength {
+		return content, nil
+	}
+	metrics.AIContentTruncated.Inc()
+	if s.config.TruncateMode == TruncateError {
+		return "", fmt.Errorf("content length %d exceeds max content length %d", originalLength, s.config.MaxContentLength)
+	}
+
+	content = truncateUTF8(content, s.config.MaxContentLength) + "..."
+	if s.config.TruncateMode == TruncateWarn {
 		logger.Warn("Content truncated for summarization",
 			"original_length", originalLength,
 			"truncated_length", len(content),
 		)
 	}
+	return content, nil
+}
 
-	// 构建提示词
-	prompt := fmt.Sprintf("请用中文总结以下文章的主要内容，突出关键点，并保持简洁：\n\n%s", content)
+// BatchEnabled 是否启用批量总结
+func (s *AiService) BatchEnabled() bool {
+	return !s.config.Disabled && s.config.BatchSize > 1
+}
 
-	var result string
-	var lastErr error
-	for i := 0; i < s.maxRetries; i++ {
-		logger.Debug("Attempting to summarize content",
-			"attempt", i+1,
-			"max_retries", s.maxRetries,
-		)
+// Ping 检查 AI 服务是否可达，只列出模型不消耗 token
+func (s *AiService) Ping(ctx context.Context) error {
+	if _, err := s.client.ListModels(ctx); err != nil {
+		return fmt.Errorf("list models: %w", err)
+	}
+	return nil
+}
 
-		result, lastErr = s.callOpenAI(ctx, prompt)
-		if lastErr == nil {
-			logger.Info("Successfully summarized content",
-				"attempt", i+1,
-				"result_length", len(result),
-			)
-			break
+// DryRunSummary 演练模式返回的占位摘要
+const DryRunSummary = "[dry run] summary not generated"
+
+// DryRun 是否处于演练模式
+func (s *AiService) DryRun() bool {
+	return s.config.DryRun
+}
+
+// logDryRun 记录演练模式下本应发送的请求
+func (s *AiService) logDryRun(prompt string) {
+	logger.Info("AI dry run, prompt not sent",
+		"model", s.config.Model,
+		"max_tokens", s.config.MaxTokens,
+		"prompt", prompt,
+	)
+}
+
+// Disabled 是否关闭 AI，关闭时使用抽取式摘要
+func (s *AiService) Disabled() bool {
+	return s.config.Disabled
+}
+
+// SummarizeBatch 批量总结多篇内容，返回结果与输入顺序一致
+// 启用批量时每 BatchSize 条合并为一次请求，批量结果无法解析时回退为逐条总结
+func (s *AiService) SummarizeBatch(ctx context.Context, contents []string) ([]string, error) {
+	results := make([]string, len(contents))
+	if !s.BatchEnabled() {
+		return results, s.summarizeEach(ctx, contents, results)
+	}
+
+	var errs []error
+	for start := 0; start < len(contents); start += s.config.BatchSize {
+		end := min(start+s.config.BatchSize, len(contents))
+		chunk := contents[start:end]
+
+		summaries, err := s.summarizeChunk(ctx, chunk)
+		if err == nil {
+			copy(results[start:end], summaries)
+			continue
 		}
 
-		logger.Warn("Failed to summarize content, retrying",
-			"attempt", i+1,
-			"error", lastErr,
+		logger.Warn("Batch summarization failed, falling back to per-item",
+			"batch_start", start,
+			"batch_size", len(chunk),
+			"error", err,
 		)
+		if err := s.summarizeEach(ctx, chunk, results[start:end]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return results, errors.Join(errs...)
+}
 
-		if i < s.maxRetries-1 {
-			time.Sleep(s.retryDelay)
+// summarizeEach 逐条总结内容，失败的条目结果为空字符串
+func (s *AiService) summarizeEach(ctx context.Context, contents []string, results []string) error {
+	var errs []error
+	for i, content := range contents {
+		if content == "" {
+			continue
 		}
+		summary, err := s.Summarize(ctx, content)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("item %d: %w", i, err))
+			continue
+		}
+		results[i] = summary
 	}
+	return errors.Join(errs...)
+}
 
-	if lastErr != nil {
-		err := fmt.Errorf("failed to summarize after %d retries: %w", s.maxRetries, lastErr)
-		logger.Error("Failed to summarize content after all retries", err)
-		return "", err
+// summarizeChunk 将一组内容合并为一次请求进行总结
+func (s *AiService) summarizeChunk(ctx context.Context, contents []string) ([]string, error) {
+	truncated := make([]string, len(contents))
+	for i, content := range contents {
+		content, err := s.truncateContent(content)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+		truncated[i] = content
 	}
+	prompt := s.buildBatchPrompt(truncated)
 
-	return result, nil
+	if s.config.DryRun {
+		s.logDryRun(prompt)
+		summaries := make([]string, len(contents))
+		for i := range summaries {
+			summaries[i] = DryRunSummary
+		}
+		return summaries, nil
+	}
+
+	result, err := s.callWithRetry(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	return parseBatchSummaries(result, len(contents))
+}
+
+// parseBatchSummaries 解析批量总结返回的 JSON 数组
+func parseBatchSummaries(result string, expected int) ([]string, error) {
+	result = strings.TrimSpace(result)
+	result = strings.TrimPrefix(result, "```json")
+	result = strings.TrimPrefix(result, "```")
+	result = strings.TrimSuffix(result, "```")
+
+	var summaries []string
+	if err := json.Unmarshal([]byte(strings.TrimSpace(result)), &summaries); err != nil {
+		return nil, fmt.Errorf("failed to decode batch summaries: %w", err)
+	}
+	if len(summaries) != expected {
+		return nil, fmt.Errorf("batch summary count mismatch: got %d, want %d", len(summaries), expected)
+	}
+	return summaries, nil
 }
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"go.orx.me/apps/unifeed/internal/metrics"
+)
+
+// aiUsage 一次总结过程中实际发出的 API 请求数和消耗的 token 数
+type aiUsage struct {
+	requests atomic.Int64
+	tokens   atomic.Int64
+}
+
+type aiUsageKey struct{}
+
+// withAIUsage 返回记录 API 用量的 ctx，callOpenAI 成功返回后累加到 usage
+func withAIUsage(ctx context.Context) (context.Context, *aiUsage) {
+	usage := &aiUsage{}
+	return context.WithValue(ctx, aiUsageKey{}, usage), usage
+}
+
+// addAIUsage 将一次 API 调用的用量累加到 ctx 中的记录，没有记录时忽略
+func addAIUsage(ctx context.Context, tokens int) {
+	if usage, ok := ctx.Value(aiUsageKey{}).(*aiUsage); ok {
+		usage.requests.Add(1)
+		usage.tokens.Add(int64(tokens))
+	}
+}
+
+// aiBudget 单个 Feed 每天的 AI 用量上限，0 表示不限制
+type aiBudget struct {
+	tokens   int64
+	requests int64
+}
+
+// aiSpend 单个 Feed 当天已使用的 AI 用量
+type aiSpend struct {
+	day      string
+	tokens   int64
+	requests int64
+}
+
+// SetFeedAIBudget 设置 Feed 每天（UTC）最多消耗的 token 数和 API 请求数，0 表示不限制；
+// 达到上限后当天的新条目不再总结，原样存储并标记为待生成，次日更新时补全
+func (s *RssService) SetFeedAIBudget(feedName string, dailyTokens, dailyRequests int) {
+	s.aiSpendMu.Lock()
+	defer s.aiSpendMu.Unlock()
+	if s.aiBudgets == nil {
+		s.aiBudgets = make(map[string]aiBudget)
+		s.aiSpends = make(map[string]*aiSpend)
+	}
+	s.aiBudgets[feedName] = aiBudget{tokens: int64(dailyTokens), requests: int64(dailyRequests)}
+}
+
+// hasAIBudget 是否为 Feed 设置了每日用量上限
+func (s *RssService) hasAIBudget(feedName string) bool {
+	s.aiSpendMu.Lock()
+	defer s.aiSpendMu.Unlock()
+	_, ok := s.aiBudgets[feedName]
+	return ok
+}
+
+// todaySpend 返回 Feed 当天的用量，跨天时重新计数，调用方需持有锁
+func (s *RssService) todaySpend(feedName string) *aiSpend {
+	day := s.clock.Now().UTC().Format(time.DateOnly)
+	spend, ok := s.aiSpends[feedName]
+	if !ok || spend.day != day {
+		spend = &aiSpend{day: day}
+		s.aiSpends[feedName] = spend
+		metrics.FeedAIDailyTokens.WithLabelValues(feedName).Set(0)
+	}
+	return spend
+}
+
+// aiBudgetExhausted Feed 当天的用量是否已达到上限
+func (s *RssService) aiBudgetExhausted(feedName string) bool {
+	s.aiSpendMu.Lock()
+	defer s.aiSpendMu.Unlock()
+	budget, ok := s.aiBudgets[feedName]
+	if !ok {
+		return false
+	}
+	spend := s.todaySpend(feedName)
+	return (budget.tokens > 0 && spend.tokens >= budget.tokens) ||
+		(budget.requests > 0 && spend.requests >= budget.requests)
+}
+
+// recordAISpend 将一次总结的用量计入 Feed 当天的用量
+func (s *RssService) recordAISpend(feedName string, usage *aiUsage) {
+	s.aiSpendMu.Lock()
+	defer s.aiSpendMu.Unlock()
+	if _, ok := s.aiBudgets[feedName]; !ok {
+		return
+	}
+	spend := s.todaySpend(feedName)
+	spend.tokens += usage.tokens.Load()
+	spend.requests += usage.requests.Load()
+	metrics.FeedAIDailyTokens.WithLabelValues(feedName).Set(float64(spend.tokens))
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+type AtomFeed struct {
+	XMLName  xml.Name    `xml:"feed"`
+	XMLNS    string      `xml:"xmlns,attr"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Author   *AtomPerson `xml:"author,omitempty"`
+	Links    []AtomLink  `xml:"link"`
+	Entries  []AtomEntry `xml:"entry"`
+}
+
+type AtomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published,omitempty"`
+	Author     *AtomPerson    `xml:"author,omitempty"`
+	Links      []AtomLink     `xml:"link"`
+	Categories []AtomCategory `xml:"category,omitempty"`
+	Content    *AtomText      `xml:"content,omitempty"`
+}
+
+type AtomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Length string `xml:"length,attr,omitempty"`
+}
+
+type AtomPerson struct {
+	Name string `xml:"name"`
+}
+
+type AtomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type AtomText struct {
+	Type string `xml:"type,attr,omitempty"`
+	Body string `xml:",chardata"`
+}
+
+// RenderAtom 将频道渲染为 Atom 1.0 XML
+func RenderAtom(channel Channel) (string, error) {
+	now := time.Now().UTC()
+	feed := AtomFeed{
+		XMLNS:    atomNamespace,
+		ID:       atomID(channel.Link, "feed:"+channel.Title),
+		Title:    channel.Title,
+		Subtitle: channel.Description,
+		// 并非每个条目都有作者，用频道标题作为默认作者
+		Author:  &AtomPerson{Name: channel.Title},
+		Entries: make([]AtomEntry, 0, len(channel.Items)),
+	}
+	if channel.Link != "" {
+		feed.Links = append(feed.Links, AtomLink{Href: channel.Link, Rel: "alternate"})
+	}
+	if channel.SelfLink != "" {
+		feed.Links = append(feed.Links, AtomLink{Href: channel.SelfLink, Rel: "self", Type: "application/atom+xml"})
+	}
+
+	var latest time.Time
+	for _, item := range channel.Items {
+		entry := AtomEntry{
+			ID:    atomID(item.GUID, "item:"+item.Link+item.Title),
+			Title: item.Title,
+		}
+		// 无法解析发布时间时以当前时间作为更新时间，Atom 要求 updated 必填
+		updated := now
+		if published, ok := parsePubDate(item.PubDate); ok {
+			updated = published
+			entry.Published = published.Format(time.RFC3339)
+		}
+		entry.Updated = updated.Format(time.RFC3339)
+		if updated.After(latest) {
+			latest = updated
+		}
+		if item.Link != "" {
+			entry.Links = append(entry.Links, AtomLink{Href: item.Link, Rel: "alternate"})
+		}
+		if item.Enclosure != nil && item.Enclosure.URL != "" {
+			entry.Links = append(entry.Links, AtomLink{
+				Href:   item.Enclosure.URL,
+				Rel:    "enclosure",
+				Type:   item.Enclosure.Type,
+				Length: item.Enclosure.Length,
+			})
+		}
+		if item.Author != "" {
+			entry.Author = &AtomPerson{Name: item.Author}
+		}
+		for _, category := range item.Categories {
+			entry.Categories = append(entry.Categories, AtomCategory{Term: category})
+		}
+		if item.Description != "" {
+			entry.Content = &AtomText{Type: "html", Body: item.Description}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	if latest.IsZero() {
+		latest = now
+	}
+	feed.Updated = latest.Format(time.RFC3339)
+
+	out, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal atom: %w", err)
+	}
+	return string(out), nil
+}
+
+// atomID 生成 Atom id，优先使用绝对 URI，否则根据 fallback 生成稳定的 URN
+func atomID(uri, fallback string) string {
+	if u, err := url.Parse(uri); err == nil && u.Scheme != "" {
+		return uri
+	}
+	if uri != "" {
+		fallback = uri
+	}
+	sum := sha256.Sum256([]byte(fallback))
+	return "urn:unifeed:" + hex.EncodeToString(sum[:16])
+}
+
+// parsePubDate 解析 RSS 的发布时间
+func parsePubDate(s string) (time.Time, bool) {
+	if s == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{time.RFC1123Z, time.RFC1123, time.RFC3339} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
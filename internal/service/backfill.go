@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/mmcdole/gofeed"
+	"go.orx.me/apps/unifeed/internal/logger"
+	"go.orx.me/apps/unifeed/internal/metrics"
+)
+
+// storedItems 读取已存储的条目，最近写入的排在前面
+func (s *RssService) storedItems(ctx context.Context, feedName string) ([]*gofeed.Item, error) {
+	raw, err := s.GetStoredFeedItems(ctx, feedName)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*gofeed.Item, 0, len(raw))
+	for _, r := range raw {
+		data, err := json.Marshal(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal stored item: %w", err)
+		}
+		var item gofeed.Item
+		if err := json.Unmarshal(data, &item); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal stored item: %w", err)
+		}
+		items = append(items, &item)
+	}
+	return items, nil
+}
+
+// summaryPendingKey 标记摘要待生成的自定义字段
+const summaryPendingKey = "summary_pending"
+
+// hasSummary 条目是否已有正式摘要，待生成的占位摘要不计入
+func hasSummary(item *gofeed.Item) bool {
+	return item.Custom["summary"] != "" && item.Custom[summaryPendingKey] != "true"
+}
+
+// markSummaryPending 将没有摘要的条目标记为待生成
+func markSummaryPending(items []*gofeed.Item) {
+	for _, item := range items {
+		if item.Custom["summary"] != "" {
+			continue
+		}
+		if item.Custom == nil {
+			item.Custom = make(map[string]string)
+		}
+		item.Custom[summaryPendingKey] = "true"
+	}
+}
+
+// anySummaryPending 是否有条目的摘要失败或被推迟，仍标记为待生成
+func anySummaryPending(items []*gofeed.Item) bool {
+	for _, item := range items {
+		if item.Custom[summaryPendingKey] == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+// summarizeNewItems 为尚未存储的条目生成摘要，已存储的条目沿用存储的摘要；
+// 已存储但摘要待生成的条目在 retryPending 为 true 时重试，否则保持待生成留给补全
+func (s *RssService) summarizeNewItems(ctx context.Context, log *slog.Logger, feedName string, items []*gofeed.Item, retryPending bool) {
+	stored, err := s.storedItems(ctx, feedName)
+	if err != nil {
+		log.Warn("Failed to load stored items, summarizing all", "error", err)
+		s.summarizeItems(ctx, log, feedName, items)
+		return
+	}
+
+	summaries := make(map[string]string, len(stored))
+	for _, item := range stored {
+		summary := ""
+		if hasSummary(item) {
+			summary = item.Custom["summary"]
+		}
+		summaries[s.itemObjectName(feedName, item)] = summary
+	}
+
+	var fresh []*gofeed.Item
+	for _, item := range items {
+		summary, ok := summaries[s.itemObjectName(feedName, item)]
+		switch {
+		case !ok, summary == "" && retryPending:
+			fresh = append(fresh, item)
+		case summary == "":
+			markSummaryPending([]*gofeed.Item{item})
+		default:
+			if item.Custom == nil {
+				item.Custom = make(map[string]string)
+			}
+			item.Custom["summary"] = summary
+			delete(item.Custom, summaryPendingKey)
+		}
+	}
+
+	if n := len(items) - len(fresh); n > 0 {
+		log.Info("Reusing stored items", "reused", n, "summarizing", len(fresh))
+	}
+	if len(fresh) > 0 {
+		s.summarizeItems(ctx, log, feedName, fresh)
+	}
+}
+
+// backfillPending 快速启动后每次更新补全一批待生成的摘要，失败时下次更新重试
+func (s *RssService) backfillPending(ctx context.Context, log *slog.Logger, feedName string) {
+	if _, err := s.BackfillSummaries(ctx, feedName, s.config.BackfillBatch); err != nil {
+		log.Warn("Failed to backfill summaries", "error", err)
+	}
+}
+
+// BackfillSummaries 为最多 limit 个没有摘要的已存储条目生成摘要并写回，返回补全的条目数
+func (s *RssService) BackfillSummaries(ctx context.Context, feedName string, limit int) (int, error) {
+	items, err := s.storedItems(ctx, feedName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load stored items: %w", err)
+	}
+
+	var pending []*gofeed.Item
+	for _, item := range items {
+		if limit > 0 && len(pending) >= limit {
+			break
+		}
+		if !hasSummary(item) {
+			pending = append(pending, item)
+		}
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	log := logger.WithContext(ctx).With("feed_name", feedName)
+	s.summarizeItems(ctx, log, feedName, pending)
+
+	var done []*gofeed.Item
+	for _, item := range pending {
+		if hasSummary(item) {
+			done = append(done, item)
+		}
+	}
+	if len(done) == 0 {
+		return 0, nil
+	}
+
+	if err := s.StoreFeedItems(ctx, feedName, done); err != nil {
+		return 0, fmt.Errorf("failed to store backfilled items: %w", err)
+	}
+	// StoreFeedItems 按本次写入数量设置了条目数，恢复为全部条目
+	metrics.FeedItemsTotal.WithLabelValues(feedName).Set(float64(len(items)))
+
+	remaining := 0
+	for _, item := range items {
+		if !hasSummary(item) {
+			remaining++
+		}
+	}
+	log.Info("Backfilled summaries",
+		"count", len(done),
+		"remaining", remaining,
+	)
+	return len(done), nil
+}
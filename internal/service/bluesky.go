@@ -3,50 +3,92 @@ package service
 import (
 	"context"
 	"encoding/json"
-	"encoding/xml"
 	"fmt"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/bluesky-social/indigo/api/atproto"
 	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/atproto/syntax"
 	"github.com/bluesky-social/indigo/xrpc"
-	"github.com/ipfs/go-cid"
 	"go.orx.me/apps/unifeed/internal/conf"
+	"go.orx.me/apps/unifeed/internal/logger"
 )
 
 type BlueskyService struct {
-	client *http.Client
+	client     *http.Client
+	enclosures *EnclosureResolver
+	// sessions 按 Host、Handle 和 app password 缓存登录获得的会话，凭据变化后不再复用旧会话
+	sessions map[sessionKey]*xrpc.AuthInfo
+	mu       sync.Mutex
+	// timeout 拉取一次时间线的超时时间，为 0 时只受调用方 context 控制
+	timeout time.Duration
 }
 
 func NewBlueskyService() *BlueskyService {
 	return &BlueskyService{client: &http.Client{Timeout: 10 * time.Second}}
 }
 
+// SetEnclosureResolver 设置附件信息补全器，为 nil 时不补全
+func (s *BlueskyService) SetEnclosureResolver(r *EnclosureResolver) {
+	s.enclosures = r
+}
+
+// SetTimeout 设置拉取一次时间线的超时时间，为 0 时不限制
+func (s *BlueskyService) SetTimeout(d time.Duration) {
+	s.timeout = d
+}
+
 // 拉取 Bluesky timeline 并生成 RSS XML
-func (s *BlueskyService) TimelineToRSS(feed conf.Feed) (string, error) {
+func (s *BlueskyService) TimelineToRSS(ctx context.Context, feed conf.Feed) (string, error) {
+	channel, err := s.Timeline(ctx, feed)
+	if err != nil {
+		return "", err
+	}
+	return RenderRSS(channel)
+}
+
+// Timeline 拉取 Bluesky timeline 并构建可渲染的频道，超过 ctx 的截止时间或 SetTimeout 的超时时间时中止
+func (s *BlueskyService) Timeline(ctx context.Context, feed conf.Feed) (Channel, error) {
 	if feed.Bluesky.Host == "" || feed.Bluesky.Handle == "" {
-		return "", fmt.Errorf("bluesky config required")
+		return Channel{}, fmt.Errorf("bluesky config required")
+	}
+
+	ctx, cancel := withOptionalTimeout(ctx, s.timeout)
+	defer cancel()
+	did, err := s.ResolveDID(ctx, feed.Bluesky.Host, feed.Bluesky.Handle)
+	if err != nil {
+		return Channel{}, err
 	}
 
 	// 创建 XRPC 客户端
+	auth, err := s.session(ctx, feed, did)
+	if err != nil {
+		return Channel{}, err
+	}
 	client := &xrpc.Client{
-		Host: feed.Bluesky.Host,
-		Auth: &xrpc.AuthInfo{
-			Handle: feed.Bluesky.Handle,
-			Did:    feed.Bluesky.AppKey,
-		},
+		Client: s.client,
+		Host:   feed.Bluesky.Host,
+		Auth:   auth,
 	}
 
-	// 获取用户 timeline
-	ctx := context.Background()
-	timeline, err := bsky.FeedGetTimeline(ctx, client, "", "", 50)
+	// 获取用户 timeline，登录会话的令牌失效时刷新后重试一次
+	posts, err := fetchTimeline(ctx, client, feed.Bluesky)
+	if err != nil && feed.Bluesky.AppSecret != "" && isUnauthorized(err) {
+		if client.Auth, err = s.refresh(ctx, feed, auth); err != nil {
+			return Channel{}, err
+		}
+		posts, err = fetchTimeline(ctx, client, feed.Bluesky)
+	}
 	if err != nil {
-		return "", fmt.Errorf("get timeline: %w", err)
+		return Channel{}, err
 	}
 
 	// 构建 RSS 内容
-	items := make([]RSSItem, 0, len(timeline.Feed))
-	for _, item := range timeline.Feed {
+	items := make([]RSSItem, 0, len(posts))
+	for _, item := range posts {
 		if item.Post == nil {
 			continue
 		}
@@ -62,6 +104,7 @@ func (s *BlueskyService) TimelineToRSS(feed conf.Feed) (string, error) {
 				} `json:"images"`
 			} `json:"embed"`
 			Labels []struct{ Val string } `json:"labels"`
+			Reply  json.RawMessage        `json:"reply"`
 		}
 		recordJSON, err := item.Post.Record.MarshalJSON()
 		if err != nil {
@@ -70,9 +113,16 @@ func (s *BlueskyService) TimelineToRSS(feed conf.Feed) (string, error) {
 		if err := json.Unmarshal(recordJSON, &postValue); err != nil {
 			continue
 		}
+		if (item.Reply != nil || postValue.Reply != nil) && !feed.Bluesky.RepliesIncluded() {
+			continue
+		}
+		if author := item.Post.Author; author != nil && !authorAllowed(feed, author.Handle, author.Did) {
+			continue
+		}
 
 		// 构建媒体内容
 		mediaHTML := ""
+		var enclosure *Enclosure
 		if postValue.Embed != nil {
 			if postValue.Embed.Record != nil {
 				// 处理引用帖子
@@ -82,6 +132,9 @@ func (s *BlueskyService) TimelineToRSS(feed conf.Feed) (string, error) {
 				// 处理图片
 				for _, img := range postValue.Embed.Images {
 					mediaHTML += fmt.Sprintf(`<br><img src="%s" alt="%s"/>`, img.Fullsize, img.Alt)
+					if enclosure == nil && img.Fullsize != "" {
+						enclosure = &Enclosure{URL: img.Fullsize}
+					}
 				}
 			}
 		}
@@ -104,38 +157,84 @@ func (s *BlueskyService) TimelineToRSS(feed conf.Feed) (string, error) {
 			continue
 		}
 
-		// 获取帖子 ID
-		postID, err := cid.Parse(item.Post.Uri)
+		// 从 at:// URI 中获取帖子 ID
+		postURI, err := syntax.ParseATURI(item.Post.Uri)
 		if err != nil {
 			continue
 		}
 
+		link := fmt.Sprintf("https://bsky.app/profile/%s/post/%s", item.Post.Author.Handle, postURI.RecordKey())
 		items = append(items, RSSItem{
 			Title:       postValue.Text,
-			Link:        fmt.Sprintf("https://bsky.app/profile/%s/post/%s", item.Post.Author.Handle, postID.String()),
+			Link:        link,
 			Description: postValue.Text + mediaHTML,
 			PubDate:     createdAt.Format(time.RFC1123Z),
 			GUID:        item.Post.Uri,
 			Author:      authorName,
 			Categories:  categories,
+			Enclosure:   enclosure,
+			parts: ContentData{
+				Title:       postValue.Text,
+				Link:        link,
+				Author:      authorName,
+				Content:     postValue.Text,
+				Media:       mediaHTML,
+				Description: postValue.Text + mediaHTML,
+			},
 		})
 	}
 
-	// 构建 RSS feed
-	rss := RSS{
-		Version: "2.0",
-		Channel: Channel{
-			Title: feed.Name,
-			Link:  feed.Bluesky.Host,
-			Items: items,
-		},
+	s.enclosures.ResolveItems(ctx, items)
+	return newChannel(feed, FeedMeta{}, feed.Bluesky.Host, items), nil
+}
+
+// fetchTimeline 按配置的页数拉取时间线，
+// 非严格模式下后续页失败时返回已获取的内容
+func fetchTimeline(ctx context.Context, client *xrpc.Client, cfg conf.Bluesky) ([]*bsky.FeedDefs_FeedViewPost, error) {
+	pages := cfg.Pages
+	if pages <= 0 {
+		pages = 1
 	}
 
-	// 转换为 XML
-	out, err := xml.MarshalIndent(rss, "", "  ")
+	var posts []*bsky.FeedDefs_FeedViewPost
+	cursor := ""
+	for page := 0; page < pages; page++ {
+		timeline, err := bsky.FeedGetTimeline(ctx, client, "", cursor, 50)
+		if err != nil {
+			if page == 0 || cfg.StrictPagination {
+				return nil, fmt.Errorf("get timeline page %d: %w", page+1, err)
+			}
+			logger.Warn("Failed to fetch timeline page, using partial results",
+				"host", cfg.Host,
+				"page", page+1,
+				"posts", len(posts),
+				"error", err,
+			)
+			break
+		}
+		posts = append(posts, timeline.Feed...)
+		if timeline.Cursor == nil || *timeline.Cursor == "" || len(timeline.Feed) == 0 {
+			break
+		}
+		cursor = *timeline.Cursor
+	}
+	return posts, nil
+}
+
+// ResolveDID 将 Bluesky 账号标识解析为 DID，标识本身为 DID 时直接返回
+func (s *BlueskyService) ResolveDID(ctx context.Context, host, identifier string) (string, error) {
+	id, err := syntax.ParseAtIdentifier(strings.TrimPrefix(identifier, "@"))
 	if err != nil {
-		return "", fmt.Errorf("marshal rss: %w", err)
+		return "", fmt.Errorf("invalid bluesky handle %q: %w", identifier, err)
+	}
+	if id.IsDID() {
+		return id.String(), nil
 	}
 
-	return string(out), nil
+	client := &xrpc.Client{Client: s.client, Host: host}
+	out, err := atproto.IdentityResolveHandle(ctx, client, id.String())
+	if err != nil {
+		return "", fmt.Errorf("resolve handle %s: %w", id, err)
+	}
+	return out.Did, nil
 }
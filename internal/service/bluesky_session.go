@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/xrpc"
+	"go.orx.me/apps/unifeed/internal/conf"
+	"go.orx.me/apps/unifeed/internal/logger"
+)
+
+// sessionKey 会话缓存的键，同一 Feed 修改凭据或同名 Feed 被替换后使用新的会话
+type sessionKey struct {
+	host, handle, secret string
+}
+
+// sessionKeyOf 返回 Feed 凭据对应的会话缓存键
+func sessionKeyOf(feed conf.Feed) sessionKey {
+	return sessionKey{
+		host:   feed.Bluesky.Host,
+		handle: strings.TrimPrefix(feed.Bluesky.Handle, "@"),
+		secret: feed.Bluesky.AppSecret,
+	}
+}
+
+// AccessToken 返回请求 Feed 的 Bluesky 上游时使用的访问令牌，配置了 AppSecret 时使用登录获得的会话
+func (s *BlueskyService) AccessToken(ctx context.Context, feed conf.Feed) (string, error) {
+	auth, err := s.session(ctx, feed, "")
+	if err != nil {
+		return "", err
+	}
+	return auth.AccessJwt, nil
+}
+
+// session 返回 Feed 的认证信息：配置了 AppSecret 时使用缓存的会话，没有会话时调用 createSession 登录，
+// 否则将 AppKey 作为访问令牌
+func (s *BlueskyService) session(ctx context.Context, feed conf.Feed, did string) (*xrpc.AuthInfo, error) {
+	if feed.Bluesky.AppSecret == "" {
+		return &xrpc.AuthInfo{
+			AccessJwt: feed.Bluesky.AppKey,
+			Handle:    strings.TrimPrefix(feed.Bluesky.Handle, "@"),
+			Did:       did,
+		}, nil
+	}
+
+	s.mu.Lock()
+	auth, ok := s.sessions[sessionKeyOf(feed)]
+	s.mu.Unlock()
+	if ok {
+		return auth, nil
+	}
+	return s.login(ctx, feed)
+}
+
+// login 使用 Handle 和 app password 调用 com.atproto.server.createSession 并缓存会话
+func (s *BlueskyService) login(ctx context.Context, feed conf.Feed) (*xrpc.AuthInfo, error) {
+	client := &xrpc.Client{Client: s.client, Host: feed.Bluesky.Host}
+	out, err := atproto.ServerCreateSession(ctx, client, &atproto.ServerCreateSession_Input{
+		Identifier: strings.TrimPrefix(feed.Bluesky.Handle, "@"),
+		Password:   feed.Bluesky.AppSecret,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create bluesky session: %w", err)
+	}
+
+	auth := &xrpc.AuthInfo{
+		AccessJwt:  out.AccessJwt,
+		RefreshJwt: out.RefreshJwt,
+		Handle:     out.Handle,
+		Did:        out.Did,
+	}
+	s.storeSession(feed, auth)
+	logger.Info("Created bluesky session", "feed_name", feed.Name, "did", out.Did)
+	return auth, nil
+}
+
+// refresh 使用 refreshJwt 调用 com.atproto.server.refreshSession，刷新失败时重新登录
+func (s *BlueskyService) refresh(ctx context.Context, feed conf.Feed, auth *xrpc.AuthInfo) (*xrpc.AuthInfo, error) {
+	// refreshSession 以 refreshJwt 作为 Bearer 令牌
+	client := &xrpc.Client{
+		Client: s.client,
+		Host:   feed.Bluesky.Host,
+		Auth:   &xrpc.AuthInfo{AccessJwt: auth.RefreshJwt},
+	}
+	out, err := atproto.ServerRefreshSession(ctx, client)
+	if err != nil {
+		logger.Warn("Failed to refresh bluesky session, logging in again",
+			"feed_name", feed.Name,
+			"error", err,
+		)
+		return s.login(ctx, feed)
+	}
+
+	refreshed := &xrpc.AuthInfo{
+		AccessJwt:  out.AccessJwt,
+		RefreshJwt: out.RefreshJwt,
+		Handle:     out.Handle,
+		Did:        out.Did,
+	}
+	s.storeSession(feed, refreshed)
+	logger.Debug("Refreshed bluesky session", "feed_name", feed.Name)
+	return refreshed, nil
+}
+
+// storeSession 按 Feed 的凭据缓存会话
+func (s *BlueskyService) storeSession(feed conf.Feed, auth *xrpc.AuthInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sessions == nil {
+		s.sessions = make(map[sessionKey]*xrpc.AuthInfo)
+	}
+	s.sessions[sessionKeyOf(feed)] = auth
+}
+
+// isUnauthorized 判断 XRPC 错误是否由访问令牌失效导致
+func isUnauthorized(err error) bool {
+	var xerr *xrpc.Error
+	if !errors.As(err, &xerr) {
+		return false
+	}
+	if xerr.StatusCode == http.StatusUnauthorized {
+		return true
+	}
+	// PDS 对过期令牌返回 400 ExpiredToken
+	var body *xrpc.XRPCError
+	return errors.As(xerr.Wrapped, &body) && body.ErrStr == "ExpiredToken"
+}
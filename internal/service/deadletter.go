@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"go.orx.me/apps/unifeed/internal/dao"
+	"go.orx.me/apps/unifeed/internal/logger"
+	"go.orx.me/apps/unifeed/internal/metrics"
+)
+
+// 条目失败的阶段
+const (
+	DeadLetterStore     = "store"
+	DeadLetterSummarize = "summarize"
+)
+
+// errSummaryFailed 摘要生成失败的死信原因，具体错误已在生成时记录日志
+var errSummaryFailed = errors.New("summary generation failed")
+
+// DeadLetter 连续失败的条目及失败原因
+type DeadLetter struct {
+	Feed     string       `json:"feed"`
+	Stage    string       `json:"stage"`
+	Reason   string       `json:"reason"`
+	Attempts int          `json:"attempts"`
+	FailedAt time.Time    `json:"failed_at"`
+	Item     *gofeed.Item `json:"item"`
+}
+
+// DeadLetterPrefix 返回 Feed 死信条目的存储前缀
+func DeadLetterPrefix(feedName string) string {
+	return fmt.Sprintf("deadletter/%s/", feedKeyName(feedName))
+}
+
+// deadLetterObjectName 返回条目的死信存储路径，文件名与条目存储路径一致
+func (s *RssService) deadLetterObjectName(feedName string, item *gofeed.Item) string {
+	return DeadLetterPrefix(feedName) + strings.TrimPrefix(s.itemObjectName(feedName, item), s.itemKeys.itemsPrefix(feedName))
+}
+
+// itemFailureKey 返回条目失败计数的键
+func (s *RssService) itemFailureKey(feedName, stage string, item *gofeed.Item) string {
+	return stage + ":" + s.itemObjectName(feedName, item)
+}
+
+// recordItemFailure 记录条目的一次失败，连续失败达到 DeadLetterAfter 时写入死信并重新计数
+func (s *RssService) recordItemFailure(ctx context.Context, feedName, stage string, item *gofeed.Item, reason error) {
+	if s.config.DeadLetterAfter <= 0 {
+		return
+	}
+
+	key := s.itemFailureKey(feedName, stage, item)
+	s.itemFailuresMu.Lock()
+	if s.itemFailures == nil {
+		s.itemFailures = make(map[string]int)
+	}
+	s.itemFailures[key]++
+	attempts := s.itemFailures[key]
+	if attempts >= s.config.DeadLetterAfter {
+		delete(s.itemFailures, key)
+	}
+	s.itemFailuresMu.Unlock()
+
+	if attempts < s.config.DeadLetterAfter {
+		return
+	}
+	if err := s.writeDeadLetter(ctx, feedName, stage, item, reason, attempts); err != nil {
+		logger.Error("Failed to write dead letter", err,
+			"feed_name", feedName,
+			"stage", stage,
+		)
+	}
+}
+
+// clearItemFailure 条目成功后清除失败计数
+func (s *RssService) clearItemFailure(feedName, stage string, item *gofeed.Item) {
+	if s.config.DeadLetterAfter <= 0 {
+		return
+	}
+
+	s.itemFailuresMu.Lock()
+	defer s.itemFailuresMu.Unlock()
+	delete(s.itemFailures, s.itemFailureKey(feedName, stage, item))
+}
+
+// writeDeadLetter 将失败的条目及原因写入死信前缀
+func (s *RssService) writeDeadLetter(ctx context.Context, feedName, stage string, item *gofeed.Item, reason error, attempts int) error {
+	letter := DeadLetter{
+		Feed:     feedName,
+		Stage:    stage,
+		Reason:   reason.Error(),
+		Attempts: attempts,
+		FailedAt: s.clock.Now().UTC(),
+		Item:     item,
+	}
+	data, err := json.Marshal(letter)
+	if err != nil {
+		return fmt.Errorf("marshal dead letter: %w", err)
+	}
+
+	store := s.storeFor(feedName)
+	if store == nil {
+		return fmt.Errorf("S3 client not configured")
+	}
+	objectName := s.deadLetterObjectName(feedName, item)
+	if err := store.PutObject(ctx, objectName, data, dao.PutOptions{
+		ContentType: "application/json",
+		Tags: map[string]string{
+			dao.TagFeed:    dao.TagValue(feedName),
+			dao.TagType:    "deadletter",
+			dao.TagCreated: letter.FailedAt.Format("2006-01-02"),
+		},
+	}); err != nil {
+		return fmt.Errorf("store dead letter %s: %w", objectName, err)
+	}
+
+	metrics.FeedDeadLetters.WithLabelValues(feedName, stage).Inc()
+	logger.Warn("Item moved to dead letter",
+		"feed_name", feedName,
+		"stage", stage,
+		"object_name", objectName,
+		"attempts", attempts,
+		"reason", letter.Reason,
+	)
+	return nil
+}
+
+// DeadLetterReplay 死信重放的结果统计
+type DeadLetterReplay struct {
+	Total    int `json:"total"`
+	Replayed int `json:"replayed"`
+	Failed   int `json:"failed"`
+}
+
+// ReplayDeadLetters 重新总结并存储 Feed 的死信条目，成功的条目从死信前缀中删除
+func (s *RssService) ReplayDeadLetters(ctx context.Context, feedName string) (DeadLetterReplay, error) {
+	var result DeadLetterReplay
+	store := s.storeFor(feedName)
+	if store == nil {
+		return result, fmt.Errorf("S3 client not configured")
+	}
+	objects, err := store.ListObjects(ctx, DeadLetterPrefix(feedName))
+	if err != nil {
+		return result, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+
+	log := logger.WithContext(ctx).With("feed_name", feedName)
+	for _, obj := range objects {
+		result.Total++
+		if err := s.replayDeadLetter(ctx, log, store, feedName, obj.Key); err != nil {
+			result.Failed++
+			log.Warn("Failed to replay dead letter", "object_name", obj.Key, "error", err)
+			continue
+		}
+		result.Replayed++
+	}
+
+	if result.Replayed > 0 {
+		// StoreFeedItems 按本次写入数量设置了条目数，恢复为全部条目
+		if items, err := s.GetStoredFeedItems(ctx, feedName); err == nil {
+			metrics.FeedItemsTotal.WithLabelValues(feedName).Set(float64(len(items)))
+		}
+	}
+	log.Info("Replayed dead letters",
+		"total", result.Total,
+		"replayed", result.Replayed,
+		"failed", result.Failed,
+	)
+	return result, nil
+}
+
+// replayDeadLetter 重放单个死信条目，总结失败的条目先重新总结，成功存储后删除死信
+func (s *RssService) replayDeadLetter(ctx context.Context, log *slog.Logger, store dao.ObjectStore, feedName, objectName string) error {
+	reader, err := store.GetObject(ctx, objectName)
+	if err != nil {
+		return fmt.Errorf("read dead letter: %w", err)
+	}
+	var letter DeadLetter
+	if err := json.NewDecoder(reader).Decode(&letter); err != nil {
+		return fmt.Errorf("decode dead letter: %w", err)
+	}
+	if letter.Item == nil {
+		return fmt.Errorf("dead letter has no item")
+	}
+
+	if letter.Stage == DeadLetterSummarize {
+		s.summarizeItems(ctx, log, feedName, []*gofeed.Item{letter.Item})
+		if !hasSummary(letter.Item) {
+			return errSummaryFailed
+		}
+	}
+	if err := s.StoreFeedItems(ctx, feedName, []*gofeed.Item{letter.Item}); err != nil {
+		return err
+	}
+	if err := store.RemoveObject(ctx, objectName); err != nil {
+		return fmt.Errorf("remove dead letter: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// contentHashKey 记录条目存储内容哈希的自定义字段，内容不变时跳过重复写入
+const contentHashKey = "content_hash"
+
+// itemIdentity 返回条目的唯一标识：优先使用 GUID，其次链接，都没有时使用标题和正文的哈希，
+// 避免只按标题区分时不同条目互相覆盖
+func itemIdentity(item *gofeed.Item) string {
+	if item.GUID != "" {
+		return item.GUID
+	}
+	if item.Link != "" {
+		return item.Link
+	}
+	h := sha256.New()
+	for _, part := range []string{item.Title, item.Description, item.Content} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return "hash-" + hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// dedupeItems 按条目 ID 去重，同一批次中重复的条目保留最新的一个（按更新时间，其次发布时间，相同时保留第一个），
+// 位置沿用第一次出现的位置；ID 不同但存储路径相同的条目同样视为重复
+func (s *RssService) dedupeItems(feedName string, items []*gofeed.Item) []*gofeed.Item {
+	byID := make(map[string]int, len(items))
+	byKey := make(map[string]int, len(items))
+	result := make([]*gofeed.Item, 0, len(items))
+	for _, item := range items {
+		id, key := itemIdentity(item), s.itemObjectName(feedName, item)
+		i, ok := byID[id]
+		if !ok {
+			i, ok = byKey[key]
+		}
+		if !ok {
+			byID[id], byKey[key] = len(result), len(result)
+			result = append(result, item)
+			continue
+		}
+		if itemModified(item).After(itemModified(result[i])) {
+			result[i] = item
+			byKey[key] = i
+		}
+	}
+	return result
+}
+
+// itemModified 条目最近的修改时间：优先更新时间，其次发布时间，都没有时为零值
+func itemModified(item *gofeed.Item) time.Time {
+	switch {
+	case item.UpdatedParsed != nil:
+		return *item.UpdatedParsed
+	case item.PublishedParsed != nil:
+		return *item.PublishedParsed
+	default:
+		return time.Time{}
+	}
+}
+
+// setContentHash 计算条目内容（不含哈希字段本身）的哈希并写入自定义字段
+func setContentHash(item *gofeed.Item) (string, error) {
+	delete(item.Custom, contentHashKey)
+	data, err := json.Marshal(item)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	if item.Custom == nil {
+		item.Custom = make(map[string]string)
+	}
+	item.Custom[contentHashKey] = hash
+	return hash, nil
+}
+
+// storedContentHashes 返回已存储条目的存储路径到内容哈希的映射，读取失败时返回 nil
+func (s *RssService) storedContentHashes(ctx context.Context, feedName string) map[string]string {
+	stored, err := s.storedItems(ctx, feedName)
+	if err != nil {
+		return nil
+	}
+	hashes := make(map[string]string, len(stored))
+	for _, item := range stored {
+		if hash := item.Custom[contentHashKey]; hash != "" {
+			hashes[s.itemObjectName(feedName, item)] = hash
+		}
+	}
+	return hashes
+}
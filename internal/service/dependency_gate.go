@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.orx.me/apps/unifeed/internal/logger"
+	"go.orx.me/apps/unifeed/internal/metrics"
+)
+
+// dependencyCheckTimeout 单个共享依赖检查的超时时间
+const dependencyCheckTimeout = 5 * time.Second
+
+// 共享依赖名称
+const (
+	DependencyStorage = "storage"
+	DependencyAI      = "ai"
+)
+
+// dependencyCheck 共享依赖的健康检查
+type dependencyCheck struct {
+	name  string
+	check func(context.Context) error
+}
+
+// dependencyGate 所有 Feed 共享的依赖健康闸门：更新失败时检查 S3 和 AI，
+// 不可用时暂停全部更新周期并按退避间隔重新检查，恢复后继续更新
+type dependencyGate struct {
+	mu     sync.Mutex
+	checks []dependencyCheck
+	// backoff 首次重新检查的间隔，maxBackoff 为退避上限
+	backoff    time.Duration
+	maxBackoff time.Duration
+
+	// down 不可用的依赖名称，为空时闸门打开
+	down      string
+	delay     time.Duration
+	nextProbe time.Time
+}
+
+// newDependencyGate 创建依赖闸门，backoff 为 0 时返回 nil 表示关闭
+func newDependencyGate(rssService *RssService, backoff, maxBackoff time.Duration) *dependencyGate {
+	if backoff <= 0 || rssService == nil {
+		return nil
+	}
+	checks := []dependencyCheck{{name: DependencyStorage, check: rssService.CheckStorage}}
+	if ai := rssService.AIService(); ai != nil && !ai.Disabled() && !ai.DryRun() {
+		checks = append(checks, dependencyCheck{name: DependencyAI, check: ai.Ping})
+	}
+	if maxBackoff < backoff {
+		maxBackoff = backoff
+	}
+	return &dependencyGate{checks: checks, backoff: backoff, maxBackoff: maxBackoff}
+}
+
+// allow 判断本次周期能否执行，依赖不可用时到达重新检查时间才检查，
+// 仍不可用时返回 false 和距下次检查的间隔
+func (g *dependencyGate) allow(ctx context.Context, now time.Time) (time.Duration, bool) {
+	if g == nil {
+		return 0, true
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.down == "" {
+		return 0, true
+	}
+	if now.Before(g.nextProbe) {
+		return g.nextProbe.Sub(now), false
+	}
+	if name, err := g.probe(ctx); err != nil {
+		g.delay *= 2
+		if g.delay > g.maxBackoff {
+			g.delay = g.maxBackoff
+		}
+		g.markDown(name, now)
+		logger.Warn("Shared dependency still down, update cycles remain paused",
+			"dependency", name,
+			"next_check", g.delay,
+			"error", err,
+		)
+		return g.delay, false
+	}
+
+	logger.Info("Shared dependency recovered, resuming update cycles", "dependency", g.down)
+	metrics.DependencyDown.WithLabelValues(g.down).Set(0)
+	g.down = ""
+	return 0, true
+}
+
+// observeFailure 更新周期失败后检查共享依赖，不可用时关闭闸门并返回距下次检查的间隔
+func (g *dependencyGate) observeFailure(ctx context.Context, now time.Time) (time.Duration, bool) {
+	if g == nil {
+		return 0, false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.down != "" {
+		// 其他任务已检测到依赖不可用
+		return g.nextProbe.Sub(now), true
+	}
+	name, err := g.probe(ctx)
+	if err == nil {
+		return 0, false
+	}
+	g.delay = g.backoff
+	g.markDown(name, now)
+	logger.Error("Shared dependency down, pausing update cycles", err,
+		"dependency", name,
+		"next_check", g.delay,
+	)
+	return g.delay, true
+}
+
+// markDown 记录不可用的依赖及下次检查时间，调用方需持有锁
+func (g *dependencyGate) markDown(name string, now time.Time) {
+	if g.down != "" && g.down != name {
+		metrics.DependencyDown.WithLabelValues(g.down).Set(0)
+	}
+	g.down = name
+	g.nextProbe = now.Add(g.delay)
+	metrics.DependencyDown.WithLabelValues(name).Set(1)
+}
+
+// probe 依次检查共享依赖，返回第一个不可用的依赖名称和原因
+func (g *dependencyGate) probe(ctx context.Context) (string, error) {
+	for _, dep := range g.checks {
+		checkCtx, cancel := context.WithTimeout(ctx, dependencyCheckTimeout)
+		err := dep.check(checkCtx)
+		cancel()
+		if err != nil {
+			return dep.name, err
+		}
+	}
+	return "", nil
+}
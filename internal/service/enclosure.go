@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.orx.me/apps/unifeed/internal/logger"
+)
+
+// enclosureConcurrency 同时进行的 HEAD 请求数量上限
+const enclosureConcurrency = 4
+
+// EnclosureResolver 通过 HEAD 请求补全附件的字节长度和 MIME 类型
+type EnclosureResolver struct {
+	client *http.Client
+}
+
+// NewEnclosureResolver 创建附件信息补全器，timeout 为单个请求的超时时间
+func NewEnclosureResolver(timeout time.Duration) *EnclosureResolver {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &EnclosureResolver{client: &http.Client{Timeout: timeout}}
+}
+
+// Resolve 补全单个附件，请求失败时保留原有信息
+func (r *EnclosureResolver) Resolve(ctx context.Context, enclosure *Enclosure) {
+	if r == nil || enclosure == nil || enclosure.URL == "" {
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, enclosure.URL, nil)
+	if err != nil {
+		logger.Warn("Failed to create enclosure request", "url", enclosure.URL, "error", err)
+		return
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		logger.Warn("Failed to resolve enclosure", "url", enclosure.URL, "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		logger.Warn("Unexpected enclosure status", "url", enclosure.URL, "status", resp.StatusCode)
+		return
+	}
+
+	if resp.ContentLength > 0 {
+		enclosure.Length = strconv.FormatInt(resp.ContentLength, 10)
+	}
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil {
+		enclosure.Type = mediaType
+	}
+}
+
+// ResolveItems 并发补全所有条目的附件
+func (r *EnclosureResolver) ResolveItems(ctx context.Context, items []RSSItem) {
+	if r == nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, enclosureConcurrency)
+	for i := range items {
+		if items[i].Enclosure == nil {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(enclosure *Enclosure) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			r.Resolve(ctx, enclosure)
+		}(items[i].Enclosure)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	htmlTagPattern    = regexp.MustCompile(`<[^>]*>`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+// stripHTML 去除 HTML 标签和实体，并合并连续空白
+func stripHTML(content string) string {
+	text := htmlTagPattern.ReplaceAllString(content, " ")
+	text = html.UnescapeString(text)
+	return strings.TrimSpace(whitespacePattern.ReplaceAllString(text, " "))
+}
+
+// ExtractiveSummary 取正文的前 n 句作为摘要，不调用 AI
+func ExtractiveSummary(content string, n int) string {
+	text := stripHTML(content)
+	if n <= 0 {
+		return text
+	}
+
+	count := 0
+	for i, r := range text {
+		if !isSentenceEnd(r) {
+			continue
+		}
+		end := i + utf8.RuneLen(r)
+		// 英文标点后需跟空白或位于末尾，避免截断小数和缩写
+		if r < utf8.RuneSelf && end < len(text) && text[end] != ' ' {
+			continue
+		}
+		count++
+		if count == n {
+			return strings.TrimSpace(text[:end])
+		}
+	}
+	return text
+}
+
+// isSentenceEnd 是否为句末标点
+func isSentenceEnd(r rune) bool {
+	switch r {
+	case '.', '!', '?', '。', '！', '？':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"go.orx.me/apps/unifeed/internal/metrics"
+)
+
+// feedCache 解析结果和存储条目的缓存，超出容量时淘汰最久未访问的条目，过期条目视为未命中
+type feedCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	ll       *list.List
+	entries  map[string]*list.Element
+}
+
+// newFeedCache 创建最多 capacity 个条目、有效期为 ttl 的缓存
+func newFeedCache(capacity int, ttl time.Duration) *feedCache {
+	return &feedCache{
+		capacity: capacity,
+		ttl:      ttl,
+		ll:       list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// get 返回未过期的条目并更新访问时间，过期条目会被删除；命中和未命中（不存在或已过期）计入缓存统计
+func (c *feedCache) get(key string, now time.Time) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		metrics.RecordCacheMiss(metrics.CacheMissAbsent)
+		return cacheEntry{}, false
+	}
+	entry := el.Value.(*cacheEntry)
+	if now.After(entry.expiresAt) {
+		c.removeElement(el)
+		metrics.FeedCacheEvictions.WithLabelValues("expired").Inc()
+		metrics.RecordCacheMiss(metrics.CacheMissExpired)
+		return cacheEntry{}, false
+	}
+	entry.lastAccess = now
+	c.ll.MoveToFront(el)
+	metrics.UpdateCacheStats(true)
+	return *entry, true
+}
+
+// set 写入条目，有效期从 now 开始计算，超出容量时淘汰最久未访问的条目
+func (c *feedCache) set(key string, entry cacheEntry, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry.key = key
+	entry.lastAccess = now
+	entry.expiresAt = now.Add(c.ttl)
+	if el, ok := c.entries[key]; ok {
+		el.Value = &entry
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.ll.PushFront(&entry)
+	for c.capacity > 0 && c.ll.Len() > c.capacity {
+		c.removeElement(c.ll.Back())
+		metrics.FeedCacheEvictions.WithLabelValues("size").Inc()
+	}
+	metrics.FeedCacheSize.Set(float64(c.ll.Len()))
+}
+
+// remove 删除条目
+func (c *feedCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.removeElement(el)
+	}
+}
+
+// removeElement 删除链表元素及其索引，调用方需持有锁
+func (c *feedCache) removeElement(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.entries, el.Value.(*cacheEntry).key)
+	metrics.FeedCacheSize.Set(float64(c.ll.Len()))
+}
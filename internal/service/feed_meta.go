@@ -0,0 +1,75 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/mmcdole/gofeed"
+	"go.orx.me/apps/unifeed/internal/dao"
+)
+
+// FeedMeta 上游 Feed 的频道信息，配置未覆盖标题和描述时用于渲染
+type FeedMeta struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// FeedMetaObject 返回 Feed 频道信息的存储路径
+func FeedMetaObject(feedName string) string {
+	return fmt.Sprintf("meta/%s.json", feedKeyName(feedName))
+}
+
+// storeFeedMeta 保存上游的频道信息，与已保存的相同时不写入
+func (s *RssService) storeFeedMeta(ctx context.Context, feedName string, parsed *gofeed.Feed) error {
+	meta := FeedMeta{Title: parsed.Title, Description: parsed.Description}
+	if last, ok := s.feedMetas.Load(feedName); ok && last.(FeedMeta) == meta {
+		return nil
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("marshal feed meta: %w", err)
+	}
+	if err := s.storeFor(feedName).PutObject(ctx, FeedMetaObject(feedName), data, dao.PutOptions{
+		ContentType: "application/json",
+	}); err != nil {
+		return fmt.Errorf("failed to store feed meta: %w", err)
+	}
+	s.feedMetas.Store(feedName, meta)
+	return nil
+}
+
+// feedMeta 返回 Feed 已保存的频道信息，内存中没有时从存储读取；没有保存过时返回零值
+func (s *RssService) feedMeta(ctx context.Context, feedName string) (FeedMeta, error) {
+	if meta, ok := s.feedMetas.Load(feedName); ok {
+		return meta.(FeedMeta), nil
+	}
+
+	store := s.storeFor(feedName)
+	objectName := FeedMetaObject(feedName)
+	objects, err := store.ListObjects(ctx, objectName)
+	if err != nil {
+		return FeedMeta{}, fmt.Errorf("failed to check feed meta: %w", err)
+	}
+	var meta FeedMeta
+	if slices.ContainsFunc(objects, func(o minio.ObjectInfo) bool { return o.Key == objectName }) {
+		reader, err := store.GetObject(ctx, objectName)
+		if err != nil {
+			return FeedMeta{}, fmt.Errorf("failed to read feed meta: %w", err)
+		}
+		var buf bytes.Buffer
+		if _, err := io.Copy(&buf, reader); err != nil {
+			return FeedMeta{}, fmt.Errorf("failed to read feed meta: %w", err)
+		}
+		if err := json.Unmarshal(buf.Bytes(), &meta); err != nil {
+			return FeedMeta{}, fmt.Errorf("failed to decode feed meta: %w", err)
+		}
+	}
+	// 更新时写入的信息会覆盖这里的缓存
+	s.feedMetas.LoadOrStore(feedName, meta)
+	return meta, nil
+}
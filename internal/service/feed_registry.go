@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/minio/minio-go/v7"
+	"go.orx.me/apps/unifeed/internal/conf"
+	"go.orx.me/apps/unifeed/internal/dao"
+	"go.orx.me/apps/unifeed/internal/logger"
+)
+
+// FeedRegistryObject 运行期间增删的 Feed 在存储中的对象名
+const FeedRegistryObject = "config/feeds.json"
+
+var (
+	// ErrFeedExists 要增加的 Feed 与已有 Feed 重名
+	ErrFeedExists = errors.New("feed already exists")
+	// ErrFeedNotFound 要删除的 Feed 不存在
+	ErrFeedNotFound = errors.New("feed not found")
+	// ErrInvalidFeed 要增加的 Feed 配置无效
+	ErrInvalidFeed = errors.New("invalid feed")
+)
+
+// feedChanges 相对配置文件的 Feed 变更，启动时先删除 Removed 再增加 Added
+type feedChanges struct {
+	Added   []conf.Feed `json:"added"`
+	Removed []string    `json:"removed"`
+}
+
+// FeedRegistry 运行期间增删 Feed：修改当前配置，并将相对配置文件的变更写入存储，重启后通过 Load 恢复
+type FeedRegistry struct {
+	store dao.ObjectStore
+	// configure Feed 加入配置后调用，用于设置独立存储、AI 等 Feed 级别的依赖
+	configure func(conf.Feed)
+
+	mu      sync.Mutex
+	changes feedChanges
+}
+
+// NewFeedRegistry 创建 Feed 注册表，store 为 nil 时变更只保存在内存中，configure 可以为 nil
+func NewFeedRegistry(store dao.ObjectStore, configure func(conf.Feed)) *FeedRegistry {
+	return &FeedRegistry{store: store, configure: configure}
+}
+
+// Load 读取存储中的变更并应用到当前配置，对象不存在时忽略；需在启动任务前调用
+func (r *FeedRegistry) Load(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.store == nil {
+		return nil
+	}
+
+	objects, err := r.store.ListObjects(ctx, FeedRegistryObject)
+	if err != nil {
+		return fmt.Errorf("failed to check feed registry: %w", err)
+	}
+	if !slices.ContainsFunc(objects, func(o minio.ObjectInfo) bool { return o.Key == FeedRegistryObject }) {
+		return nil
+	}
+	reader, err := r.store.GetObject(ctx, FeedRegistryObject)
+	if err != nil {
+		return fmt.Errorf("failed to read feed registry: %w", err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read feed registry: %w", err)
+	}
+	var changes feedChanges
+	if err := json.Unmarshal(data, &changes); err != nil {
+		return fmt.Errorf("failed to decode feed registry: %w", err)
+	}
+
+	err = conf.Update(func(cfg *conf.Config) error {
+		cfg.Feeds = slices.DeleteFunc(cfg.Feeds, func(f conf.Feed) bool {
+			return slices.Contains(changes.Removed, f.Name)
+		})
+		for _, feed := range changes.Added {
+			if err := cfg.ValidateFeed(feed); err != nil || hasFeed(cfg.Feeds, feed.Name) {
+				logger.Warn("Skipping invalid runtime feed", "feed_name", feed.Name, "error", err)
+				continue
+			}
+			cfg.Feeds = append(cfg.Feeds, cfg.ApplyIntervalFloor(feed))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	r.changes = changes
+	logger.Info("Loaded runtime feed changes",
+		"added", len(changes.Added),
+		"removed", len(changes.Removed),
+	)
+	return nil
+}
+
+// Add 校验并增加 Feed，返回实际保存的 Feed（更新间隔已按下限提升）；
+// 重名时返回 ErrFeedExists；变更写入存储失败时不修改配置
+func (r *FeedRegistry) Add(ctx context.Context, feed conf.Feed) (conf.Feed, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err := conf.Update(func(cfg *conf.Config) error {
+		if hasFeed(cfg.Feeds, feed.Name) {
+			return fmt.Errorf("%w: %s", ErrFeedExists, feed.Name)
+		}
+		if err := cfg.ValidateFeed(feed); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidFeed, err)
+		}
+		feed = cfg.ApplyIntervalFloor(feed)
+		changes := feedChanges{
+			Added:   append(slices.Clone(r.changes.Added), feed),
+			Removed: r.changes.Removed,
+		}
+		if err := r.save(ctx, changes); err != nil {
+			return err
+		}
+		r.changes = changes
+		cfg.Feeds = append(cfg.Feeds, feed)
+		return nil
+	})
+	if err != nil {
+		return conf.Feed{}, err
+	}
+	if r.configure != nil {
+		r.configure(feed)
+	}
+	logger.Info("Added feed at runtime", "feed_name", feed.Name)
+	return feed, nil
+}
+
+// Remove 删除 Feed，不存在时返回 ErrFeedNotFound；已存储的条目保留
+func (r *FeedRegistry) Remove(ctx context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err := conf.Update(func(cfg *conf.Config) error {
+		if !hasFeed(cfg.Feeds, name) {
+			return fmt.Errorf("%w: %s", ErrFeedNotFound, name)
+		}
+		changes := feedChanges{
+			Added: slices.DeleteFunc(slices.Clone(r.changes.Added), func(f conf.Feed) bool {
+				return f.Name == name
+			}),
+			Removed: r.changes.Removed,
+		}
+		// 运行期间增加的 Feed 只需撤销增加，来自配置文件的 Feed 记录删除
+		if len(changes.Added) == len(r.changes.Added) && !slices.Contains(changes.Removed, name) {
+			changes.Removed = append(slices.Clone(changes.Removed), name)
+		}
+		if err := r.save(ctx, changes); err != nil {
+			return err
+		}
+		r.changes = changes
+		cfg.Feeds = slices.DeleteFunc(cfg.Feeds, func(f conf.Feed) bool {
+			return f.Name == name
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	logger.Info("Removed feed at runtime", "feed_name", name)
+	return nil
+}
+
+// IsRuntime Feed 是否为运行期间增加的
+func (r *FeedRegistry) IsRuntime(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return hasFeed(r.changes.Added, name)
+}
+
+// save 将变更写入存储
+func (r *FeedRegistry) save(ctx context.Context, changes feedChanges) error {
+	if r.store == nil {
+		return nil
+	}
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("failed to encode feed registry: %w", err)
+	}
+	if err := r.store.PutObject(ctx, FeedRegistryObject, data, dao.PutOptions{ContentType: "application/json"}); err != nil {
+		return fmt.Errorf("failed to save feed registry: %w", err)
+	}
+	return nil
+}
+
+// hasFeed 列表中是否有同名 Feed
+func hasFeed(feeds []conf.Feed, name string) bool {
+	return slices.ContainsFunc(feeds, func(f conf.Feed) bool { return f.Name == name })
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/mmcdole/gofeed"
+	"go.orx.me/apps/unifeed/internal/conf"
+)
+
+// normalizeAuthor 统一作者名称的比较形式，忽略大小写和开头的 @
+func normalizeAuthor(author string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(author), "@"))
+}
+
+// matchAuthor 是否有任一作者出现在列表中
+func matchAuthor(list []string, authors []string) bool {
+	for _, want := range list {
+		want = normalizeAuthor(want)
+		for _, author := range authors {
+			if author != "" && normalizeAuthor(author) == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// authorAllowed 按 Feed 的作者规则判断条目是否保留，authors 为条目的作者名称或账号
+// 配置了 IncludeAuthors 时只保留匹配的作者，匹配 ExcludeAuthors 的作者总是丢弃
+func authorAllowed(feed conf.Feed, authors ...string) bool {
+	if len(feed.IncludeAuthors) > 0 && !matchAuthor(feed.IncludeAuthors, authors) {
+		return false
+	}
+	return !matchAuthor(feed.ExcludeAuthors, authors)
+}
+
+// itemAuthors 返回 RSS 条目的作者名称和邮箱
+func itemAuthors(item *gofeed.Item) []string {
+	var authors []string
+	for _, a := range item.Authors {
+		if a != nil {
+			authors = append(authors, a.Name, a.Email)
+		}
+	}
+	if item.Author != nil {
+		authors = append(authors, item.Author.Name, item.Author.Email)
+	}
+	return authors
+}
+
+// filterItemsByAuthor 按作者规则过滤 RSS 条目
+func filterItemsByAuthor(feed conf.Feed, items []*gofeed.Item) []*gofeed.Item {
+	if len(feed.IncludeAuthors) == 0 && len(feed.ExcludeAuthors) == 0 {
+		return items
+	}
+	result := make([]*gofeed.Item, 0, len(items))
+	for _, item := range items {
+		if authorAllowed(feed, itemAuthors(item)...) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
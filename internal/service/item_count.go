@@ -0,0 +1,72 @@
+package service
+
+import (
+	"log/slog"
+
+	"go.orx.me/apps/unifeed/internal/metrics"
+)
+
+// itemCountMinRuns 计算平均条目数前至少需要的历史更新次数
+const itemCountMinRuns = 3
+
+// defaultItemCountWindow 平均条目数默认统计的最近更新次数
+const defaultItemCountWindow = 10
+
+// itemCountHistory 单个 Feed 最近若干次更新的上游条目数
+type itemCountHistory struct {
+	counts []int
+	next   int
+}
+
+// average 返回历史条目数的平均值
+func (h *itemCountHistory) average() float64 {
+	total := 0
+	for _, n := range h.counts {
+		total += n
+	}
+	return float64(total) / float64(len(h.counts))
+}
+
+// add 记录一次更新的条目数，超过 window 时覆盖最早的记录
+func (h *itemCountHistory) add(count, window int) {
+	if len(h.counts) < window {
+		h.counts = append(h.counts, count)
+		return
+	}
+	h.counts[h.next] = count
+	h.next = (h.next + 1) % window
+}
+
+// checkItemCount 记录本次上游条目数，低于最近平均值的 ItemCountAlarmRatio 时输出告警
+func (s *RssService) checkItemCount(log *slog.Logger, feedName string, count int) {
+	if s.config.ItemCountAlarmRatio <= 0 {
+		return
+	}
+
+	s.itemCountsMu.Lock()
+	if s.itemCounts == nil {
+		s.itemCounts = make(map[string]*itemCountHistory)
+	}
+	history, ok := s.itemCounts[feedName]
+	if !ok {
+		history = &itemCountHistory{}
+		s.itemCounts[feedName] = history
+	}
+	var average float64
+	enough := len(history.counts) >= itemCountMinRuns
+	if enough {
+		average = history.average()
+	}
+	history.add(count, s.config.ItemCountWindow)
+	s.itemCountsMu.Unlock()
+
+	if !enough || float64(count) >= average*s.config.ItemCountAlarmRatio {
+		return
+	}
+	metrics.FeedItemCountAlarms.WithLabelValues(feedName).Inc()
+	log.Warn("Feed returned far fewer items than usual",
+		"item_count", count,
+		"average", average,
+		"alarm_ratio", s.config.ItemCountAlarmRatio,
+	)
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// DefaultItemKeyTemplate 默认的条目存储路径模板
+const DefaultItemKeyTemplate = "feeds/{feed}/items/{id}.json"
+
+// itemKeyLayout 条目存储路径模板，支持 {feed}、{id} 以及按条目发布日期分区的 {yyyy}、{mm}、{dd}
+type itemKeyLayout struct {
+	template string
+	// prefix 模板中第一个非 {feed} 占位符之前的部分，用于列举 Feed 的全部条目
+	prefix string
+	// dated 模板是否包含日期占位符
+	dated bool
+}
+
+// newItemKeyLayout 解析存储路径模板，为空时使用 DefaultItemKeyTemplate
+func newItemKeyLayout(template string) itemKeyLayout {
+	if template == "" {
+		template = DefaultItemKeyTemplate
+	}
+	end := len(template)
+	for _, placeholder := range []string{"{id}", "{yyyy}", "{mm}", "{dd}"} {
+		if i := strings.Index(template, placeholder); i >= 0 && i < end {
+			end = i
+		}
+	}
+	return itemKeyLayout{
+		template: template,
+		prefix:   template[:end],
+		dated:    end < strings.Index(template, "{id}"),
+	}
+}
+
+// itemsPrefix 返回 Feed 条目的存储前缀
+func (l itemKeyLayout) itemsPrefix(feedName string) string {
+	return strings.ReplaceAll(l.prefix, "{feed}", feedKeyName(feedName))
+}
+
+// key 返回 Feed 中 id 和日期对应的存储路径，id 需已清理
+func (l itemKeyLayout) key(feedName, id string, date time.Time) string {
+	date = date.UTC()
+	return strings.NewReplacer(
+		"{feed}", feedKeyName(feedName),
+		"{id}", id,
+		"{yyyy}", date.Format("2006"),
+		"{mm}", date.Format("01"),
+		"{dd}", date.Format("02"),
+	).Replace(l.template)
+}
+
+// itemDate 返回条目用于日期分区的时间：优先发布时间，其次更新时间，
+// 都没有时使用零值，保证同一条目的存储路径不随更新时间变化
+func itemDate(item *gofeed.Item) time.Time {
+	switch {
+	case item.PublishedParsed != nil:
+		return *item.PublishedParsed
+	case item.UpdatedParsed != nil:
+		return *item.UpdatedParsed
+	default:
+		return time.Time{}
+	}
+}
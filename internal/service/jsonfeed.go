@@ -0,0 +1,95 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const jsonFeedVersion = "https://jsonfeed.org/version/1.1"
+
+type JSONFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	FeedURL     string         `json:"feed_url,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Items       []JSONFeedItem `json:"items"`
+}
+
+type JSONFeedItem struct {
+	ID            string               `json:"id"`
+	URL           string               `json:"url,omitempty"`
+	Title         string               `json:"title,omitempty"`
+	ContentHTML   string               `json:"content_html,omitempty"`
+	Image         string               `json:"image,omitempty"`
+	DatePublished string               `json:"date_published,omitempty"`
+	Authors       []JSONFeedAuthor     `json:"authors,omitempty"`
+	Tags          []string             `json:"tags,omitempty"`
+	Attachments   []JSONFeedAttachment `json:"attachments,omitempty"`
+}
+
+type JSONFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+type JSONFeedAttachment struct {
+	URL         string `json:"url"`
+	MimeType    string `json:"mime_type"`
+	SizeInBytes int64  `json:"size_in_bytes,omitempty"`
+}
+
+// NewJSONFeed 将频道转换为 JSON Feed 1.1
+func NewJSONFeed(channel Channel) JSONFeed {
+	feed := JSONFeed{
+		Version:     jsonFeedVersion,
+		Title:       channel.Title,
+		HomePageURL: channel.Link,
+		FeedURL:     channel.SelfLink,
+		Description: channel.Description,
+		Items:       make([]JSONFeedItem, 0, len(channel.Items)),
+	}
+	for _, item := range channel.Items {
+		id := item.GUID
+		if id == "" {
+			id = item.Link
+		}
+		entry := JSONFeedItem{
+			ID:          id,
+			URL:         item.Link,
+			Title:       item.Title,
+			ContentHTML: item.Description,
+			Image:       item.Image,
+			Tags:        item.Categories,
+		}
+		if published, ok := parsePubDate(item.PubDate); ok {
+			entry.DatePublished = published.Format(time.RFC3339)
+		}
+		if item.Author != "" {
+			entry.Authors = []JSONFeedAuthor{{Name: item.Author}}
+		}
+		if item.Enclosure != nil && item.Enclosure.URL != "" {
+			attachment := JSONFeedAttachment{URL: item.Enclosure.URL, MimeType: item.Enclosure.Type}
+			// mime_type 必填，未知时使用通用类型
+			if attachment.MimeType == "" {
+				attachment.MimeType = "application/octet-stream"
+			}
+			if size, err := strconv.ParseInt(item.Enclosure.Length, 10, 64); err == nil {
+				attachment.SizeInBytes = size
+			}
+			entry.Attachments = []JSONFeedAttachment{attachment}
+		}
+		feed.Items = append(feed.Items, entry)
+	}
+	return feed
+}
+
+// RenderJSONFeed 将频道渲染为 JSON Feed 1.1
+func RenderJSONFeed(channel Channel) (string, error) {
+	out, err := json.MarshalIndent(NewJSONFeed(channel), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal json feed: %w", err)
+	}
+	return string(out), nil
+}
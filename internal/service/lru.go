@@ -0,0 +1,85 @@
+package service
+
+import (
+	"container/list"
+	"sync"
+)
+
+// lruCache 并发安全的定长 LRU 缓存
+type lruCache[K comparable, V any] struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[K]*list.Element
+	// onEvict 因容量不足淘汰条目时调用
+	onEvict func(key K, value V)
+}
+
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// newLRUCache 创建容量为 capacity 的 LRU 缓存
+func newLRUCache[K comparable, V any](capacity int, onEvict func(K, V)) *lruCache[K, V] {
+	return &lruCache[K, V]{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[K]*list.Element),
+		onEvict:  onEvict,
+	}
+}
+
+// Get 获取缓存值并将其标记为最近使用
+func (c *lruCache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		return el.Value.(*lruEntry[K, V]).value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Add 写入缓存，超出容量时淘汰最久未使用的条目
+func (c *lruCache[K, V]) Add(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		el.Value.(*lruEntry[K, V]).value = value
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&lruEntry[K, V]{key: key, value: value})
+	for c.capacity > 0 && c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		entry := oldest.Value.(*lruEntry[K, V])
+		c.ll.Remove(oldest)
+		delete(c.items, entry.key)
+		if c.onEvict != nil {
+			c.onEvict(entry.key, entry.value)
+		}
+	}
+}
+
+// Remove 删除缓存条目
+func (c *lruCache[K, V]) Remove(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.ll.Remove(el)
+		delete(c.items, key)
+	}
+}
+
+// Len 返回当前条目数
+func (c *lruCache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
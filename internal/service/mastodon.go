@@ -9,9 +9,33 @@ import (
 
 	"github.com/mattn/go-mastodon"
 	"go.orx.me/apps/unifeed/internal/conf"
+	"go.orx.me/apps/unifeed/internal/logger"
 )
 
 type MastodonService struct {
+	enclosures *EnclosureResolver
+	// newClient 创建拉取时间线的客户端
+	newClient MastodonClientFactory
+	// timeout 拉取一次时间线的超时时间，为 0 时只受调用方 context 控制
+	timeout time.Duration
+}
+
+// MastodonClient 拉取时间线使用的 Mastodon 客户端方法，*mastodon.Client 实现了该接口
+type MastodonClient interface {
+	GetTimelineHome(ctx context.Context, pg *mastodon.Pagination) ([]*mastodon.Status, error)
+	GetTimelinePublic(ctx context.Context, isLocal bool, pg *mastodon.Pagination) ([]*mastodon.Status, error)
+	GetTimelineHashtag(ctx context.Context, tag string, isLocal bool, pg *mastodon.Pagination) ([]*mastodon.Status, error)
+}
+
+// MastodonClientFactory 根据 Feed 的 Mastodon 配置创建客户端
+type MastodonClientFactory func(cfg conf.Mastodon) MastodonClient
+
+// newMastodonClient 默认的客户端工厂，使用 go-mastodon 访问实例
+func newMastodonClient(cfg conf.Mastodon) MastodonClient {
+	return mastodon.NewClient(&mastodon.Config{
+		Server:      cfg.Host,
+		AccessToken: cfg.Token,
+	})
 }
 
 type MastodonStatus struct {
@@ -22,9 +46,11 @@ type MastodonStatus struct {
 }
 
 type RSS struct {
-	XMLName xml.Name `xml:"rss"`
-	Version string   `xml:"version,attr"`
-	Channel Channel  `xml:"channel"`
+	XMLName     xml.Name `xml:"rss"`
+	Version     string   `xml:"version,attr"`
+	XMLNSItunes string   `xml:"xmlns:itunes,attr,omitempty"`
+	XMLNSAtom   string   `xml:"xmlns:atom,attr,omitempty"`
+	Channel     Channel  `xml:"channel"`
 }
 
 type RSSItem struct {
@@ -38,37 +64,163 @@ type RSSItem struct {
 	Categories  []string   `xml:"category,omitempty"`
 	Enclosure   *Enclosure `xml:"enclosure,omitempty"`
 	Image       string     `xml:"image,omitempty"`
+	// 播客 iTunes 扩展
+	ITunesDuration string       `xml:"itunes:duration,omitempty"`
+	ITunesEpisode  string       `xml:"itunes:episode,omitempty"`
+	ITunesImage    *ITunesImage `xml:"itunes:image,omitempty"`
+
+	// parts 正文的组成部分，供内容模板使用
+	parts ContentData
 }
 
 type Enclosure struct {
-	URL    string `xml:"url,attr"`
-	Type   string `xml:"type,attr,omitempty"`
-	Length string `xml:"length,attr,omitempty"`
+	URL    string `xml:"url,attr" json:"url"`
+	Type   string `xml:"type,attr,omitempty" json:"type,omitempty"`
+	Length string `xml:"length,attr,omitempty" json:"length,omitempty"`
+}
+
+type ITunesImage struct {
+	Href string `xml:"href,attr"`
 }
 
 type Channel struct {
-	Title string    `xml:"title"`
-	Link  string    `xml:"link"`
-	Items []RSSItem `xml:"item"`
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	// SelfLink 本服务上该订阅的地址，渲染时输出为 self 链接
+	SelfLink string `xml:"-"`
+	// AtomLink RSS 中的 atom:link self 链接，由 RenderRSS 根据 SelfLink 生成
+	AtomLink *AtomLink `xml:"atom:link,omitempty"`
+	Items    []RSSItem `xml:"item"`
+}
+
+// withOptionalTimeout 为 ctx 设置超时，timeout 为 0 时只返回可取消的 ctx
+func withOptionalTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
 }
 
 func NewMastodonService() *MastodonService {
-	return &MastodonService{}
+	return &MastodonService{newClient: newMastodonClient}
+}
+
+// SetTimeout 设置拉取一次时间线的超时时间，为 0 时不限制
+func (s *MastodonService) SetTimeout(d time.Duration) {
+	s.timeout = d
+}
+
+// SetClientFactory 设置创建 Mastodon 客户端的工厂，为 nil 时恢复默认
+func (s *MastodonService) SetClientFactory(f MastodonClientFactory) {
+	if f == nil {
+		f = newMastodonClient
+	}
+	s.newClient = f
+}
+
+// timelinePage 拉取一页时间线
+type timelinePage func(ctx context.Context, pg *mastodon.Pagination) ([]*mastodon.Status, error)
+
+// timelineFetcher 根据 Timeline 配置选择拉取的时间线
+func timelineFetcher(client MastodonClient, cfg conf.Mastodon) (string, timelinePage, error) {
+	kind, tag, err := cfg.ParseTimeline()
+	if err != nil {
+		return "", nil, err
+	}
+	switch kind {
+	case conf.MastodonTimelinePublic, conf.MastodonTimelineLocal:
+		isLocal := kind == conf.MastodonTimelineLocal
+		return kind, func(ctx context.Context, pg *mastodon.Pagination) ([]*mastodon.Status, error) {
+			return client.GetTimelinePublic(ctx, isLocal, pg)
+		}, nil
+	case conf.MastodonTimelineTag:
+		return kind, func(ctx context.Context, pg *mastodon.Pagination) ([]*mastodon.Status, error) {
+			return client.GetTimelineHashtag(ctx, tag, false, pg)
+		}, nil
+	default:
+		return kind, client.GetTimelineHome, nil
+	}
+}
+
+// SetEnclosureResolver 设置附件信息补全器，为 nil 时不补全
+func (s *MastodonService) SetEnclosureResolver(r *EnclosureResolver) {
+	s.enclosures = r
+}
+
+// fetchMastodonTimeline 按配置的页数拉取 Timeline 指定的时间线，
+// 非严格模式下后续页失败时返回已获取的内容
+func fetchMastodonTimeline(ctx context.Context, client MastodonClient, cfg conf.Mastodon) ([]*mastodon.Status, error) {
+	kind, getPage, err := timelineFetcher(client, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// 设置 MaxItems 且未设置 Pages 时不限页数，拉取到足够条目或时间线结束为止
+	pages := cfg.Pages
+	if pages <= 0 && cfg.MaxItems <= 0 {
+		pages = 1
+	}
+
+	var statuses []*mastodon.Status
+	seen := make(map[mastodon.ID]bool)
+	pg := &mastodon.Pagination{}
+	for page := 0; pages <= 0 || page < pages; page++ {
+		next := &mastodon.Pagination{MaxID: pg.MaxID}
+		batch, err := getPage(ctx, next)
+		if err != nil {
+			if page == 0 || cfg.StrictPagination {
+				return nil, fmt.Errorf("get %s timeline page %d: %w", kind, page+1, err)
+			}
+			logger.Warn("Failed to fetch timeline page, using partial results",
+				"host", cfg.Host,
+				"timeline", kind,
+				"page", page+1,
+				"statuses", len(statuses),
+				"error", err,
+			)
+			break
+		}
+		// 相邻页可能重叠，按状态 ID 去重
+		for _, status := range batch {
+			if seen[status.ID] {
+				continue
+			}
+			seen[status.ID] = true
+			statuses = append(statuses, status)
+		}
+		if cfg.MaxItems > 0 && len(statuses) >= cfg.MaxItems {
+			statuses = statuses[:cfg.MaxItems]
+			break
+		}
+		// 响应未提供新的 next 链接时已到末页
+		if next.MaxID == "" || next.MaxID == pg.MaxID || len(batch) == 0 {
+			break
+		}
+		pg = next
+	}
+	return statuses, nil
 }
 
 // 拉取 Mastodon timeline 并生成 RSS XML
-func (s *MastodonService) TimelineToRSS(feed conf.Feed) (string, error) {
+func (s *MastodonService) TimelineToRSS(ctx context.Context, feed conf.Feed) (string, error) {
+	channel, err := s.Timeline(ctx, feed)
+	if err != nil {
+		return "", err
+	}
+	return RenderRSS(channel)
+}
+
+// Timeline 拉取 Mastodon timeline 并构建可渲染的频道，超过 ctx 的截止时间或 SetTimeout 的超时时间时中止
+func (s *MastodonService) Timeline(ctx context.Context, feed conf.Feed) (Channel, error) {
 	if feed.Mastodon.Host == "" || feed.Mastodon.Token == "" {
-		return "", fmt.Errorf("mastodon config required")
+		return Channel{}, fmt.Errorf("mastodon config required")
 	}
-	client := mastodon.NewClient(&mastodon.Config{
-		Server:      feed.Mastodon.Host,
-		AccessToken: feed.Mastodon.Token,
-	})
-	ctx := context.Background()
-	statuses, err := client.GetTimelineHome(ctx, nil)
+	ctx, cancel := withOptionalTimeout(ctx, s.timeout)
+	defer cancel()
+	statuses, err := fetchMastodonTimeline(ctx, s.newClient(feed.Mastodon), feed.Mastodon)
 	if err != nil {
-		return "", err
+		return Channel{}, err
 	}
 	items := make([]RSSItem, 0, len(statuses))
 	for _, st := range statuses {
@@ -78,6 +230,13 @@ func (s *MastodonService) TimelineToRSS(feed conf.Feed) (string, error) {
 			status = st.Reblog
 			isReblog = true
 		}
+		if status.InReplyToID != nil && !feed.Mastodon.RepliesIncluded() {
+			continue
+		}
+		// 转发同时匹配转发者和原作者
+		if !authorAllowed(feed, st.Account.Acct, status.Account.Acct) {
+			continue
+		}
 
 		// 构建 media HTML
 		mediaHTML := ""
@@ -141,24 +300,17 @@ func (s *MastodonService) TimelineToRSS(feed conf.Feed) (string, error) {
 			Categories:  categories,
 			Enclosure:   enclosure,
 			Image:       image,
+			parts: ContentData{
+				Title:       title,
+				Link:        link,
+				Author:      author,
+				Content:     status.Content,
+				Media:       mediaHTML,
+				Description: description,
+			},
 		})
 	}
 
-	var title = feed.Title
-	if title == "" {
-		title = feed.Name
-	}
-	rss := RSS{
-		Version: "2.0",
-		Channel: Channel{
-			Title: title,
-			Link:  feed.Mastodon.Host,
-			Items: items,
-		},
-	}
-	out, err := xml.MarshalIndent(rss, "", "  ")
-	if err != nil {
-		return "", err
-	}
-	return string(out), nil
+	s.enclosures.ResolveItems(ctx, items)
+	return newChannel(feed, FeedMeta{}, feed.Mastodon.Host, items), nil
 }
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"go.orx.me/apps/unifeed/internal/conf"
+	"go.orx.me/apps/unifeed/internal/dao"
+	"go.orx.me/apps/unifeed/internal/logger"
+	"go.orx.me/apps/unifeed/internal/metrics"
+)
+
+// archivedKey 标记上游已删除条目的自定义字段，值为归档时间（RFC 3339）
+const archivedKey = "archived_at"
+
+// addItemNames 将条目的存储路径加入集合
+func (s *RssService) addItemNames(names map[string]bool, feedName string, items []*gofeed.Item) {
+	for _, item := range items {
+		names[s.itemObjectName(feedName, item)] = true
+	}
+}
+
+// reconcileMissing 处理已存储但上游不再包含的条目：Feed 设置 RemoveMissing 时删除，
+// 否则标记 archived_at 后重新写入；条目重新出现在上游时按正常更新覆盖并清除标记
+func (s *RssService) reconcileMissing(ctx context.Context, feed conf.Feed, upstream map[string]bool) error {
+	stored, err := s.storedItems(ctx, feed.Name)
+	if err != nil {
+		return err
+	}
+
+	store := s.storeFor(feed.Name)
+	action := "archived"
+	if feed.RemoveMissing {
+		action = "removed"
+	}
+	now := s.clock.Now().UTC().Format(time.RFC3339)
+	changed := 0
+	var firstErr error
+	for _, item := range stored {
+		objectName := s.itemObjectName(feed.Name, item)
+		if upstream[objectName] {
+			continue
+		}
+		operation := "remove"
+		if feed.RemoveMissing {
+			err = store.RemoveObject(ctx, objectName)
+		} else {
+			if item.Custom[archivedKey] != "" {
+				continue
+			}
+			operation = "store"
+			err = s.archiveItem(ctx, store, feed.Name, objectName, item, now)
+		}
+		if err != nil {
+			metrics.S3OperationTotal.WithLabelValues(operation, "error").Inc()
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to handle missing item %s: %w", objectName, err)
+			}
+			continue
+		}
+		metrics.S3OperationTotal.WithLabelValues(operation, "success").Inc()
+		changed++
+	}
+
+	if changed > 0 {
+		metrics.FeedItemsMissing.WithLabelValues(feed.Name, action).Add(float64(changed))
+		s.cache.remove(fmt.Sprintf("items:%s", feed.Name))
+		logger.Info("Handled items missing upstream",
+			"feed_name", feed.Name,
+			"action", action,
+			"count", changed,
+		)
+	}
+	return firstErr
+}
+
+// archiveItem 为条目加上归档标记并重新计算内容哈希后写回存储，
+// 使条目重新出现在上游时内容哈希不同而被覆盖
+func (s *RssService) archiveItem(ctx context.Context, store dao.ObjectStore, feedName, objectName string, item *gofeed.Item, archivedAt string) error {
+	if item.Custom == nil {
+		item.Custom = make(map[string]string)
+	}
+	item.Custom[archivedKey] = archivedAt
+	if _, err := setContentHash(item); err != nil {
+		return err
+	}
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	return store.PutObject(ctx, objectName, data, itemPutOptions(feedName))
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	neturl "net/url"
+	"syscall"
+	"time"
+
+	"butterfly.orx.me/core/log"
+	"github.com/mmcdole/gofeed"
+	"go.orx.me/apps/unifeed/internal/conf"
+)
+
+// previewMaxRedirects 预览请求最多跟随的重定向次数
+const previewMaxRedirects = 5
+
+var (
+	// ErrPreviewURL 预览地址不是有效的 http/https 地址
+	ErrPreviewURL = errors.New("url must be an absolute http or https URL")
+	// ErrPreviewBlocked 预览地址解析到内网、回环等受限地址
+	ErrPreviewBlocked = errors.New("url resolves to a blocked address")
+	// ErrPreviewTooLarge 上游响应超过预览允许的大小
+	ErrPreviewTooLarge = errors.New("feed exceeds preview size limit")
+)
+
+// cgnatPrefix 运营商级 NAT 地址段，同样视为内网
+var cgnatPrefix = netip.MustParsePrefix("100.64.0.0/10")
+
+// blockedAddr 是否为预览禁止连接的地址
+func blockedAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() || addr.IsUnspecified() || cgnatPrefix.Contains(addr)
+}
+
+// NewPreviewClient 创建预览使用的 HTTP 客户端，allowPrivate 为 false 时在连接前检查解析后的地址，
+// 拒绝内网、回环和链路本地地址（包括重定向后的地址），且不使用环境变量中的代理
+func NewPreviewClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("%w: %s", ErrPreviewBlocked, address)
+			}
+			if blockedAddr(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrPreviewBlocked, addrPort.Addr())
+			}
+			return nil
+		}
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= previewMaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", previewMaxRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return ErrPreviewURL
+			}
+			return nil
+		},
+	}
+}
+
+// Preview 拉取并解析任意地址的 Feed，为前 summaries 个条目生成摘要后构建频道；
+// 不读写存储、不使用解析缓存，也不影响调度任务。响应超过 maxBytes 时返回 ErrPreviewTooLarge
+func (s *RssService) Preview(ctx context.Context, client *http.Client, rawURL string, summaries int, maxBytes int64) (Channel, error) {
+	u, err := neturl.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Channel{}, ErrPreviewURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return Channel{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrPreviewBlocked) || errors.Is(err, ErrPreviewURL) {
+			return Channel{}, err
+		}
+		return Channel{}, fmt.Errorf("failed to fetch feed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Channel{}, fmt.Errorf("failed to fetch feed: status code %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return Channel{}, fmt.Errorf("failed to read feed: %w", err)
+	}
+	if int64(len(body)) > maxBytes {
+		return Channel{}, ErrPreviewTooLarge
+	}
+	parsed, err := gofeed.NewParser().Parse(bytes.NewReader(body))
+	if err != nil {
+		return Channel{}, fmt.Errorf("failed to parse feed: %w", err)
+	}
+
+	items := parsed.Items
+	if n := min(summaries, len(items)); n > 0 && !s.aiService.Disabled() {
+		contents := make([]string, n)
+		for i, item := range items[:n] {
+			contents[i] = item.Content
+			if contents[i] == "" {
+				contents[i] = item.Description
+			}
+		}
+		for i, summary := range s.generateSummaries(ctx, log.FromContext(ctx), s.aiService, contents) {
+			if summary == "" {
+				continue
+			}
+			if items[i].Custom == nil {
+				items[i].Custom = make(map[string]string)
+			}
+			items[i].Custom["summary"] = summary
+		}
+	}
+
+	rssItems := make([]RSSItem, 0, len(items))
+	for _, item := range items {
+		rssItems = append(rssItems, NewFeedItem(item).RSSItem())
+	}
+	link := parsed.Link
+	if link == "" {
+		link = rawURL
+	}
+	return newChannel(conf.Feed{Name: rawURL}, FeedMeta{Title: parsed.Title, Description: parsed.Description}, link, rssItems), nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 摘要语言
+const (
+	LanguageChinese = "zh"
+	LanguageEnglish = "en"
+)
+
+// promptSet 一种语言的默认提示词
+type promptSet struct {
+	// single 单篇总结的提示词，%s 为正文
+	single string
+	// batch 批量总结的说明
+	batch string
+	// heading 批量总结中每篇文章的标题，%d 为编号
+	heading string
+}
+
+var defaultPrompts = map[string]promptSet{
+	LanguageChinese: {
+		single: "请用中文总结以下文章的主要内容，突出关键点，并保持简洁：\n\n%s",
+		batch: "请用中文分别总结以下每篇文章的主要内容，突出关键点，并保持简洁。" +
+			"请只返回一个 JSON 字符串数组，数组长度与文章数量相同，顺序与文章编号一致：\n\n",
+		heading: "### 文章 %d",
+	},
+	LanguageEnglish: {
+		single: "Summarize the main content of the following article in English, highlighting the key points and keeping it concise:\n\n%s",
+		batch: "Summarize the main content of each of the following articles in English, highlighting the key points and keeping it concise. " +
+			"Return only a JSON array of strings with one summary per article, in the same order as the article numbers:\n\n",
+		heading: "### Article %d",
+	},
+}
+
+// prompts 返回配置语言的默认提示词，未配置时使用中文
+func (s *AiService) prompts() promptSet {
+	if p, ok := defaultPrompts[s.config.Language]; ok {
+		return p
+	}
+	return defaultPrompts[LanguageChinese]
+}
+
+// BuildPrompt 构建单篇总结的提示词，配置了 PromptTemplate 时将其中的 %s 替换为正文
+func (s *AiService) BuildPrompt(content string) string {
+	if s.config.PromptTemplate != "" {
+		return strings.Replace(s.config.PromptTemplate, "%s", content, 1)
+	}
+	return fmt.Sprintf(s.prompts().single, content)
+}
+
+// buildBatchPrompt 构建批量总结的提示词，批量请求需要固定的返回格式，始终使用默认提示词
+func (s *AiService) buildBatchPrompt(contents []string) string {
+	p := s.prompts()
+	var b strings.Builder
+	b.WriteString(p.batch)
+	for i, content := range contents {
+		fmt.Fprintf(&b, p.heading+"\n%s\n\n", i+1, content)
+	}
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+
+	"go.orx.me/apps/unifeed/internal/conf"
+)
+
+// RawResponse 上游的原始响应
+type RawResponse struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	// Truncated 响应体超过上限被截断
+	Truncated bool
+}
+
+// FetchRaw 绕过缓存直接请求 Feed 的上游，最多读取 maxBytes 字节；Bluesky Feed 的访问令牌由 bluesky 提供
+func FetchRaw(ctx context.Context, client *http.Client, bluesky *BlueskyService, feed conf.Feed, maxBytes int64) (*RawResponse, error) {
+	req, err := rawRequest(ctx, bluesky, feed)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch upstream: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upstream: %w", err)
+	}
+	raw := &RawResponse{
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        body,
+	}
+	if int64(len(body)) > maxBytes {
+		raw.Body = body[:maxBytes]
+		raw.Truncated = true
+	}
+	return raw, nil
+}
+
+// rawRequest 根据 Feed 类型构建上游请求
+func rawRequest(ctx context.Context, bluesky *BlueskyService, feed conf.Feed) (*http.Request, error) {
+	var url, token string
+	switch {
+	case feed.Mastodon.Host != "":
+		path, err := mastodonTimelinePath(feed.Mastodon)
+		if err != nil {
+			return nil, err
+		}
+		url = strings.TrimSuffix(feed.Mastodon.Host, "/") + path
+		token = feed.Mastodon.Token
+	case feed.Bluesky.Host != "":
+		url = strings.TrimSuffix(feed.Bluesky.Host, "/") + "/xrpc/app.bsky.feed.getTimeline?limit=50"
+		var err error
+		if token, err = bluesky.AccessToken(ctx, feed); err != nil {
+			return nil, err
+		}
+	case feed.RssFeed != "":
+		url = feed.RssFeed
+	default:
+		return nil, fmt.Errorf("feed %s has no upstream", feed.Name)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req, nil
+}
+
+// mastodonTimelinePath 返回 Timeline 配置对应的 Mastodon API 路径
+func mastodonTimelinePath(cfg conf.Mastodon) (string, error) {
+	kind, tag, err := cfg.ParseTimeline()
+	if err != nil {
+		return "", err
+	}
+	switch kind {
+	case conf.MastodonTimelinePublic:
+		return "/api/v1/timelines/public", nil
+	case conf.MastodonTimelineLocal:
+		return "/api/v1/timelines/public?local=true", nil
+	case conf.MastodonTimelineTag:
+		return "/api/v1/timelines/tag/" + neturl.PathEscape(tag), nil
+	default:
+		return "/api/v1/timelines/home", nil
+	}
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"cmp"
+	"encoding/xml"
+	"fmt"
+
+	"go.orx.me/apps/unifeed/internal/conf"
+)
+
+const itunesNamespace = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+
+// RenderRSS 将频道渲染为 RSS 2.0 XML
+func RenderRSS(channel Channel) (string, error) {
+	rss := RSS{
+		Version: "2.0",
+		Channel: channel,
+	}
+	if channel.SelfLink != "" {
+		rss.XMLNSAtom = atomNamespace
+		rss.Channel.AtomLink = &AtomLink{Href: channel.SelfLink, Rel: "self", Type: "application/rss+xml"}
+	}
+	for _, item := range channel.Items {
+		if item.ITunesDuration != "" || item.ITunesEpisode != "" || item.ITunesImage != nil {
+			rss.XMLNSItunes = itunesNamespace
+			break
+		}
+	}
+
+	out, err := xml.MarshalIndent(rss, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal rss: %w", err)
+	}
+	return string(out), nil
+}
+
+// newChannel 根据 Feed 配置构建频道，标题和描述优先使用配置中的覆盖值，其次使用上游的频道信息
+func newChannel(feed conf.Feed, meta FeedMeta, link string, items []RSSItem) Channel {
+	title := cmp.Or(feed.Title, meta.Title, feed.Name)
+	description := cmp.Or(feed.Description, meta.Description, title)
+	if feed.ContentTemplate != "" {
+		applyContentTemplate(feed, items)
+	}
+	if feed.Link != "" {
+		link = feed.Link
+	}
+	return Channel{
+		Title:       title,
+		Link:        link,
+		Description: description,
+		Items:       items,
+	}
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// RenderCache 按 Feed 名称缓存渲染后的输出
+type RenderCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]renderEntry
+}
+
+type renderEntry struct {
+	output    string
+	expiresAt time.Time
+}
+
+// NewRenderCache 创建一个新的渲染缓存
+func NewRenderCache(ttl time.Duration) *RenderCache {
+	return &RenderCache{
+		ttl:     ttl,
+		entries: make(map[string]renderEntry),
+	}
+}
+
+// Get 获取未过期的缓存输出
+func (c *RenderCache) Get(name string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[name]
+	if !ok {
+		return "", false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, name)
+		return "", false
+	}
+	return entry.output, true
+}
+
+// Set 写入缓存
+func (c *RenderCache) Set(name, output string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[name] = renderEntry{
+		output:    output,
+		expiresAt: time.Now().Add(c.ttl),
+	}
+}
+
+// Invalidate 删除缓存
+func (c *RenderCache) Invalidate(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, name)
+}
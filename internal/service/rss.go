@@ -1,22 +1,33 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"path"
+	"sort"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"butterfly.orx.me/core/log"
 	"github.com/mmcdole/gofeed"
+	"go.orx.me/apps/unifeed/internal/clock"
 	"go.orx.me/apps/unifeed/internal/conf"
 	"go.orx.me/apps/unifeed/internal/dao"
 	"go.orx.me/apps/unifeed/internal/logger"
 	"go.orx.me/apps/unifeed/internal/metrics"
+	"go.orx.me/apps/unifeed/internal/retry"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
 )
 
 type RssConfig struct {
@@ -24,20 +35,100 @@ type RssConfig struct {
 	RetryDelay    time.Duration
 	CacheDuration time.Duration
 	MaxCacheSize  int
+	// SkipUnchanged 上游内容与上次更新相同时跳过摘要和存储
+	SkipUnchanged bool
+	// FastStart 首次更新只存储不总结，之后每次更新补全 BackfillBatch 个条目的摘要
+	FastStart bool
+	// BackfillBatch 每次补全摘要的条目数，默认 5
+	BackfillBatch int
+	// CacheSummaries 在存储中按内容哈希缓存摘要，内容未变化的条目不再调用 AI
+	CacheSummaries bool
+	// HTTPTimeout 拉取上游 Feed 的超时时间，默认 30s
+	HTTPTimeout time.Duration
+	// Transport 拉取上游 Feed 使用的 Transport，为 nil 时使用 http.DefaultTransport
+	Transport http.RoundTripper
+	// DeadLetterAfter 条目连续存储或总结失败达到该次数后写入死信前缀，0 表示关闭
+	DeadLetterAfter int
+	// ItemCountAlarmRatio 上游条目数低于最近平均值的该比例时告警，0 表示关闭
+	ItemCountAlarmRatio float64
+	// ItemCountWindow 平均条目数统计的最近更新次数，默认 10
+	ItemCountWindow int
+	// PartialResults 为 true 时 GetStoredFeedItems 部分条目读取失败也返回成功读取的条目（同时返回错误，且不缓存），
+	// 输出 Feed 时忽略失败的条目
+	PartialResults bool
+	// MaxConcurrency 读取或写入条目时同时进行的存储请求数上限，默认 16
+	MaxConcurrency int
+	// StoreConcurrency StoreFeedItems 同时写入存储的任务数上限，默认与 MaxConcurrency 相同
+	StoreConcurrency int
+	// StoreBatchSize 每个写入任务依次写入的条目数，条目多且小时可减少任务数，默认 1
+	StoreBatchSize int
+	// ItemKeyTemplate 条目存储路径模板，默认 DefaultItemKeyTemplate
+	ItemKeyTemplate string
+	// RetryJitter 重试等待时间的随机浮动比例（0~1），0 表示固定间隔
+	RetryJitter float64
 }
 
 type cacheEntry struct {
+	key string
+	// feed ParseFeed 的解析结果
+	feed *gofeed.Feed
+	// items GetStoredFeedItems 读取的存储条目
 	items      []map[string]interface{}
 	expiresAt  time.Time
 	lastAccess time.Time
 }
 
+// feedValidator 条件请求所需的响应头及对应的解析结果
+type feedValidator struct {
+	etag         string
+	lastModified string
+	feed         *gofeed.Feed
+}
+
 type RssService struct {
-	parser    *gofeed.Parser
 	aiService *AiService
-	s3Client  *dao.S3Client
-	config    RssConfig
-	cache     sync.Map
+	s3Client  dao.ObjectStore
+	// feedStores 使用独立存储配置的 Feed，未配置时使用 s3Client
+	feedStores map[string]dao.ObjectStore
+	// feedAI 使用独立 AI 接口的 Feed，未配置时使用 aiService
+	feedAI map[string]*AiService
+	// feedMu 保护 feedStores 和 feedAI，运行期间增加的 Feed 会在更新进行中设置
+	feedMu sync.RWMutex
+	config RssConfig
+	// itemKeys 条目存储路径布局
+	itemKeys itemKeyLayout
+	// client 拉取上游 Feed 使用的 HTTP 客户端
+	client *http.Client
+	// cache 解析结果和存储条目的缓存，按 MaxCacheSize 淘汰、CacheDuration 过期
+	cache *feedCache
+	// fetches 合并同一 URL 的并发拉取
+	fetches singleflight.Group
+	// bodyHashes 每个 URL 最近一次拉取内容的哈希
+	bodyHashes sync.Map
+	// redirects 永久重定向后的新地址，键为配置的 URL
+	redirects sync.Map
+	// validators 每个 URL 最近一次响应的 ETag/Last-Modified 及解析结果，用于条件请求
+	validators sync.Map
+	// processedHashes 每个 Feed 最近一次成功更新时的内容哈希
+	processedHashes sync.Map
+	// started 已完成首次更新的 Feed，仅 FastStart 时使用
+	started sync.Map
+	// feedMetas 每个 Feed 已保存的上游频道信息
+	feedMetas sync.Map
+	// itemFailures 条目连续失败次数，键为阶段和条目存储路径
+	itemFailures   map[string]int
+	itemFailuresMu sync.Mutex
+	// itemCounts 每个 Feed 最近若干次更新的上游条目数，仅 ItemCountAlarmRatio 大于 0 时使用
+	itemCounts   map[string]*itemCountHistory
+	itemCountsMu sync.Mutex
+	// aiBudgets 设置了每日 AI 用量上限的 Feed，aiSpends 为这些 Feed 当天的用量
+	aiBudgets map[string]aiBudget
+	aiSpends  map[string]*aiSpend
+	aiSpendMu sync.Mutex
+	// onHub 更新时发现 WebSub hub 的回调，为 nil 时不处理
+	onHub func(feed conf.Feed, hub, topic string)
+	// clock 重试等待使用的时间源
+	clock clock.Clock
 }
 
 type FeedItem struct {
@@ -47,15 +138,24 @@ type FeedItem struct {
 	Published   time.Time `json:"published"`
 	Content     string    `json:"content,omitempty"`
 	Summary     string    `json:"summary,omitempty"`
+	// SummaryPending 摘要生成失败，等待后续更新重试
+	SummaryPending bool       `json:"summary_pending,omitempty"`
+	GUID           string     `json:"guid,omitempty"`
+	Enclosure      *Enclosure `json:"enclosure,omitempty"`
+	Image          string     `json:"image,omitempty"`
+	// 播客扩展字段
+	Duration string `json:"duration,omitempty"`
+	Episode  string `json:"episode,omitempty"`
 }
 
 // NewRssService 创建一个新的 RSS 服务实例
-func NewRssService(aiService *AiService, s3Client *dao.S3Client, config RssConfig) *RssService {
+func NewRssService(aiService *AiService, s3Client dao.ObjectStore, config RssConfig) *RssService {
 	logger.Info("Initializing RSS service",
 		"max_retries", config.MaxRetries,
 		"retry_delay", config.RetryDelay,
 		"cache_duration", config.CacheDuration,
 		"max_cache_size", config.MaxCacheSize,
+		"http_timeout", config.HTTPTimeout,
 	)
 
 	// 设置默认值
@@ -65,47 +165,129 @@ func NewRssService(aiService *AiService, s3Client *dao.S3Client, config RssConfi
 	if config.MaxCacheSize == 0 {
 		config.MaxCacheSize = 100
 	}
+	if config.BackfillBatch <= 0 {
+		config.BackfillBatch = 5
+	}
+	if config.HTTPTimeout <= 0 {
+		config.HTTPTimeout = 30 * time.Second
+	}
+	if config.ItemCountWindow <= 0 {
+		config.ItemCountWindow = defaultItemCountWindow
+	}
+	if config.MaxConcurrency <= 0 {
+		config.MaxConcurrency = 16
+	}
+	if config.StoreConcurrency <= 0 {
+		config.StoreConcurrency = config.MaxConcurrency
+	}
+	if config.StoreBatchSize <= 0 {
+		config.StoreBatchSize = 1
+	}
 
 	return &RssService{
-		parser:    gofeed.NewParser(),
 		aiService: aiService,
 		s3Client:  s3Client,
 		config:    config,
+		itemKeys:  newItemKeyLayout(config.ItemKeyTemplate),
+		client:    &http.Client{Timeout: config.HTTPTimeout, Transport: config.Transport},
+		cache:     newFeedCache(config.MaxCacheSize, config.CacheDuration),
+		clock:     clock.Real(),
 	}
 }
 
-// retryWithBackoff 执行带退避的重试逻辑
-func (s *RssService) retryWithBackoff(ctx context.Context, operation string, fn func() error) error {
-	var lastErr error
-	for i := 0; i <= s.config.MaxRetries; i++ {
-		if i > 0 {
-			logger.Info("Retrying operation",
-				"operation", operation,
-				"attempt", i,
-				"max_retries", s.config.MaxRetries,
-				"delay", s.config.RetryDelay,
-			)
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(s.config.RetryDelay):
-			}
-		}
+// SetHubHandler 设置发现 WebSub hub 时的回调，需在开始更新前调用
+func (s *RssService) SetHubHandler(f func(feed conf.Feed, hub, topic string)) {
+	s.onHub = f
+}
 
-		if err := fn(); err != nil {
-			lastErr = err
-			logger.Warn("Operation failed",
-				"operation", operation,
-				"attempt", i+1,
-				"error", err,
-			)
-			continue
-		}
+// SetFeedStore 为指定 Feed 设置独立的对象存储，需在该 Feed 开始更新前调用
+func (s *RssService) SetFeedStore(feedName string, store dao.ObjectStore) {
+	s.feedMu.Lock()
+	defer s.feedMu.Unlock()
+	if s.feedStores == nil {
+		s.feedStores = make(map[string]dao.ObjectStore)
+	}
+	s.feedStores[feedName] = store
+}
 
-		return nil
+// SetFeedAIService 为指定 Feed 设置独立的 AI 服务，需在该 Feed 开始更新前调用
+func (s *RssService) SetFeedAIService(feedName string, ai *AiService) {
+	s.feedMu.Lock()
+	defer s.feedMu.Unlock()
+	if s.feedAI == nil {
+		s.feedAI = make(map[string]*AiService)
+	}
+	s.feedAI[feedName] = ai
+}
+
+// SetClock 设置重试等待使用的时间源
+func (s *RssService) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// AIService 返回用于总结的 AI 服务
+func (s *RssService) AIService() *AiService {
+	return s.aiService
+}
+
+// CheckStorage 检查默认对象存储是否可访问
+func (s *RssService) CheckStorage(ctx context.Context) error {
+	if s.s3Client == nil {
+		return fmt.Errorf("S3 client not configured")
 	}
+	if _, err := s.s3Client.ListObjects(ctx, dao.ProbePrefix); err != nil {
+		return fmt.Errorf("list objects: %w", err)
+	}
+	return nil
+}
 
-	return fmt.Errorf("operation %s failed after %d retries: %w", operation, s.config.MaxRetries, lastErr)
+// storeFor 返回 Feed 使用的对象存储
+func (s *RssService) storeFor(feedName string) dao.ObjectStore {
+	s.feedMu.RLock()
+	defer s.feedMu.RUnlock()
+	if store, ok := s.feedStores[feedName]; ok {
+		return store
+	}
+	return s.s3Client
+}
+
+// aiFor 返回 Feed 使用的 AI 服务
+func (s *RssService) aiFor(feedName string) *AiService {
+	s.feedMu.RLock()
+	defer s.feedMu.RUnlock()
+	if ai, ok := s.feedAI[feedName]; ok {
+		return ai
+	}
+	return s.aiService
+}
+
+// retryWithBackoff 按固定间隔重试操作，最多重试 MaxRetries 次
+func (s *RssService) retryWithBackoff(ctx context.Context, operation string, fn func() error) error {
+	var lastErr error
+	r := retry.Retrier{
+		MaxAttempts: s.config.MaxRetries + 1,
+		Backoff:     retry.Constant(s.config.RetryDelay),
+		Jitter:      s.config.RetryJitter,
+		Clock:       s.clock,
+		OnAttempt: func(attempt int, err error) {
+			if err != nil {
+				logger.Warn("Operation failed",
+					"operation", operation,
+					"attempt", attempt,
+					"max_retries", s.config.MaxRetries,
+					"error", err,
+				)
+			}
+		},
+	}
+	err := r.Do(ctx, func(context.Context) error {
+		lastErr = fn()
+		return lastErr
+	})
+	if errors.Is(err, retry.ErrExhausted) {
+		return fmt.Errorf("operation %s failed after %d retries: %w", operation, s.config.MaxRetries, lastErr)
+	}
+	return err
 }
 
 // ParseFeed 解析 RSS Feed
@@ -117,44 +299,136 @@ func (s *RssService) ParseFeed(ctx context.Context, url string) (*gofeed.Feed, e
 	}()
 
 	// 检查缓存
-	if cached, ok := s.cache.Load(url); ok {
+	if cached, ok := s.cache.get(url, s.clock.Now()); ok {
 		logger.Debug("Cache hit for feed", "url", url)
-		metrics.UpdateCacheStats(true)
-		if feed, ok := cached.(*gofeed.Feed); ok {
-			return feed, nil
+		return cached.feed, nil
+	}
+
+	// 同一 URL 的并发请求共享一次拉取
+	v, err, shared := s.fetches.Do(url, func() (interface{}, error) {
+		feed, err := s.fetchAndParse(ctx, url)
+		if err != nil && isTransientParseError(err) {
+			// 响应体可能被截断，重新拉取一次
+			logger.Warn("Transient feed parse error, refetching", "url", url, "error", err)
+			feed, err = s.fetchAndParse(ctx, url)
+		}
+		if err != nil {
+			return nil, err
 		}
+
+		// 更新缓存
+		s.cache.set(url, cacheEntry{feed: feed}, s.clock.Now())
+		return feed, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if shared {
+		logger.Debug("Shared in-flight feed fetch", "url", url)
 	}
-	metrics.UpdateCacheStats(false)
 
-	// 获取 Feed 内容
-	resp, err := http.Get(url)
+	return v.(*gofeed.Feed), nil
+}
+
+// fetchAndParse 拉取并解析 Feed，每次使用新的解析器
+func (s *RssService) fetchAndParse(ctx context.Context, url string) (*gofeed.Feed, error) {
+	target := s.FeedURL(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	var prev *feedValidator
+	if v, ok := s.validators.Load(url); ok {
+		prev = v.(*feedValidator)
+		if prev.etag != "" {
+			req.Header.Set("If-None-Match", prev.etag)
+		}
+		if prev.lastModified != "" {
+			req.Header.Set("If-Modified-Since", prev.lastModified)
+		}
+	}
+	resp, err := s.client.Do(req)
 	if err != nil {
 		metrics.FeedErrors.WithLabelValues(url, "http_error").Inc()
 		return nil, fmt.Errorf("failed to fetch feed: %w", err)
 	}
 	defer resp.Body.Close()
+	s.recordRedirect(url, target, resp)
+
+	// 内容未变化，复用上次的解析结果
+	if resp.StatusCode == http.StatusNotModified && prev != nil {
+		logger.Debug("Feed not modified", "url", url)
+		metrics.FeedNotModified.WithLabelValues(url).Inc()
+		return prev.feed, nil
+	}
 
 	if resp.StatusCode != http.StatusOK {
 		metrics.FeedErrors.WithLabelValues(url, "http_status_error").Inc()
 		return nil, fmt.Errorf("failed to fetch feed: status code %d", resp.StatusCode)
 	}
 
-	// 解析 Feed
-	parser := gofeed.NewParser()
-	feed, err := parser.Parse(resp.Body)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		metrics.FeedErrors.WithLabelValues(url, "http_error").Inc()
+		return nil, fmt.Errorf("failed to read feed: %w", err)
+	}
+
+	feed, err := gofeed.NewParser().Parse(bytes.NewReader(body))
 	if err != nil {
 		metrics.FeedErrors.WithLabelValues(url, "parse_error").Inc()
 		return nil, fmt.Errorf("failed to parse feed: %w", err)
 	}
 
-	// 更新缓存
-	s.cache.Store(url, feed)
-	metrics.FeedCacheSize.Inc()
+	sum := sha256.Sum256(body)
+	s.bodyHashes.Store(url, hex.EncodeToString(sum[:]))
 
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if etag != "" || lastModified != "" {
+		s.validators.Store(url, &feedValidator{etag: etag, lastModified: lastModified, feed: feed})
+	} else {
+		s.validators.Delete(url)
+	}
 	return feed, nil
 }
 
-// GetStoredFeedItems 从缓存或 S3 获取存储的 Feed 项目
+// InvalidateFeedCache 丢弃 URL 的解析缓存，下次 ParseFeed 重新请求上游（仍会发送条件请求头）
+func (s *RssService) InvalidateFeedCache(url string) {
+	s.cache.remove(url)
+}
+
+// FeedURL 返回实际请求的地址，上游永久重定向后为新地址
+func (s *RssService) FeedURL(url string) string {
+	if moved, ok := s.redirects.Load(url); ok {
+		return moved.(string)
+	}
+	return url
+}
+
+// recordRedirect 重定向链全部为永久重定向（301/308）时记录新地址，之后直接请求新地址
+func (s *RssService) recordRedirect(url, target string, resp *http.Response) {
+	final := resp.Request.URL.String()
+	if final == target {
+		return
+	}
+	for r := resp.Request.Response; r != nil; r = r.Request.Response {
+		if r.StatusCode != http.StatusMovedPermanently && r.StatusCode != http.StatusPermanentRedirect {
+			return
+		}
+	}
+	s.redirects.Store(url, final)
+	logger.Info("Feed moved permanently, using new URL",
+		"url", url,
+		"old_url", target,
+		"new_url", final,
+	)
+}
+
+// isTransientParseError 判断解析错误是否由响应体不完整导致
+func isTransientParseError(err error) bool {
+	return errors.Is(err, io.ErrUnexpectedEOF) || strings.Contains(err.Error(), "unexpected EOF")
+}
+
+// GetStoredFeedItems 从缓存或 S3 获取存储的 Feed 项目，读取失败时返回合并了所有失败条目的错误
 func (s *RssService) GetStoredFeedItems(ctx context.Context, feedName string) ([]map[string]interface{}, error) {
 	startTime := time.Now()
 	defer func() {
@@ -165,22 +439,23 @@ func (s *RssService) GetStoredFeedItems(ctx context.Context, feedName string) ([
 
 	// 检查缓存
 	cacheKey := fmt.Sprintf("items:%s", feedName)
-	if cached, ok := s.cache.Load(cacheKey); ok {
+	if cached, ok := s.cache.get(cacheKey, s.clock.Now()); ok {
 		logger.Debug("Cache hit for stored items", "feed_name", feedName)
-		metrics.UpdateCacheStats(true)
-		items := cached.([]map[string]interface{})
-		return items, nil
+		return cached.items, nil
 	}
-	metrics.UpdateCacheStats(false)
 
 	logger.Info("Retrieving stored feed items", "feed_name", feedName)
 
 	// 获取 item keys
 	var items []map[string]interface{}
-	prefix := fmt.Sprintf("feeds/%s/items/", feedName)
-
-	// 列出所有匹配前缀的对象
-	objectInfos, err := s.s3Client.ListObjects(ctx, prefix)
+	prefix := s.itemKeys.itemsPrefix(feedName)
+
+	// 列出所有匹配前缀的对象，最近写入的排在前面
+	store := s.storeFor(feedName)
+	objectInfos, err := dao.ListObjectsSorted(ctx, store, prefix, dao.ListOptions{
+		SortBy: dao.SortByLastModified,
+		Desc:   true,
+	})
 	if err != nil {
 		logger.Error("Failed to list feed item keys from S3", err,
 			"feed_name", feedName,
@@ -192,20 +467,21 @@ func (s *RssService) GetStoredFeedItems(ctx context.Context, feedName string) ([
 		return nil, fmt.Errorf("failed to list feed items: %w", err)
 	}
 
-	// 并行获取每个 item
-	var wg sync.WaitGroup
-	itemChan := make(chan map[string]interface{}, len(objectInfos))
-	errChan := make(chan error, len(objectInfos))
+	// 并行获取每个 item，同时进行的请求数不超过 MaxConcurrency，结果和错误按列举顺序保存；
+	// 单个条目失败不影响其他条目
+	var g errgroup.Group
+	g.SetLimit(s.config.MaxConcurrency)
+	results := make([]map[string]interface{}, len(objectInfos))
+	errs := make([]error, len(objectInfos))
 
-	for _, objInfo := range objectInfos {
-		wg.Add(1)
-		go func(key string) {
-			defer wg.Done()
+	for i, objInfo := range objectInfos {
+		idx, key := i, objInfo.Key
+		g.Go(func() error {
 			var reader io.Reader
 			var err error
 
 			err = s.retryWithBackoff(ctx, "get_feed_item", func() error {
-				reader, err = s.s3Client.GetObject(ctx, key)
+				reader, err = store.GetObject(ctx, key)
 				return err
 			})
 
@@ -214,8 +490,8 @@ func (s *RssService) GetStoredFeedItems(ctx context.Context, feedName string) ([
 					"feed_name", feedName,
 					"key", key,
 				)
-				errChan <- err
-				return
+				errs[idx] = fmt.Errorf("%s: %w", key, err)
+				return nil
 			}
 
 			// 读取数据
@@ -225,8 +501,8 @@ func (s *RssService) GetStoredFeedItems(ctx context.Context, feedName string) ([
 					"feed_name", feedName,
 					"key", key,
 				)
-				errChan <- err
-				return
+				errs[idx] = fmt.Errorf("%s: %w", key, err)
+				return nil
 			}
 
 			var item map[string]interface{}
@@ -235,36 +511,51 @@ func (s *RssService) GetStoredFeedItems(ctx context.Context, feedName string) ([
 					"feed_name", feedName,
 					"data_size", len(data),
 				)
-				errChan <- err
-				return
+				errs[idx] = fmt.Errorf("%s: %w", key, err)
+				return nil
 			}
 
-			itemChan <- item
-		}(objInfo.Key)
+			results[idx] = item
+			return nil
+		})
 	}
 
-	// 等待所有 goroutine 完成
-	wg.Wait()
-	close(itemChan)
-	close(errChan)
+	g.Wait()
 
-	// 处理错误
-	if len(errChan) > 0 {
-		err := <-errChan
+	// 处理错误，合并所有失败条目的错误
+	var failed []error
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	var readErr error
+	if len(failed) > 0 {
 		metrics.S3OperationTotal.WithLabelValues("get", "error").Inc()
 		metrics.S3OperationErrors.WithLabelValues("get", "read_error").Inc()
 		metrics.FeedErrors.WithLabelValues(feedName, "read_error").Inc()
-		return nil, fmt.Errorf("failed to read some feed items: %w", err)
+		readErr = fmt.Errorf("failed to read %d of %d feed items (%d succeeded): %w",
+			len(failed), len(objectInfos), len(objectInfos)-len(failed), errors.Join(failed...))
+		if !s.config.PartialResults {
+			return nil, readErr
+		}
 	}
 
-	// 收集所有 item
-	for item := range itemChan {
+	// 收集所有 item，跳过读取失败的位置
+	for _, item := range results {
+		if item == nil {
+			continue
+		}
 		// 确保摘要字段存在于结果中
-		if summary, ok := item["custom"].(map[string]interface{})["summary"]; ok {
-			item["summary"] = summary
+		if custom, ok := item["custom"].(map[string]interface{}); ok {
+			if summary, ok := custom["summary"]; ok {
+				item["summary"] = summary
+			}
 		}
 		items = append(items, item)
 	}
+	// 并发读取和列举顺序不代表发布顺序，按发布时间倒序返回
+	SortItemsByPublished(items)
 
 	// 记录项目大小
 	for _, item := range items {
@@ -273,9 +564,13 @@ func (s *RssService) GetStoredFeedItems(ctx context.Context, feedName string) ([
 		}
 	}
 
+	// 部分结果不缓存，下次读取时重试失败的条目
+	if readErr != nil {
+		return items, readErr
+	}
+
 	// 更新缓存
-	s.cache.Store(cacheKey, items)
-	metrics.FeedCacheMisses.Inc()
+	s.cache.set(cacheKey, cacheEntry{items: items}, s.clock.Now())
 
 	return items, nil
 }
@@ -289,7 +584,8 @@ func (s *RssService) StoreFeedItems(ctx context.Context, feedName string, items
 		metrics.FeedOperationLatency.WithLabelValues("store_feed_items").Observe(duration)
 	}()
 
-	if s.s3Client == nil {
+	store := s.storeFor(feedName)
+	if store == nil {
 		err := fmt.Errorf("S3 client not configured")
 		logger.Error("Failed to store feed items", err)
 		metrics.S3OperationTotal.WithLabelValues("store", "error").Inc()
@@ -298,84 +594,56 @@ func (s *RssService) StoreFeedItems(ctx context.Context, feedName string, items
 		return err
 	}
 
+	// 去重，并跳过内容哈希与已存储版本相同的条目
+	items = s.dedupeItems(feedName, items)
+	storedHashes := s.storedContentHashes(ctx, feedName)
+	changed := make([]*gofeed.Item, 0, len(items))
+	for _, item := range items {
+		hash, err := setContentHash(item)
+		if err == nil && hash == storedHashes[s.itemObjectName(feedName, item)] {
+			continue
+		}
+		changed = append(changed, item)
+	}
+	if unchanged := len(items) - len(changed); unchanged > 0 {
+		metrics.FeedItemsUnchanged.WithLabelValues(feedName).Add(float64(unchanged))
+	}
+
 	logger.Info("Storing feed items",
 		"feed_name", feedName,
 		"item_count", len(items),
+		"changed", len(changed),
 	)
 
-	// 存储每个 item 到 S3
-	var wg sync.WaitGroup
-	errChan := make(chan error, len(items))
-
-	for i, item := range items {
-		wg.Add(1)
-		go func(idx int, feedItem *gofeed.Item) {
-			defer wg.Done()
-
-			// 为 item 生成唯一标识符
-			itemID := feedItem.GUID
-			if itemID == "" {
-				// 如果没有 GUID，使用链接或标题作为备选
-				if feedItem.Link != "" {
-					itemID = feedItem.Link
-				} else {
-					itemID = feedItem.Title
+	putOpts := itemPutOptions(feedName)
+
+	// 按批并发存储到 S3，同时进行的任务数不超过 StoreConcurrency；
+	// 单个条目失败不影响其他条目，返回第一个错误
+	var g errgroup.Group
+	g.SetLimit(s.config.StoreConcurrency)
+	for start := 0; start < len(changed); start += s.config.StoreBatchSize {
+		end := min(start+s.config.StoreBatchSize, len(changed))
+		g.Go(func() error {
+			var firstErr error
+			for idx := start; idx < end; idx++ {
+				if err := s.storeFeedItem(ctx, store, feedName, idx, changed[idx], putOpts); err != nil && firstErr == nil {
+					firstErr = err
 				}
 			}
-
-			// 创建安全的文件名
-			safeID := s.sanitizeID(itemID)
-
-			// 生成存储路径
-			objectName := fmt.Sprintf("feeds/%s/items/%s.json", feedName, safeID)
-
-			// 将项目转换为 JSON
-			data, err := json.Marshal(feedItem)
-			if err != nil {
-				logger.Error("Failed to marshal feed item", err,
-					"feed_name", feedName,
-					"item_index", idx,
-				)
-				errChan <- fmt.Errorf("failed to marshal item: %w", err)
-				return
-			}
-
-			// 存储到 S3
-			if err := s.s3Client.PutObject(ctx, objectName, data, "application/json"); err != nil {
-				logger.Error("Failed to store item in S3", err,
-					"feed_name", feedName,
-					"object_name", objectName,
-				)
-				errChan <- fmt.Errorf("failed to store item in S3: %w", err)
-				return
-			}
-
-			logger.Debug("Successfully stored feed item",
-				"feed_name", feedName,
-				"object_name", objectName,
-				"data_size", len(data),
-			)
-
-			metrics.S3OperationTotal.WithLabelValues("store", "success").Inc()
-			metrics.S3ObjectSize.WithLabelValues("store").Observe(float64(len(data)))
-		}(i, item)
+			return firstErr
+		})
 	}
 
-	// 等待所有 goroutine 完成
-	wg.Wait()
-	close(errChan)
-
 	// 处理错误
-	if len(errChan) > 0 {
-		err := <-errChan
+	if err := g.Wait(); err != nil {
 		metrics.S3OperationTotal.WithLabelValues("store", "error").Inc()
 		metrics.S3OperationErrors.WithLabelValues("store", "s3_error").Inc()
 		metrics.FeedErrors.WithLabelValues(feedName, "s3_error").Inc()
 		return fmt.Errorf("failed to store some items in S3: %w", err)
 	}
 
-	// 更新缓存
-	s.cache.Store(fmt.Sprintf("items:%s", feedName), items)
+	// 使缓存失效，下次读取时从 S3 重新加载
+	s.cache.remove(fmt.Sprintf("items:%s", feedName))
 
 	logger.Info("Successfully stored all feed items",
 		"feed_name", feedName,
@@ -387,6 +655,161 @@ func (s *RssService) StoreFeedItems(ctx context.Context, feedName string, items
 	return nil
 }
 
+// itemPutOptions 返回写入条目的选项，为对象打上标签，便于存储生命周期规则按 Feed 和日期清理
+func itemPutOptions(feedName string) dao.PutOptions {
+	return dao.PutOptions{
+		ContentType: "application/json",
+		Tags: map[string]string{
+			dao.TagFeed:    dao.TagValue(feedName),
+			dao.TagType:    "item",
+			dao.TagCreated: time.Now().UTC().Format("2006-01-02"),
+		},
+	}
+}
+
+// storeFeedItem 将单个条目写入存储，失败时记录到死信计数
+func (s *RssService) storeFeedItem(ctx context.Context, store dao.ObjectStore, feedName string, idx int, feedItem *gofeed.Item, putOpts dao.PutOptions) error {
+	objectName := s.itemObjectName(feedName, feedItem)
+
+	// 将项目转换为 JSON
+	data, err := json.Marshal(feedItem)
+	if err != nil {
+		logger.Error("Failed to marshal feed item", err,
+			"feed_name", feedName,
+			"item_index", idx,
+		)
+		err = fmt.Errorf("failed to marshal item: %w", err)
+		s.recordItemFailure(ctx, feedName, DeadLetterStore, feedItem, err)
+		return err
+	}
+
+	// 存储到 S3
+	if err := store.PutObject(ctx, objectName, data, putOpts); err != nil {
+		logger.Error("Failed to store item in S3", err,
+			"feed_name", feedName,
+			"object_name", objectName,
+		)
+		err = fmt.Errorf("failed to store item in S3: %w", err)
+		s.recordItemFailure(ctx, feedName, DeadLetterStore, feedItem, err)
+		return err
+	}
+	s.clearItemFailure(feedName, DeadLetterStore, feedItem)
+
+	logger.Debug("Successfully stored feed item",
+		"feed_name", feedName,
+		"object_name", objectName,
+		"data_size", len(data),
+	)
+
+	metrics.S3OperationTotal.WithLabelValues("store", "success").Inc()
+	metrics.S3ObjectSize.WithLabelValues("store").Observe(float64(len(data)))
+	return nil
+}
+
+// itemObjectName 返回条目的存储路径
+func (s *RssService) itemObjectName(feedName string, item *gofeed.Item) string {
+	return s.itemIDObjectName(feedName, itemIdentity(item), itemDate(item))
+}
+
+// itemIDObjectName 返回条目 ID 和日期对应的存储路径
+func (s *RssService) itemIDObjectName(feedName, itemID string, date time.Time) string {
+	// 创建安全的文件名
+	objectName := s.itemKeys.key(feedName, s.sanitizeID(itemID), date)
+	if len(objectName) > maxObjectKeyLength {
+		// 兜底：超出对象键长度限制时整体使用哈希
+		objectName = s.itemKeys.key(feedName, shortHash(itemID, 64), date)
+	}
+	return objectName
+}
+
+// findItemObject 返回 Feed 中条目 ID 对应的已存储对象名，不存在时返回空字符串；
+// 按日期分区时日期未知，在 Feed 的全部条目中按文件名查找
+func (s *RssService) findItemObject(ctx context.Context, store dao.ObjectStore, feedName, itemID string) (string, error) {
+	objectName := s.itemIDObjectName(feedName, itemID, time.Time{})
+	prefix := objectName
+	if s.itemKeys.dated {
+		prefix = s.itemKeys.itemsPrefix(feedName)
+	}
+
+	// 未分区时以完整对象名为前缀列举，确认对象存在
+	objects, err := store.ListObjects(ctx, prefix)
+	if err != nil {
+		return "", err
+	}
+	fileName := path.Base(objectName)
+	for _, obj := range objects {
+		if obj.Key == objectName || (s.itemKeys.dated && path.Base(obj.Key) == fileName) {
+			return obj.Key, nil
+		}
+	}
+	return "", nil
+}
+
+// ErrItemNotFound 要删除的条目不存在
+var ErrItemNotFound = errors.New("item not found")
+
+// DeleteStoredItem 删除 Feed 中 ID（GUID、链接或内容哈希）对应的存储条目并使条目缓存失效，
+// 条目不存在时返回 ErrItemNotFound
+func (s *RssService) DeleteStoredItem(ctx context.Context, feedName, itemID string) error {
+	store := s.storeFor(feedName)
+	if store == nil {
+		return fmt.Errorf("S3 client not configured")
+	}
+	objectName, err := s.findItemObject(ctx, store, feedName, itemID)
+	if err != nil {
+		return fmt.Errorf("failed to check item: %w", err)
+	}
+	if objectName == "" {
+		return ErrItemNotFound
+	}
+
+	if err := store.RemoveObject(ctx, objectName); err != nil {
+		metrics.S3OperationTotal.WithLabelValues("remove", "error").Inc()
+		return fmt.Errorf("failed to remove item: %w", err)
+	}
+	metrics.S3OperationTotal.WithLabelValues("remove", "success").Inc()
+	metrics.FeedItemsTotal.WithLabelValues(feedName).Dec()
+	s.cache.remove(fmt.Sprintf("items:%s", feedName))
+
+	logger.Info("Deleted stored item",
+		"feed_name", feedName,
+		"object_name", objectName,
+	)
+	return nil
+}
+
+const (
+	// maxObjectKeyLength S3 对象键的最大字节数
+	maxObjectKeyLength = 1024
+	// maxFeedKeyLength 对象键中 Feed 名称部分的最大字节数
+	maxFeedKeyLength = 128
+)
+
+// feedKeyName 返回对象键中使用的 Feed 名称，过长的名称截断并附加哈希以保持唯一
+func feedKeyName(feedName string) string {
+	if len(feedName) <= maxFeedKeyLength {
+		return feedName
+	}
+	return truncateUTF8(feedName, maxFeedKeyLength-9) + "_" + shortHash(feedName, 8)
+}
+
+// shortHash 返回 SHA-256 十六进制摘要的前 n 位
+func shortHash(s string, n int) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])[:n]
+}
+
+// truncateUTF8 截断到不超过 n 字节，且不拆分多字节字符
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
 // sanitizeID 清理标识符以便安全用作文件名
 func (s *RssService) sanitizeID(id string) string {
 	// 简单替换不安全的字符
@@ -406,10 +829,7 @@ func (s *RssService) sanitizeID(id string) string {
 	// 如果 ID 过长，截断并添加哈希后缀
 	safeID := replacer.Replace(id)
 	if len(safeID) > 200 {
-		h := sha256.New()
-		h.Write([]byte(id))
-		hash := fmt.Sprintf("%x", h.Sum(nil))[:8]
-		safeID = safeID[:192] + "_" + hash
+		safeID = truncateUTF8(safeID, 192) + "_" + shortHash(id, 8)
 	}
 
 	return safeID
@@ -437,6 +857,15 @@ func (s *RssService) UpdateFeed(ctx context.Context, feed conf.Feed) error {
 
 	logger.Info("Starting feed update")
 
+	transformers, err := NewTransformers(feed.Transforms)
+	if err != nil {
+		logger.Error("Invalid feed transforms",
+			"error", err,
+		)
+		metrics.FeedUpdateTotal.WithLabelValues(feed.Name, "error").Inc()
+		return fmt.Errorf("invalid transforms: %w", err)
+	}
+
 	// 解析 Feed
 	parsedFeed, err := s.ParseFeed(ctx, feed.RssFeed)
 	if err != nil {
@@ -451,35 +880,61 @@ func (s *RssService) UpdateFeed(ctx context.Context, feed conf.Feed) error {
 
 		"item_count", len(parsedFeed.Items),
 	)
-
-	// 为每个条目生成摘要
-	items := parsedFeed.Items
-	for i, item := range items {
-
-		var content = item.Content
-		if content == "" {
-			content = item.Description
+	s.checkItemCount(logger, feed.Name, len(parsedFeed.Items))
+	if s.onHub != nil {
+		if hub, topic := DiscoverHub(parsedFeed); hub != "" {
+			s.onHub(feed, hub, topic)
 		}
-		// 生成摘要
-		summary, err := s.aiService.Summarize(ctx, content)
-		if err != nil {
-			logger.Error("Failed to generate summary",
-				"error", err,
-				"content", content,
-				"item_index", i,
-			)
-			metrics.AISummaryErrors.WithLabelValues("summarize_error").Inc()
-			continue // 继续处理其他条目
+	}
+
+	// 内容未变化时跳过摘要和存储，但快速启动留下的待生成摘要仍需补全
+	_, started := s.started.Load(feed.Name)
+	bodyHash, _ := s.bodyHashes.Load(feed.RssFeed)
+	if s.config.SkipUnchanged && bodyHash != nil {
+		if last, ok := s.processedHashes.Load(feed.Name); ok && last == bodyHash {
+			logger.Info("Feed unchanged since last update, skipping")
+			if s.config.FastStart && started {
+				s.backfillPending(ctx, logger, feed.Name)
+			}
+			metrics.FeedUpdateTotal.WithLabelValues(feed.Name, "unchanged").Inc()
+			return nil
 		}
+	}
 
-		logger.Info("Summary",
-			"summary", summary)
+	// 记录上游当前的条目，转换前后的存储路径都视为存在
+	upstream := make(map[string]bool, len(parsedFeed.Items))
+	s.addItemNames(upstream, feed.Name, parsedFeed.Items)
 
-		// 创建自定义字段存储摘要，而不是覆盖内容
-		if item.Custom == nil {
-			items[i].Custom = make(map[string]string)
-		}
-		items[i].Custom["summary"] = summary
+	// 按作者过滤
+	items := filterItemsByAuthor(feed, parsedFeed.Items)
+	if len(items) < len(parsedFeed.Items) {
+		logger.Info("Filtered feed items by author",
+			"dropped", len(parsedFeed.Items)-len(items),
+		)
+	}
+
+	// 只处理最新的若干条目
+	filtered := len(items)
+	items = latestItems(items, feed.MaxFetchItems)
+	if len(items) < filtered {
+		logger.Info("Limited feed items",
+			"max_fetch_items", feed.MaxFetchItems,
+			"dropped", filtered-len(items),
+		)
+	}
+
+	// 在总结前应用转换
+	items = applyTransformers(items, transformers)
+
+	// 为每个条目生成摘要，快速启动的首次更新跳过
+	switch {
+	case s.config.FastStart && !started:
+		logger.Info("Fast start, storing items without summaries")
+		markSummaryPending(items)
+	case s.config.FastStart:
+		s.summarizeNewItems(ctx, logger, feed.Name, items, false)
+	default:
+		s.summarizeNewItems(ctx, logger, feed.Name, items, true)
 	}
 
 	// 存储到 S3
@@ -492,6 +947,38 @@ func (s *RssService) UpdateFeed(ctx context.Context, feed conf.Feed) error {
 		return fmt.Errorf("failed to store feed items: %w", err)
 	}
 
+	// 保存上游的频道标题和描述，失败时下次更新重试
+	if err := s.storeFeedMeta(ctx, feed.Name, parsedFeed); err != nil {
+		logger.Warn("Failed to store feed meta", "error", err)
+	}
+
+	// 处理上游已删除的条目；上游为空时可能是临时故障，不处理
+	if len(parsedFeed.Items) > 0 {
+		s.addItemNames(upstream, feed.Name, items)
+		if err := s.reconcileMissing(ctx, feed, upstream); err != nil {
+			logger.Warn("Failed to handle items missing upstream", "error", err)
+		}
+	}
+
+	// 清理超出保留上限的旧条目，失败时下次更新重试
+	if feed.MaxStoredItems > 0 {
+		if err := s.pruneStoredItems(ctx, feed.Name, feed.MaxStoredItems); err != nil {
+			logger.Warn("Failed to prune stored items", "error", err)
+		}
+	}
+
+	// 摘要失败或推迟时不记录，相同内容的下次更新重新生成
+	if bodyHash != nil && !anySummaryPending(items) {
+		s.processedHashes.Store(feed.Name, bodyHash)
+	}
+
+	if s.config.FastStart {
+		if started {
+			s.backfillPending(ctx, logger, feed.Name)
+		}
+		s.started.Store(feed.Name, struct{}{})
+	}
+
 	logger.Info("Successfully updated feed",
 		"item_count", len(items),
 	)
@@ -500,6 +987,184 @@ func (s *RssService) UpdateFeed(ctx context.Context, feed conf.Feed) error {
 	return nil
 }
 
+// pruneStoredItems 按发布时间保留最新的 limit 个存储条目，删除其余条目；
+// 没有发布时间的条目视为最旧，优先删除
+func (s *RssService) pruneStoredItems(ctx context.Context, feedName string, limit int) error {
+	items, err := s.GetStoredFeedItems(ctx, feedName)
+	if err != nil {
+		return err
+	}
+	if len(items) <= limit {
+		return nil
+	}
+
+	store := s.storeFor(feedName)
+	removed := 0
+	var firstErr error
+	for _, raw := range items[limit:] {
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return fmt.Errorf("marshal item: %w", err)
+		}
+		var item gofeed.Item
+		if err := json.Unmarshal(data, &item); err != nil {
+			return fmt.Errorf("unmarshal item: %w", err)
+		}
+		objectName := s.itemObjectName(feedName, &item)
+		if err := store.RemoveObject(ctx, objectName); err != nil {
+			metrics.S3OperationTotal.WithLabelValues("remove", "error").Inc()
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to remove %s: %w", objectName, err)
+			}
+			continue
+		}
+		metrics.S3OperationTotal.WithLabelValues("remove", "success").Inc()
+		removed++
+	}
+
+	if removed > 0 {
+		metrics.FeedItemsPruned.WithLabelValues(feedName).Add(float64(removed))
+		metrics.FeedItemsTotal.WithLabelValues(feedName).Set(float64(len(items) - removed))
+		s.cache.remove(fmt.Sprintf("items:%s", feedName))
+		logger.Info("Pruned stored items beyond retention limit",
+			"feed_name", feedName,
+			"max_stored_items", limit,
+			"removed", removed,
+		)
+	}
+	return firstErr
+}
+
+// latestItems 按发布时间倒序返回最新的 limit 个条目，limit 为 0 时返回全部
+func latestItems(items []*gofeed.Item, limit int) []*gofeed.Item {
+	if limit <= 0 || len(items) <= limit {
+		return items
+	}
+
+	sorted := make([]*gofeed.Item, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].PublishedParsed, sorted[j].PublishedParsed
+		if a == nil || b == nil {
+			// 没有发布时间的条目排在最后
+			return a != nil
+		}
+		return a.After(*b)
+	})
+	return sorted[:limit]
+}
+
+// summarizeItems 为条目生成摘要并写入自定义字段，失败的条目保持原样
+func (s *RssService) summarizeItems(ctx context.Context, logger *slog.Logger, feedName string, items []*gofeed.Item) {
+	ai := s.aiFor(feedName)
+	contents := make([]string, len(items))
+	for i, item := range items {
+		contents[i] = item.Content
+		if contents[i] == "" {
+			contents[i] = item.Description
+		}
+	}
+
+	var summaries []string
+	skipFrom := len(items)
+	if s.hasAIBudget(feedName) {
+		summaries, skipFrom = s.summarizeWithinBudget(ctx, logger, ai, feedName, contents)
+	} else {
+		summaries = s.summarizeContents(ctx, logger, ai, feedName, contents)
+	}
+	if skipped := len(items) - skipFrom; skipped > 0 {
+		// 超出当天用量上限的条目原样存储，后续更新时重新生成
+		logger.Warn("Daily AI budget exhausted, storing items without summaries",
+			"skipped", skipped,
+		)
+		metrics.AIBudgetSkippedItems.WithLabelValues(feedName).Add(float64(skipped))
+		markSummaryPending(items[skipFrom:])
+	}
+
+	for i, summary := range summaries[:skipFrom] {
+		if summary == "" {
+			// 标记为待重试，后续更新会重新生成
+			markSummaryPending(items[i : i+1])
+			s.recordItemFailure(ctx, feedName, DeadLetterSummarize, items[i], errSummaryFailed)
+			continue
+		}
+		s.clearItemFailure(feedName, DeadLetterSummarize, items[i])
+		logger.Info("Summary",
+			"summary", summary)
+
+		// 创建自定义字段存储摘要，而不是覆盖内容
+		if items[i].Custom == nil {
+			items[i].Custom = make(map[string]string)
+		}
+		items[i].Custom["summary"] = summary
+		if ai.DryRun() {
+			// 演练模式的占位摘要不算完成，关闭演练后重新生成
+			items[i].Custom[summaryPendingKey] = "true"
+		} else {
+			delete(items[i].Custom, summaryPendingKey)
+		}
+	}
+}
+
+// summarizeContents 为每段内容生成摘要，开启摘要缓存时优先使用缓存
+func (s *RssService) summarizeContents(ctx context.Context, logger *slog.Logger, ai *AiService, feedName string, contents []string) []string {
+	if s.config.CacheSummaries && !ai.DryRun() {
+		return s.summarizeWithCache(ctx, logger, ai, feedName, contents)
+	}
+	return s.generateSummaries(ctx, logger, ai, contents)
+}
+
+// summarizeWithinBudget 按批次生成摘要并累计 AI 用量，当天用量达到上限后停止，
+// 返回摘要和第一个未总结条目的下标
+func (s *RssService) summarizeWithinBudget(ctx context.Context, logger *slog.Logger, ai *AiService, feedName string, contents []string) ([]string, int) {
+	chunk := 1
+	if ai.BatchEnabled() {
+		chunk = ai.config.BatchSize
+	}
+	summaries := make([]string, len(contents))
+	for start := 0; start < len(contents); start += chunk {
+		if s.aiBudgetExhausted(feedName) {
+			return summaries, start
+		}
+		end := min(start+chunk, len(contents))
+		usageCtx, usage := withAIUsage(ctx)
+		copy(summaries[start:end], s.summarizeContents(usageCtx, logger, ai, feedName, contents[start:end]))
+		s.recordAISpend(feedName, usage)
+	}
+	return summaries, len(contents)
+}
+
+// generateSummaries 调用 AI 为每段内容生成摘要，失败的位置为空字符串
+func (s *RssService) generateSummaries(ctx context.Context, logger *slog.Logger, ai *AiService, contents []string) []string {
+	if ai.BatchEnabled() {
+		summaries, err := ai.SummarizeBatch(ctx, contents)
+		if err != nil {
+			logger.Error("Failed to generate some summaries",
+				"error", err,
+			)
+			metrics.AISummaryErrors.WithLabelValues("summarize_error").Inc()
+		}
+		return summaries
+	}
+
+	summaries := make([]string, len(contents))
+	for i, content := range contents {
+		// 生成摘要
+		summary, err := ai.Summarize(ctx, content)
+		if err != nil {
+			logger.Error("Failed to generate summary",
+				"error", err,
+				"content", content,
+				"item_index", i,
+			)
+			metrics.AISummaryErrors.WithLabelValues("summarize_error").Inc()
+			continue // 继续处理其他条目
+		}
+		summaries[i] = summary
+	}
+	return summaries
+}
+
 // FormatFeedItems 格式化 Feed 项目，确保内容包含摘要
 func (s *RssService) FormatFeedItems(ctx context.Context, feedName string) ([]map[string]interface{}, error) {
 	startTime := time.Now()
@@ -510,14 +1175,23 @@ func (s *RssService) FormatFeedItems(ctx context.Context, feedName string) ([]ma
 
 	// 获取存储的 Feed 项目
 	items, err := s.GetStoredFeedItems(ctx, feedName)
-	if err != nil {
+	if err != nil && items == nil {
 		logger.Error("Failed to get feed items for formatting", err, "feed_name", feedName)
 		metrics.FeedErrors.WithLabelValues(feedName, "format_error").Inc()
 		return nil, fmt.Errorf("failed to get feed items: %w", err)
 	}
+	if err != nil {
+		logger.Warn("Serving partial feed items", "feed_name", feedName, "error", err)
+	}
+
+	// 处理每个项目，复制后再修改以免污染缓存
+	formatted := make([]map[string]interface{}, len(items))
+	for i, cached := range items {
+		item := make(map[string]interface{}, len(cached))
+		for k, v := range cached {
+			item[k] = v
+		}
 
-	// 处理每个项目
-	for i, item := range items {
 		var content string
 		var summary string
 
@@ -546,10 +1220,178 @@ func (s *RssService) FormatFeedItems(ctx context.Context, feedName string) ([]ma
 		}
 
 		// 确保返回的数据不包含任何可能导致序列化问题的类型
-		items[i] = cleanupItemFields(item)
+		formatted[i] = cleanupItemFields(item)
 	}
 
-	return items, nil
+	return formatted, nil
+}
+
+// GetFeedItems 获取结构化的 Feed 项目，摘要填充到 Summary 字段
+func (s *RssService) GetFeedItems(ctx context.Context, feedName string) ([]FeedItem, error) {
+	items, err := s.GetStoredFeedItems(ctx, feedName)
+	if err != nil && items == nil {
+		return nil, fmt.Errorf("failed to get feed items: %w", err)
+	}
+	if err != nil {
+		logger.Warn("Serving partial feed items", "feed_name", feedName, "error", err)
+	}
+
+	feedItems := make([]FeedItem, 0, len(items))
+	for _, raw := range items {
+		item, err := feedItemFromMap(raw)
+		if err != nil {
+			logger.Warn("Skipping malformed feed item",
+				"feed_name", feedName,
+				"error", err,
+			)
+			continue
+		}
+		feedItems = append(feedItems, item)
+	}
+	return feedItems, nil
+}
+
+// feedItemFromMap 将存储的原始条目转换为 FeedItem
+func feedItemFromMap(raw map[string]interface{}) (FeedItem, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return FeedItem{}, fmt.Errorf("marshal item: %w", err)
+	}
+	var item gofeed.Item
+	if err := json.Unmarshal(data, &item); err != nil {
+		return FeedItem{}, fmt.Errorf("unmarshal item: %w", err)
+	}
+	return NewFeedItem(&item), nil
+}
+
+// NewFeedItem 将 gofeed 条目转换为 FeedItem
+func NewFeedItem(item *gofeed.Item) FeedItem {
+	feedItem := FeedItem{
+		Title:       item.Title,
+		Link:        item.Link,
+		Description: item.Description,
+		Content:     item.Content,
+		Summary:     item.Custom["summary"],
+		GUID:        item.GUID,
+	}
+	feedItem.SummaryPending = item.Custom[summaryPendingKey] == "true"
+	if item.PublishedParsed != nil {
+		feedItem.Published = *item.PublishedParsed
+	} else if item.UpdatedParsed != nil {
+		feedItem.Published = *item.UpdatedParsed
+	}
+	if len(item.Enclosures) > 0 && item.Enclosures[0] != nil {
+		feedItem.Enclosure = &Enclosure{
+			URL:    item.Enclosures[0].URL,
+			Type:   item.Enclosures[0].Type,
+			Length: item.Enclosures[0].Length,
+		}
+	}
+	if item.Image != nil {
+		feedItem.Image = item.Image.URL
+	}
+	if itunes := item.ITunesExt; itunes != nil {
+		feedItem.Duration = itunes.Duration
+		feedItem.Episode = itunes.Episode
+		if itunes.Image != "" {
+			feedItem.Image = itunes.Image
+		}
+	}
+	return feedItem
+}
+
+// RSSItem 将 FeedItem 转换为 RSS 条目，摘要置于描述之前
+func (i FeedItem) RSSItem() RSSItem {
+	description := i.Description
+	if i.Summary != "" {
+		description = fmt.Sprintf("<p><strong>摘要</strong>: %s</p><hr/>%s", i.Summary, i.Description)
+	}
+	item := RSSItem{
+		Title:          i.Title,
+		Link:           i.Link,
+		Description:    description,
+		Content:        i.Content,
+		GUID:           i.GUID,
+		Enclosure:      i.Enclosure,
+		Image:          i.Image,
+		ITunesDuration: i.Duration,
+		ITunesEpisode:  i.Episode,
+		parts: ContentData{
+			Title:       i.Title,
+			Link:        i.Link,
+			Summary:     i.Summary,
+			Content:     i.Content,
+			Description: i.Description,
+		},
+	}
+	if !i.Published.IsZero() {
+		item.PubDate = i.Published.Format(time.RFC1123Z)
+	}
+	if i.Image != "" && (i.Duration != "" || i.Episode != "") {
+		item.ITunesImage = &ITunesImage{Href: i.Image}
+	}
+	return item
+}
+
+// GetChannel 获取存储的条目并构建可渲染的频道
+func (s *RssService) GetChannel(ctx context.Context, feed conf.Feed) (Channel, error) {
+	feedItems, err := s.GetFeedItems(ctx, feed.Name)
+	if err != nil {
+		return Channel{}, err
+	}
+	items := make([]RSSItem, 0, len(feedItems))
+	for _, item := range feedItems {
+		items = append(items, item.RSSItem())
+	}
+	// 频道信息读取失败时仍可渲染，标题退回到 Feed 名称
+	meta, err := s.feedMeta(ctx, feed.Name)
+	if err != nil {
+		log.FromContext(ctx).Warn("Failed to load feed meta", "feed_name", feed.Name, "error", err)
+	}
+	return newChannel(feed, meta, feed.RssFeed, items), nil
+}
+
+// SortItemsByPublished 按发布时间倒序排列条目，无法解析时间的条目排在最后，
+// 时间相同或都无法解析时按 guid、链接、标题排序，保证顺序稳定
+func SortItemsByPublished(items []map[string]interface{}) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, aok := itemPublished(items[i])
+		b, bok := itemPublished(items[j])
+		if aok != bok {
+			return aok
+		}
+		if aok && !a.Equal(b) {
+			return a.After(b)
+		}
+		return itemSortKey(items[i]) < itemSortKey(items[j])
+	})
+}
+
+// itemSortKey 发布时间无法区分时用于排序的键
+func itemSortKey(item map[string]interface{}) string {
+	var key strings.Builder
+	for _, field := range []string{"guid", "link", "title"} {
+		value, _ := item[field].(string)
+		key.WriteString(value)
+		key.WriteByte(0)
+	}
+	return key.String()
+}
+
+// itemPublished 解析条目的发布时间，没有发布时间时使用更新时间
+func itemPublished(item map[string]interface{}) (time.Time, bool) {
+	for _, key := range []string{"publishedParsed", "updatedParsed", "published", "updated"} {
+		value, ok := item[key].(string)
+		if !ok || value == "" {
+			continue
+		}
+		for _, layout := range []string{time.RFC3339Nano, time.RFC1123Z, time.RFC1123} {
+			if t, err := time.Parse(layout, value); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
 }
 
 // cleanupItemFields 清理项目字段，确保数据类型适合JSON序列化
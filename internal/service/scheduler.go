@@ -2,17 +2,43 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
+	"go.orx.me/apps/unifeed/internal/clock"
 	"go.orx.me/apps/unifeed/internal/conf"
+	"go.orx.me/apps/unifeed/internal/logger"
+	"go.orx.me/apps/unifeed/internal/metrics"
+)
+
+var (
+	// ErrJobExists Feed 已有更新任务
+	ErrJobExists = errors.New("job already exists")
+	// ErrJobNotFound Feed 没有更新任务
+	ErrJobNotFound = errors.New("job not found")
 )
 
 type SchedulerConfig struct {
 	UpdateInterval time.Duration
 	MaxRetries     int
 	RetryDelay     time.Duration
+	// FailureBackoff 失败周期后的首次重试间隔
+	FailureBackoff time.Duration
+	// ErrorLogWindow 相同错误的日志采样窗口，为 0 时不采样
+	ErrorLogWindow time.Duration
+	// ErrorLogEvery 采样窗口内每 N 次相同错误输出一次日志
+	ErrorLogEvery int
+	// BootConcurrency StartAllJobs 启动时同时进行首次更新的任务数上限，0 表示不限制
+	BootConcurrency int
+	// BootStagger StartAllJobs 启动时将各任务的首次更新均匀分散到该时间窗口内
+	BootStagger time.Duration
+	// DependencyBackoff 共享依赖不可用时暂停全部更新周期的初始间隔，0 表示关闭
+	DependencyBackoff time.Duration
+	// ShutdownTimeout StopAllJobs 等待进行中的更新完成的最长时间
+	ShutdownTimeout time.Duration
 }
 
 type SchedulerService struct {
@@ -20,6 +46,41 @@ type SchedulerService struct {
 	config     SchedulerConfig
 	jobs       map[string]*Job
 	mu         sync.RWMutex
+	// events 订阅者接收更新事件的通道，为 nil 时不发送
+	events chan Event
+	// errorLogs 按 Feed 采样重复的失败日志，为 nil 时全部输出
+	errorLogs *logger.Sampler
+	// bootSlots 限制启动时并发的首次更新，为 nil 时不限制
+	bootSlots chan struct{}
+	// clock 定时和等待使用的时间源
+	clock clock.Clock
+	// dependencies 共享依赖闸门，为 nil 时各 Feed 独立失败
+	dependencies *dependencyGate
+	// running 运行中的更新循环，停止时等待其退出
+	running sync.WaitGroup
+}
+
+// EventType 调度事件类型
+type EventType string
+
+const (
+	EventStarted   EventType = "started"
+	EventSucceeded EventType = "succeeded"
+	EventFailed    EventType = "failed"
+	// EventPaused 共享依赖不可用，本次周期未执行
+	EventPaused EventType = "paused"
+)
+
+// eventBufferSize 事件通道的缓冲大小
+const eventBufferSize = 64
+
+// Event 一次 Feed 更新的生命周期事件
+type Event struct {
+	Type     EventType
+	FeedName string
+	Time     time.Time
+	// Err 更新失败的原因，仅 EventFailed 时有值
+	Err error
 }
 
 type Job struct {
@@ -27,8 +88,64 @@ type Job struct {
 	StopChan chan struct{}
 	LastRun  time.Time
 	Error    error
+	// Failures 连续失败的周期数
+	Failures int
+	// Started 任务启动时间
+	Started time.Time
+	// trigger 请求立即执行一次更新
+	trigger chan struct{}
+	// mu 保护更新循环写入的 LastRun、Error 和 Failures
+	mu sync.Mutex
+}
+
+// snapshot 返回任务状态的副本，调用方可以在更新循环运行时安全读取
+func (j *Job) snapshot() *Job {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return &Job{
+		Feed:     j.Feed,
+		StopChan: j.StopChan,
+		LastRun:  j.LastRun,
+		Error:    j.Error,
+		Failures: j.Failures,
+		Started:  j.Started,
+	}
 }
 
+// recordSuccess 记录一次成功的更新周期
+func (j *Job) recordSuccess(at time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.LastRun = at
+	j.Error = nil
+	j.Failures = 0
+}
+
+// recordFailure 记录一次失败的更新周期，返回连续失败的周期数
+func (j *Job) recordFailure(err error) int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.Error = err
+	j.Failures++
+	return j.Failures
+}
+
+// failing 最近一次更新周期是否失败
+func (j *Job) failing() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.Failures > 0
+}
+
+// JobHealth 任务健康状态
+type JobHealth string
+
+const (
+	JobHealthy JobHealth = "healthy"
+	JobFailing JobHealth = "failing"
+	JobStale   JobHealth = "stale"
+)
+
 // NewSchedulerService 创建一个新的调度器服务实例
 func NewSchedulerService(rssService *RssService, cfg SchedulerConfig) *SchedulerService {
 	if cfg.UpdateInterval == 0 {
@@ -40,33 +157,64 @@ func NewSchedulerService(rssService *RssService, cfg SchedulerConfig) *Scheduler
 	if cfg.RetryDelay == 0 {
 		cfg.RetryDelay = time.Second * 5
 	}
+	if cfg.FailureBackoff == 0 {
+		cfg.FailureBackoff = time.Minute
+	}
+	if cfg.ShutdownTimeout == 0 {
+		cfg.ShutdownTimeout = 30 * time.Second
+	}
 
-	return &SchedulerService{
+	svc := &SchedulerService{
 		rssService: rssService,
 		config:     cfg,
 		jobs:       make(map[string]*Job),
+		clock:      clock.Real(),
+	}
+	svc.dependencies = newDependencyGate(rssService, cfg.DependencyBackoff, cfg.UpdateInterval)
+	if cfg.ErrorLogWindow > 0 {
+		svc.errorLogs = logger.NewSampler(cfg.ErrorLogWindow, cfg.ErrorLogEvery)
+	}
+	if cfg.BootConcurrency > 0 {
+		svc.bootSlots = make(chan struct{}, cfg.BootConcurrency)
 	}
+	return svc
 }
 
-// StartJob 启动一个 Feed 更新任务
+// SetClock 设置时间源，需在启动任务前调用
+func (s *SchedulerService) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// StartJob 启动一个 Feed 更新任务，立即执行首次更新
 func (s *SchedulerService) StartJob(ctx context.Context, feed conf.Feed) error {
+	return s.startJob(ctx, feed, 0, false)
+}
+
+// startJob 启动更新任务，首次更新延迟 delay 执行，boot 为 true 时首次更新受 BootConcurrency 限制
+func (s *SchedulerService) startJob(ctx context.Context, feed conf.Feed, delay time.Duration, boot bool) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	if _, exists := s.jobs[feed.Name]; exists {
-		return fmt.Errorf("job already exists for feed: %s", feed.Name)
+		return fmt.Errorf("%w for feed: %s", ErrJobExists, feed.Name)
 	}
 
 	stopChan := make(chan struct{})
 	job := &Job{
 		Feed:     feed,
 		StopChan: stopChan,
+		Started:  s.clock.Now(),
+		trigger:  make(chan struct{}, 1),
 	}
 
 	s.jobs[feed.Name] = job
 
 	// 启动更新循环
-	go s.runUpdateLoop(ctx, job)
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		s.runUpdateLoop(ctx, job, delay, boot)
+	}()
 
 	return nil
 }
@@ -78,7 +226,7 @@ func (s *SchedulerService) StopJob(feedName string) error {
 
 	job, exists := s.jobs[feedName]
 	if !exists {
-		return fmt.Errorf("job not found for feed: %s", feedName)
+		return fmt.Errorf("%w for feed: %s", ErrJobNotFound, feedName)
 	}
 
 	close(job.StopChan)
@@ -87,28 +235,92 @@ func (s *SchedulerService) StopJob(feedName string) error {
 	return nil
 }
 
-// GetJobStatus 获取任务状态
+// TriggerUpdate 让已启动的任务立即执行一次更新，之后按更新间隔继续
+func (s *SchedulerService) TriggerUpdate(feedName string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	job, exists := s.jobs[feedName]
+	if !exists {
+		return fmt.Errorf("%w for feed: %s", ErrJobNotFound, feedName)
+	}
+	select {
+	case job.trigger <- struct{}{}:
+	default:
+		// 已有待执行的触发
+	}
+	return nil
+}
+
+// GetJobStatus 获取任务状态的副本
 func (s *SchedulerService) GetJobStatus(feedName string) (*Job, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	job, exists := s.jobs[feedName]
 	if !exists {
-		return nil, fmt.Errorf("job not found for feed: %s", feedName)
+		return nil, fmt.Errorf("%w for feed: %s", ErrJobNotFound, feedName)
 	}
 
-	return job, nil
+	return job.snapshot(), nil
 }
 
-// runUpdateLoop 运行更新循环
-func (s *SchedulerService) runUpdateLoop(ctx context.Context, job *Job) {
-	ticker := time.NewTicker(s.config.UpdateInterval)
-	defer ticker.Stop()
+// GetAllJobs 返回所有任务状态的副本，按 Feed 名称排序
+func (s *SchedulerService) GetAllJobs() []*Job {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	// 立即执行一次更新
-	if err := s.updateFeed(ctx, job); err != nil {
-		job.Error = err
+	jobs := make([]*Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job.snapshot())
 	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].Feed.Name < jobs[j].Feed.Name
+	})
+	return jobs
+}
+
+// Health 根据 GetJobStatus 或 GetAllJobs 返回的任务状态计算健康状态：最近一次周期失败为 failing，
+// 超过两个更新间隔（Feed 设置了 UpdateInterval 时按 Feed 的间隔）没有成功更新（从未成功时从启动算起）为 stale
+func (s *SchedulerService) Health(job *Job) JobHealth {
+	if job.Failures > 0 {
+		return JobFailing
+	}
+	last := job.LastRun
+	if last.IsZero() {
+		last = job.Started
+	}
+	if s.clock.Now().Sub(last) > 2*s.updateInterval(job) {
+		return JobStale
+	}
+	return JobHealthy
+}
+
+// SchedulerStats 调度器任务统计
+type SchedulerStats struct {
+	Jobs int `json:"jobs"`
+	// Failing 最近一次周期失败的任务数
+	Failing int `json:"failing"`
+}
+
+// Stats 返回当前任务统计
+func (s *SchedulerService) Stats() SchedulerStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := SchedulerStats{Jobs: len(s.jobs)}
+	for _, job := range s.jobs {
+		if job.failing() {
+			stats.Failing++
+		}
+	}
+	return stats
+}
+
+// runUpdateLoop 运行更新循环，首次更新延迟 delay 执行，失败后以更短的间隔重试
+func (s *SchedulerService) runUpdateLoop(ctx context.Context, job *Job, delay time.Duration, boot bool) {
+	timer := s.clock.NewTimer(delay)
+	defer timer.Stop()
 
 	for {
 		select {
@@ -116,55 +328,203 @@ func (s *SchedulerService) runUpdateLoop(ctx context.Context, job *Job) {
 			return
 		case <-job.StopChan:
 			return
-		case <-ticker.C:
-			if err := s.updateFeed(ctx, job); err != nil {
-				job.Error = err
+		case <-job.trigger:
+			boot = false
+			timer.Stop()
+			timer.Reset(s.runCycle(ctx, job))
+		case <-timer.C():
+			if !boot {
+				timer.Reset(s.runCycle(ctx, job))
+				continue
+			}
+			boot = false
+			next, ok := s.runBootCycle(ctx, job)
+			if !ok {
+				return
 			}
+			timer.Reset(next)
+		}
+	}
+}
+
+// runBootCycle 在获得启动并发名额后执行首次更新，任务停止时返回 false
+func (s *SchedulerService) runBootCycle(ctx context.Context, job *Job) (time.Duration, bool) {
+	if s.bootSlots != nil {
+		select {
+		case s.bootSlots <- struct{}{}:
+		case <-ctx.Done():
+			return 0, false
+		case <-job.StopChan:
+			return 0, false
 		}
+		defer func() { <-s.bootSlots }()
 	}
+	return s.runCycle(ctx, job), true
 }
 
-// updateFeed 更新单个 Feed
+// Events 返回更新事件通道，首次调用时创建；订阅者处理不及时时事件会被丢弃
+func (s *SchedulerService) Events() <-chan Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.events == nil {
+		s.events = make(chan Event, eventBufferSize)
+	}
+	return s.events
+}
+
+// emit 非阻塞地发送事件，没有订阅者或通道已满时丢弃
+func (s *SchedulerService) emit(eventType EventType, feedName string, err error) {
+	s.mu.RLock()
+	events := s.events
+	s.mu.RUnlock()
+	if events == nil {
+		return
+	}
+
+	select {
+	case events <- Event{Type: eventType, FeedName: feedName, Time: s.clock.Now(), Err: err}:
+	default:
+		logger.Debug("Dropped scheduler event", "type", eventType, "feed_name", feedName)
+	}
+}
+
+// runCycle 执行一次更新周期并返回距下次更新的间隔
+func (s *SchedulerService) runCycle(ctx context.Context, job *Job) time.Duration {
+	if wait, ok := s.dependencies.allow(ctx, s.clock.Now()); !ok {
+		metrics.SchedulerPausedCycles.Inc()
+		s.emit(EventPaused, job.Feed.Name, nil)
+		return wait
+	}
+
+	s.emit(EventStarted, job.Feed.Name, nil)
+	if err := s.updateFeed(ctx, job); err != nil {
+		s.emit(EventFailed, job.Feed.Name, err)
+		failures := job.recordFailure(err)
+		delay := s.failureDelay(failures, s.updateInterval(job))
+		// 共享依赖不可用时等待闸门重新检查，而不是按各自的间隔重试
+		if wait, down := s.dependencies.observeFailure(ctx, s.clock.Now()); down {
+			delay = wait
+		}
+		s.errorLogs.Warn(job.Feed.Name, "Feed update cycle failed", err,
+			"feed_name", job.Feed.Name,
+			"failures", failures,
+			"next_retry", delay,
+		)
+		return delay
+	}
+	job.recordSuccess(s.clock.Now())
+	s.emit(EventSucceeded, job.Feed.Name, nil)
+	return s.updateInterval(job)
+}
+
+// updateInterval 返回任务的更新间隔，Feed 未设置时使用调度器默认值
+func (s *SchedulerService) updateInterval(job *Job) time.Duration {
+	if job.Feed.UpdateInterval > 0 {
+		return job.Feed.UpdateInterval
+	}
+	return s.config.UpdateInterval
+}
+
+// failureDelay 计算连续失败后的重试间隔，按 FailureBackoff 指数增长且不超过更新间隔 interval
+func (s *SchedulerService) failureDelay(failures int, interval time.Duration) time.Duration {
+	delay := s.config.FailureBackoff
+	for i := 1; i < failures && delay < interval; i++ {
+		delay *= 2
+	}
+	if delay > interval {
+		delay = interval
+	}
+	return delay
+}
+
+// retryOperationSchedulerUpdate 调度器更新尝试在 FeedRetries 中的 operation 标签
+const retryOperationSchedulerUpdate = "scheduler_update"
+
+// updateFeed 更新单个 Feed，通过 RssService.UpdateFeed 完成解析、摘要和存储，与手动更新保持一致
 func (s *SchedulerService) updateFeed(ctx context.Context, job *Job) error {
 	var lastErr error
 	for i := 0; i < s.config.MaxRetries; i++ {
-		// 解析 Feed
+		// 解析、总结并存储 Feed
 		err := s.rssService.UpdateFeed(ctx, job.Feed)
 		if err != nil {
-			lastErr = fmt.Errorf("failed to parse feed: %w", err)
-			time.Sleep(s.config.RetryDelay)
+			metrics.FeedRetries.WithLabelValues(job.Feed.Name, retryOperationSchedulerUpdate).Inc()
+			lastErr = fmt.Errorf("failed to update feed: %w", err)
+			// 任务停止或 ctx 取消时不再重试，尽快退出
+			if !s.waitRetry(ctx, job) {
+				return lastErr
+			}
 			continue
 		}
 
 		// 更新成功
-		job.LastRun = time.Now()
-		job.Error = nil
 		return nil
 	}
 
+	metrics.FeedRetriesExhausted.WithLabelValues(job.Feed.Name).Inc()
 	return fmt.Errorf("failed after %d retries: %w", s.config.MaxRetries, lastErr)
 }
 
-// StartAllJobs 启动所有配置的 Feed 更新任务
+// StartAllJobs 启动所有配置的 Feed 更新任务，首次更新按 BootStagger 错开并受 BootConcurrency 限制
 func (s *SchedulerService) StartAllJobs(ctx context.Context, feeds []conf.Feed) error {
+	var rssFeeds []conf.Feed
 	for _, feed := range feeds {
-		if feed.RssFeed == "" {
-			continue
+		if feed.RssFeed != "" {
+			rssFeeds = append(rssFeeds, feed)
 		}
-		if err := s.StartJob(ctx, feed); err != nil {
+	}
+
+	for i, feed := range rssFeeds {
+		delay := s.config.BootStagger * time.Duration(i) / time.Duration(len(rssFeeds))
+		if err := s.startJob(ctx, feed, delay, true); err != nil {
 			return fmt.Errorf("failed to start job for feed %s: %w", feed.Name, err)
 		}
 	}
 	return nil
 }
 
-// StopAllJobs 停止所有 Feed 更新任务
+// waitRetry 等待重试间隔，任务停止或 ctx 取消时提前返回 false
+func (s *SchedulerService) waitRetry(ctx context.Context, job *Job) bool {
+	timer := s.clock.NewTimer(s.config.RetryDelay)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return true
+	case <-job.StopChan:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// StopAllJobs 停止所有 Feed 更新任务，最多等待 ShutdownTimeout 让进行中的更新完成
 func (s *SchedulerService) StopAllJobs() {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+	defer cancel()
+	if err := s.StopAllJobsWithTimeout(ctx); err != nil {
+		logger.Warn("Stopped jobs without waiting for in-flight updates", "error", err)
+	}
+}
 
+// StopAllJobsWithTimeout 停止所有 Feed 更新任务，并等待进行中的更新完成；
+// ctx 结束时不再等待并返回其错误，未完成的更新继续在后台运行
+func (s *SchedulerService) StopAllJobsWithTimeout(ctx context.Context) error {
+	s.mu.Lock()
 	for feedName, job := range s.jobs {
 		close(job.StopChan)
 		delete(s.jobs, feedName)
 	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for in-flight updates: %w", ctx.Err())
+	}
 }
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+
+	"go.orx.me/apps/unifeed/internal/dao"
+)
+
+// summaryCachePrefix 存储中按内容哈希缓存摘要的前缀
+const summaryCachePrefix = "summaries/"
+
+// summaryCacheKey 返回命名空间中内容对应的摘要缓存对象名，namespace 由 AiService.cacheNamespace 生成
+func summaryCacheKey(namespace, content string) string {
+	h := sha256.New()
+	h.Write([]byte(namespace))
+	h.Write([]byte{0})
+	h.Write([]byte(content))
+	return summaryCachePrefix + hex.EncodeToString(h.Sum(nil)) + ".txt"
+}
+
+// summarizeWithCache 优先使用存储中缓存的摘要，只为未命中的内容调用 AI 并写回缓存
+func (s *RssService) summarizeWithCache(ctx context.Context, log *slog.Logger, ai *AiService, feedName string, contents []string) []string {
+	store := s.storeFor(feedName)
+	namespace := ai.cacheNamespace()
+	summaries := make([]string, len(contents))
+	var missing []int
+	for i, content := range contents {
+		if content == "" {
+			missing = append(missing, i)
+			continue
+		}
+		if summary, ok := loadCachedSummary(ctx, store, summaryCacheKey(namespace, content)); ok {
+			summaries[i] = summary
+			continue
+		}
+		missing = append(missing, i)
+	}
+	log.Debug("Summary cache lookup",
+		"hits", len(contents)-len(missing),
+		"misses", len(missing),
+	)
+	if len(missing) == 0 {
+		return summaries
+	}
+
+	pending := make([]string, len(missing))
+	for j, i := range missing {
+		pending[j] = contents[i]
+	}
+	for j, summary := range s.generateSummaries(ctx, log, ai, pending) {
+		i := missing[j]
+		summaries[i] = summary
+		if summary == "" || contents[i] == "" {
+			continue
+		}
+		key := summaryCacheKey(namespace, contents[i])
+		if err := store.PutObject(ctx, key, []byte(summary), dao.PutOptions{
+			ContentType: "text/plain; charset=utf-8",
+			Tags: map[string]string{
+				dao.TagFeed:    dao.TagValue(feedName),
+				dao.TagType:    "summary",
+				dao.TagCreated: s.clock.Now().UTC().Format("2006-01-02"),
+			},
+		}); err != nil {
+			// 缓存写入失败不影响本次摘要
+			log.Warn("Failed to cache summary", "key", key, "error", err)
+		}
+	}
+	return summaries
+}
+
+// loadCachedSummary 读取 key 对应的缓存摘要，不存在或读取失败时返回 false
+func loadCachedSummary(ctx context.Context, store dao.ObjectStore, key string) (string, bool) {
+	reader, err := store.GetObject(ctx, key)
+	if err != nil {
+		return "", false
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil || len(data) == 0 {
+		return "", false
+	}
+	return string(data), true
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"strings"
+	"text/template"
+
+	"go.orx.me/apps/unifeed/internal/conf"
+	"go.orx.me/apps/unifeed/internal/logger"
+)
+
+// ContentData 内容模板可使用的字段
+type ContentData struct {
+	Title   string
+	Link    string
+	Author  string
+	Summary string
+	// Content 原始正文
+	Content string
+	// Media 媒体附件的 HTML
+	Media string
+	// Description 默认拼接得到的正文
+	Description string
+}
+
+// applyContentTemplate 按 Feed 配置的模板重写条目正文，渲染失败时保留默认正文
+func applyContentTemplate(feed conf.Feed, items []RSSItem) {
+	tmpl, err := template.New(feed.Name).Parse(feed.ContentTemplate)
+	if err != nil {
+		logger.Warn("Invalid content template", "feed_name", feed.Name, "error", err)
+		return
+	}
+	for i := range items {
+		var b strings.Builder
+		if err := tmpl.Execute(&b, items[i].parts); err != nil {
+			logger.Warn("Failed to render content template", "feed_name", feed.Name, "guid", items[i].GUID, "error", err)
+			continue
+		}
+		// 社交源的 content 与 description 相同，一并替换
+		if items[i].Content == items[i].Description {
+			items[i].Content = b.String()
+		}
+		items[i].Description = b.String()
+	}
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/mmcdole/gofeed"
+	"go.orx.me/apps/unifeed/internal/conf"
+)
+
+const (
+	TransformRegexReplace        = "regex_replace"
+	TransformStripTrackingParams = "strip_tracking_params"
+	TransformUnescapeHTML        = "unescape_html"
+)
+
+// Transformer 在存储前对条目进行转换
+type Transformer interface {
+	Transform(item *gofeed.Item) *gofeed.Item
+}
+
+// NewTransformers 根据配置按顺序构建转换器
+func NewTransformers(cfgs []conf.Transform) ([]Transformer, error) {
+	transformers := make([]Transformer, 0, len(cfgs))
+	for i, cfg := range cfgs {
+		switch cfg.Type {
+		case TransformRegexReplace:
+			re, err := regexp.Compile(cfg.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("transform %d: invalid pattern: %w", i, err)
+			}
+			transformers = append(transformers, &RegexReplaceTransformer{
+				Field:       cfg.Field,
+				Pattern:     re,
+				Replacement: cfg.Replacement,
+			})
+		case TransformStripTrackingParams:
+			transformers = append(transformers, &TrackingParamStripper{})
+		case TransformUnescapeHTML:
+			transformers = append(transformers, &DoubleEscapeFixer{Field: cfg.Field})
+		default:
+			return nil, fmt.Errorf("transform %d: unknown type %q", i, cfg.Type)
+		}
+	}
+	return transformers, nil
+}
+
+// applyTransformers 依次应用转换器
+func applyTransformers(items []*gofeed.Item, transformers []Transformer) []*gofeed.Item {
+	if len(transformers) == 0 {
+		return items
+	}
+	result := make([]*gofeed.Item, 0, len(items))
+	for _, item := range items {
+		for _, t := range transformers {
+			item = t.Transform(item)
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+// textFields 返回条目中指定字段的指针，field 为空时返回 description 和 content
+func textFields(item *gofeed.Item, field string) []*string {
+	switch field {
+	case "title":
+		return []*string{&item.Title}
+	case "link":
+		return []*string{&item.Link}
+	case "description":
+		return []*string{&item.Description}
+	case "content":
+		return []*string{&item.Content}
+	default:
+		return []*string{&item.Description, &item.Content}
+	}
+}
+
+// RegexReplaceTransformer 使用正则替换字段内容
+type RegexReplaceTransformer struct {
+	Field       string
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+func (t *RegexReplaceTransformer) Transform(item *gofeed.Item) *gofeed.Item {
+	for _, field := range textFields(item, t.Field) {
+		*field = t.Pattern.ReplaceAllString(*field, t.Replacement)
+	}
+	return item
+}
+
+var (
+	// escapedTagPattern 被转义的 HTML 标签，如 &lt;p&gt; 或 &lt;/a&gt;
+	escapedTagPattern = regexp.MustCompile(`&lt;/?[a-zA-Z][a-zA-Z0-9]*(\s[^<>]*?)?/?&gt;`)
+	// rawTagPattern 未转义的 HTML 标签
+	rawTagPattern = regexp.MustCompile(`</?[a-zA-Z][a-zA-Z0-9]*(\s[^<>]*)?/?>`)
+)
+
+// DoubleEscapeFixer 修复被转义两次的 HTML：字段中只有转义后的标签、没有真正的标签时反转义一次，
+// 已经正常的内容保持不变
+type DoubleEscapeFixer struct {
+	Field string
+}
+
+func (t *DoubleEscapeFixer) Transform(item *gofeed.Item) *gofeed.Item {
+	for _, field := range textFields(item, t.Field) {
+		*field = UnescapeDoubleEscaped(*field)
+	}
+	return item
+}
+
+// UnescapeDoubleEscaped 检测到双重转义时返回反转义一次的结果，否则原样返回
+func UnescapeDoubleEscaped(s string) string {
+	if !escapedTagPattern.MatchString(s) || rawTagPattern.MatchString(s) {
+		return s
+	}
+	return html.UnescapeString(s)
+}
+
+// trackingParams 常见的跟踪参数
+var trackingParams = []string{"fbclid", "gclid", "dclid", "msclkid", "mc_cid", "mc_eid", "igshid", "ref_src", "spm"}
+
+var urlPattern = regexp.MustCompile(`https?://[^\s"'<>]+`)
+
+// TrackingParamStripper 移除链接和正文中 URL 的跟踪参数
+type TrackingParamStripper struct{}
+
+func (t *TrackingParamStripper) Transform(item *gofeed.Item) *gofeed.Item {
+	item.Link = StripTrackingParams(item.Link)
+	for i, link := range item.Links {
+		item.Links[i] = StripTrackingParams(link)
+	}
+	item.Description = urlPattern.ReplaceAllStringFunc(item.Description, StripTrackingParams)
+	item.Content = urlPattern.ReplaceAllStringFunc(item.Content, StripTrackingParams)
+	return item
+}
+
+// StripTrackingParams 移除 URL 中的 utm_* 等跟踪参数，无法解析的 URL 原样返回
+func StripTrackingParams(rawURL string) string {
+	if !strings.Contains(rawURL, "?") {
+		return rawURL
+	}
+	// 正文 HTML 中的 & 可能被转义为 &amp;
+	u, err := url.Parse(strings.ReplaceAll(rawURL, "&amp;", "&"))
+	if err != nil {
+		return rawURL
+	}
+
+	query := u.Query()
+	changed := false
+	for key := range query {
+		if isTrackingParam(key) {
+			query.Del(key)
+			changed = true
+		}
+	}
+	if !changed {
+		return rawURL
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+func isTrackingParam(key string) bool {
+	key = strings.ToLower(key)
+	if strings.HasPrefix(key, "utm_") {
+		return true
+	}
+	for _, p := range trackingParams {
+		if key == p {
+			return true
+		}
+	}
+	return false
+}
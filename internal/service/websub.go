@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"go.orx.me/apps/unifeed/internal/clock"
+	"go.orx.me/apps/unifeed/internal/conf"
+	"go.orx.me/apps/unifeed/internal/logger"
+)
+
+// webSubRequestTimeout 向 hub 发送订阅请求的超时时间
+const webSubRequestTimeout = 10 * time.Second
+
+// webSubRetryAfter 订阅请求未被验证时，距上次请求超过该时间才重新订阅
+const webSubRetryAfter = 10 * time.Minute
+
+// DiscoverHub 从 Feed 的 atom:link 中查找 WebSub hub 和 topic，未声明 self 链接时 topic 为空
+func DiscoverHub(feed *gofeed.Feed) (hub, topic string) {
+	if feed == nil {
+		return "", ""
+	}
+	for _, link := range feed.Extensions["atom"]["link"] {
+		switch link.Attrs["rel"] {
+		case "hub":
+			if hub == "" {
+				hub = link.Attrs["href"]
+			}
+		case "self":
+			if topic == "" {
+				topic = link.Attrs["href"]
+			}
+		}
+	}
+	return hub, topic
+}
+
+// webSubscription 单个 Feed 在 hub 上的订阅
+type webSubscription struct {
+	hub    string
+	topic  string
+	secret string
+	// active hub 已完成订阅验证
+	active    bool
+	requested time.Time
+	expiresAt time.Time
+}
+
+// WebSubscriber 为声明了 WebSub hub 的 Feed 订阅推送，收到通知时立即更新，
+// 订阅失败或未声明 hub 的 Feed 仍按更新间隔轮询
+type WebSubscriber struct {
+	client *http.Client
+	// callbackBase 回调地址的前缀，回调为 <callbackBase>/websub/<feed>
+	callbackBase string
+	lease        time.Duration
+	subs         map[string]*webSubscription
+	mu           sync.Mutex
+	clock        clock.Clock
+}
+
+// NewWebSubscriber 创建 WebSub 订阅者，callbackBase 为 hub 可访问的服务地址，lease 为请求的订阅时长
+func NewWebSubscriber(callbackBase string, lease time.Duration) *WebSubscriber {
+	return &WebSubscriber{
+		client:       &http.Client{Timeout: webSubRequestTimeout},
+		callbackBase: strings.TrimSuffix(callbackBase, "/"),
+		lease:        lease,
+		subs:         make(map[string]*webSubscription),
+		clock:        clock.Real(),
+	}
+}
+
+// CallbackURL 返回 Feed 的回调地址
+func (w *WebSubscriber) CallbackURL(feedName string) string {
+	return w.callbackBase + "/websub/" + url.PathEscape(feedName)
+}
+
+// Discovered 更新时发现 hub 后调用，未订阅、hub 变化或订阅即将到期时向 hub 发送订阅请求
+func (w *WebSubscriber) Discovered(feed conf.Feed, hub, topic string) {
+	if topic == "" {
+		topic = feed.RssFeed
+	}
+	now := w.clock.Now()
+
+	w.mu.Lock()
+	if sub, ok := w.subs[feed.Name]; ok && sub.hub == hub && sub.topic == topic {
+		// 已订阅且剩余时间超过 1/10 订阅时长，或请求仍在等待验证
+		if sub.active && sub.expiresAt.Sub(now) > w.lease/10 {
+			w.mu.Unlock()
+			return
+		}
+		if !sub.active && now.Sub(sub.requested) < webSubRetryAfter {
+			w.mu.Unlock()
+			return
+		}
+	}
+	secret, err := randomSecret()
+	if err != nil {
+		w.mu.Unlock()
+		logger.Error("Failed to generate WebSub secret", err, "feed_name", feed.Name)
+		return
+	}
+	sub := &webSubscription{hub: hub, topic: topic, secret: secret, requested: now}
+	w.subs[feed.Name] = sub
+	w.mu.Unlock()
+
+	go func() {
+		if err := w.subscribe(context.Background(), feed.Name, sub); err != nil {
+			logger.Warn("WebSub subscription failed, polling only",
+				"feed_name", feed.Name,
+				"hub", hub,
+				"error", err,
+			)
+			w.mu.Lock()
+			if w.subs[feed.Name] == sub {
+				delete(w.subs, feed.Name)
+			}
+			w.mu.Unlock()
+		}
+	}()
+}
+
+// subscribe 向 hub 发送订阅请求，hub 随后通过 GET 回调验证
+func (w *WebSubscriber) subscribe(ctx context.Context, feedName string, sub *webSubscription) error {
+	form := url.Values{
+		"hub.mode":          {"subscribe"},
+		"hub.topic":         {sub.topic},
+		"hub.callback":      {w.CallbackURL(feedName)},
+		"hub.secret":        {sub.secret},
+		"hub.lease_seconds": {strconv.Itoa(int(w.lease.Seconds()))},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.hub, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create subscribe request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("subscribe request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("hub returned status %d", resp.StatusCode)
+	}
+	logger.Info("WebSub subscription requested",
+		"feed_name", feedName,
+		"hub", sub.hub,
+		"topic", sub.topic,
+	)
+	return nil
+}
+
+// Verify 处理 hub 的验证请求，topic 与订阅一致时返回 true，调用方应原样返回 challenge；
+// hub 拒绝订阅时删除订阅并返回 true
+func (w *WebSubscriber) Verify(feedName, mode, topic string, leaseSeconds int) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	sub, ok := w.subs[feedName]
+	if !ok || sub.topic != topic {
+		return false
+	}
+	switch mode {
+	case "subscribe":
+		lease := w.lease
+		if leaseSeconds > 0 {
+			lease = time.Duration(leaseSeconds) * time.Second
+		}
+		sub.active = true
+		sub.expiresAt = w.clock.Now().Add(lease)
+		logger.Info("WebSub subscription verified", "feed_name", feedName, "lease", lease)
+		return true
+	case "denied":
+		delete(w.subs, feedName)
+		logger.Warn("WebSub subscription denied by hub, polling only", "feed_name", feedName, "hub", sub.hub)
+		return true
+	default:
+		return false
+	}
+}
+
+// Subscribed 是否存在 Feed 的订阅（包括等待验证的订阅）
+func (w *WebSubscriber) Subscribed(feedName string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, ok := w.subs[feedName]
+	return ok
+}
+
+// Authenticate 校验通知的 X-Hub-Signature，签名缺失或不匹配时返回错误
+func (w *WebSubscriber) Authenticate(feedName string, body []byte, signature string) error {
+	w.mu.Lock()
+	sub, ok := w.subs[feedName]
+	w.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("no subscription for feed %s", feedName)
+	}
+
+	method, digest, ok := strings.Cut(signature, "=")
+	if !ok {
+		return fmt.Errorf("missing signature")
+	}
+	var newHash func() hash.Hash
+	switch method {
+	case "sha1":
+		newHash = sha1.New
+	case "sha256":
+		newHash = sha256.New
+	case "sha384":
+		newHash = sha512.New384
+	case "sha512":
+		newHash = sha512.New
+	default:
+		return fmt.Errorf("unsupported signature method %q", method)
+	}
+	want, err := hex.DecodeString(digest)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	mac := hmac.New(newHash, []byte(sub.secret))
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), want) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+// randomSecret 生成订阅使用的 HMAC 密钥
+func randomSecret() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,217 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.orx.me/apps/unifeed/internal/conf"
+)
+
+func loadConfig(t *testing.T, path string) *conf.Config {
+	t.Helper()
+	cfg, err := conf.LoadConfigFromFile(path)
+	if err != nil {
+		t.Fatalf("load %s: %v", path, err)
+	}
+	return cfg
+}
+
+func TestLoadConfigFromFile_YAMLAndJSON(t *testing.T) {
+	fromYAML := loadConfig(t, "testdata/config.yaml")
+	fromJSON := loadConfig(t, "testdata/config.json")
+
+	if !reflect.DeepEqual(fromYAML, fromJSON) {
+		t.Fatalf("expected identical configs\nyaml: %+v\njson: %+v", fromYAML, fromJSON)
+	}
+	if len(fromYAML.Feeds) != 3 || fromYAML.Feeds[2].Transforms[0].Pattern != `^\[AD\] ` {
+		t.Errorf("unexpected feeds: %+v", fromYAML.Feeds)
+	}
+	if fromYAML.Scheduler.UpdateInterval != 5*time.Minute || fromYAML.HTTP.RequestTimeout != 15*time.Second {
+		t.Errorf("expected durations to be decoded, got %+v %+v", fromYAML.Scheduler, fromYAML.HTTP)
+	}
+}
+
+func TestLoadConfigFromFile_UnknownExtension(t *testing.T) {
+	want := loadConfig(t, "testdata/config.yaml")
+	dir := t.TempDir()
+
+	// 未知扩展名先按 YAML 解析，失败后按 JSON 解析
+	for src, name := range map[string]string{"testdata/config.yaml": "unifeed.conf", "testdata/config.json": "unifeed.cfg"} {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			t.Fatalf("read %s: %v", src, err)
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+		if got := loadConfig(t, path); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected config equal to YAML fixture, got %+v", name, got)
+		}
+	}
+
+	path := filepath.Join(dir, "broken.conf")
+	if err := os.WriteFile(path, []byte("feeds: [\n"), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+	if _, err := conf.LoadConfigFromFile(path); err == nil {
+		t.Error("expected error for config that is neither YAML nor JSON")
+	}
+}
+
+func TestLoadConfigFromFile_EnvReferences(t *testing.T) {
+	t.Setenv("UNIFEED_TEST_S3_SECRET", "s3-secret-from-env")
+	t.Setenv("UNIFEED_TEST_AI_KEY", "ai-key-from-env")
+	t.Setenv("UNIFEED_TEST_MASTODON_TOKEN", "token-from-env")
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig := func(body string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+	}
+	const base = `
+feeds:
+  - name: home
+    mastodon:
+      host: https://mastodon.example.com
+      token: ${UNIFEED_TEST_MASTODON_TOKEN}
+  - name: sky
+    bluesky:
+      host: https://bsky.social
+      handle: alice.bsky.social
+      app_secret: literal-secret
+s3:
+  endpoint: s3.example.com
+  access_key_id: literal-access-key
+  secret_access_key: ${UNIFEED_TEST_S3_SECRET}
+  bucket_name: unifeed
+ai:
+  api_key: ${UNIFEED_TEST_AI_KEY}
+  model: prefix-${UNIFEED_TEST_AI_KEY}
+`
+	writeConfig(base)
+	cfg := loadConfig(t, path)
+	if cfg.S3.SecretAccessKey != "s3-secret-from-env" || cfg.AI.APIKey != "ai-key-from-env" || cfg.Feeds[0].Mastodon.Token != "token-from-env" {
+		t.Errorf("expected env references to be resolved, got s3=%q ai=%q token=%q", cfg.S3.SecretAccessKey, cfg.AI.APIKey, cfg.Feeds[0].Mastodon.Token)
+	}
+	// 字面值和非完整引用保持不变
+	if cfg.S3.AccessKeyID != "literal-access-key" || cfg.Feeds[1].Bluesky.AppSecret != "literal-secret" || cfg.AI.Model != "prefix-${UNIFEED_TEST_AI_KEY}" {
+		t.Errorf("expected literal values to be kept, got %+v %+v", cfg.S3, cfg.AI)
+	}
+
+	writeConfig(base + "storage:\n  profiles:\n    archive:\n      endpoint: s3.other.com\n      access_key_id: id\n      secret_access_key: ${UNIFEED_TEST_MISSING_SECRET}\n      bucket_name: archive\n")
+	_, err := conf.LoadConfigFromFile(path)
+	if err == nil || !strings.Contains(err.Error(), "UNIFEED_TEST_MISSING_SECRET") {
+		t.Fatalf("expected error naming the missing variable, got %v", err)
+	}
+}
+
+func TestLoadConfigFromURL(t *testing.T) {
+	want := loadConfig(t, "testdata/config.yaml")
+	data, err := os.ReadFile("testdata/config.yaml")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/unifeed.yaml":
+			w.Write(data)
+		case "/invalid.yaml":
+			w.Write([]byte("feeds: []\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	got, err := conf.LoadConfig(context.Background(), srv.URL+"/unifeed.yaml")
+	if err != nil {
+		t.Fatalf("load config from url: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected config equal to file fixture, got %+v", got)
+	}
+
+	if _, err := conf.LoadConfigFromURL(context.Background(), srv.URL+"/missing.yaml"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected status error, got %v", err)
+	}
+	if _, err := conf.LoadConfigFromURL(context.Background(), srv.URL+"/invalid.yaml"); err == nil || !strings.Contains(err.Error(), "no feeds") {
+		t.Errorf("expected validation error, got %v", err)
+	}
+}
+
+func TestConfigValidate_RaisesIntervalsToFloor(t *testing.T) {
+	cfg := conf.Config{
+		Feeds: []conf.Feed{
+			{Name: "fast", RssFeed: "https://example.com/fast.xml", UpdateInterval: time.Second},
+			{Name: "slow", RssFeed: "https://example.com/slow.xml", UpdateInterval: time.Hour},
+			{Name: "default", RssFeed: "https://example.com/default.xml"},
+		},
+		S3:        conf.S3Config{Endpoint: "s3", AccessKeyID: "id", SecretAccessKey: "secret", BucketName: "bucket"},
+		AI:        conf.AIConfig{Disabled: true},
+		Scheduler: conf.SchedulerConfig{UpdateInterval: time.Second, MinUpdateInterval: 2 * time.Minute},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if cfg.Scheduler.UpdateInterval != 2*time.Minute {
+		t.Errorf("expected the global 1s interval to be raised to the floor, got %s", cfg.Scheduler.UpdateInterval)
+	}
+	want := []time.Duration{2 * time.Minute, time.Hour, 0}
+	for i, feed := range cfg.Feeds {
+		if feed.UpdateInterval != want[i] {
+			t.Errorf("feed %s: expected update_interval %s, got %s", feed.Name, want[i], feed.UpdateInterval)
+		}
+	}
+
+	// 未配置下限时默认为 1 分钟
+	cfg.Scheduler = conf.SchedulerConfig{UpdateInterval: time.Second}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if cfg.Scheduler.UpdateInterval != time.Minute {
+		t.Errorf("expected the default floor of 1m, got %s", cfg.Scheduler.UpdateInterval)
+	}
+
+	cfg.Scheduler.MinUpdateInterval = -time.Second
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected a negative min_update_interval to be rejected")
+	}
+}
+
+func TestConfigApplyIntervalFloors_Unvalidated(t *testing.T) {
+	// 框架直接加载的配置不经过 Validate，下限仍需生效
+	cfg := conf.Config{
+		Feeds: []conf.Feed{
+			{Name: "fast", UpdateInterval: time.Second},
+			{Name: "default"},
+		},
+		Scheduler: conf.SchedulerConfig{UpdateInterval: 10 * time.Second},
+	}
+	cfg.ApplyIntervalFloors()
+	if cfg.Scheduler.UpdateInterval != time.Minute {
+		t.Errorf("expected the global 10s interval to be raised to 1m, got %s", cfg.Scheduler.UpdateInterval)
+	}
+	if cfg.Feeds[0].UpdateInterval != time.Minute {
+		t.Errorf("expected the feed 1s interval to be raised to 1m, got %s", cfg.Feeds[0].UpdateInterval)
+	}
+	if cfg.Feeds[1].UpdateInterval != 0 {
+		t.Errorf("expected an unset feed interval to stay unset, got %s", cfg.Feeds[1].UpdateInterval)
+	}
+
+	// 未设置全局间隔时保留调度器默认值
+	cfg = conf.Config{}
+	cfg.ApplyIntervalFloors()
+	if cfg.Scheduler.UpdateInterval != 0 {
+		t.Errorf("expected an unset global interval to stay unset, got %s", cfg.Scheduler.UpdateInterval)
+	}
+}
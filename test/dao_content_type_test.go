@@ -0,0 +1,28 @@
+package test
+
+import (
+	"testing"
+
+	"go.orx.me/apps/unifeed/internal/dao"
+)
+
+// pngHeader PNG 文件签名
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestDetectContentType(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"media/cover.png", pngHeader, "image/png"},
+		{"media/cover", pngHeader, "image/png"},
+		{"media/episode.mp3", []byte("ID3"), "audio/mpeg"},
+		{"feeds/blog/items/a.json", []byte(`{"title":"a"}`), "application/json"},
+	}
+	for _, c := range cases {
+		if got := dao.DetectContentType(c.name, c.data); got != c.want {
+			t.Errorf("%s: expected %s, got %s", c.name, c.want, got)
+		}
+	}
+}
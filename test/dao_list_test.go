@@ -0,0 +1,40 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.orx.me/apps/unifeed/internal/dao"
+)
+
+func TestListObjectsSorted_LastModifiedAscending(t *testing.T) {
+	store := newFakeStore()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for key, offset := range map[string]int{"p/c": 1, "p/a": 3, "p/b": 2, "p/d": 0} {
+		store.PutObject(context.Background(), key, []byte("{}"), dao.PutOptions{ContentType: "application/json"})
+		store.SetModTime(key, base.Add(time.Duration(offset)*time.Hour))
+	}
+
+	objects, err := dao.ListObjectsSorted(context.Background(), store, "p/", dao.ListOptions{SortBy: dao.SortByLastModified})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"p/d", "p/c", "p/b", "p/a"}
+	if len(objects) != len(want) {
+		t.Fatalf("expected %d objects, got %d", len(want), len(objects))
+	}
+	for i, obj := range objects {
+		if obj.Key != want[i] {
+			t.Errorf("position %d: got %s, want %s", i, obj.Key, want[i])
+		}
+	}
+
+	limited, err := dao.ListObjectsSorted(context.Background(), store, "p/", dao.ListOptions{SortBy: dao.SortByKey, Limit: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(limited) != 2 || limited[0].Key != "p/a" || limited[1].Key != "p/b" {
+		t.Errorf("unexpected limited result: %v", limited)
+	}
+}
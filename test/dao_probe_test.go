@@ -0,0 +1,39 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.orx.me/apps/unifeed/internal/dao"
+)
+
+func TestProbeWritable(t *testing.T) {
+	store := newFakeStore()
+	if err := dao.ProbeWritable(context.Background(), store); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	puts := store.Puts()
+	if len(puts) != 1 || !strings.HasPrefix(puts[0], dao.ProbePrefix) {
+		t.Fatalf("expected one probe object under %s, got %v", dao.ProbePrefix, puts)
+	}
+	removes := store.Removes()
+	if len(removes) != 1 || removes[0] != puts[0] {
+		t.Errorf("expected probe object %s to be removed, got %v", puts[0], removes)
+	}
+	if keys := store.Keys(dao.ProbePrefix); len(keys) != 0 {
+		t.Errorf("expected no leftover probe objects, got %v", keys)
+	}
+}
+
+func TestProbeWritable_Denied(t *testing.T) {
+	store := newFakeStore()
+	store.putErr = func(string) error { return errors.New("access denied") }
+
+	err := dao.ProbeWritable(context.Background(), store)
+	if err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Fatalf("expected access denied error, got %v", err)
+	}
+}
@@ -0,0 +1,427 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minio/minio-go/v7"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/sashabaranov/go-openai"
+	"go.orx.me/apps/unifeed/internal/conf"
+	"go.orx.me/apps/unifeed/internal/dao"
+	unifeedhttp "go.orx.me/apps/unifeed/internal/http"
+	"go.orx.me/apps/unifeed/internal/service"
+)
+
+// aiServer 模拟 OpenAI Chat Completion 接口，记录收到的请求
+type aiServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []openai.ChatCompletionRequest
+	// finish 返回回复的 finish_reason，为空时返回 stop
+	finish func(req openai.ChatCompletionRequest) openai.FinishReason
+}
+
+// newAIServer 创建模拟 AI 服务，reply 根据请求返回状态码与回复内容
+func newAIServer(t *testing.T, reply func(req openai.ChatCompletionRequest) (int, string)) *aiServer {
+	t.Helper()
+	s := &aiServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/models") {
+			// 健康检查使用的模型列表
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"object":"list","data":[]}`))
+			return
+		}
+		if !strings.HasSuffix(r.URL.Path, "/chat/completions") {
+			http.NotFound(w, r)
+			return
+		}
+		var req openai.ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.requests = append(s.requests, req)
+		s.mu.Unlock()
+
+		status, content := reply(req)
+		finish := openai.FinishReasonStop
+		if s.finish != nil {
+			finish = s.finish(req)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if status != http.StatusOK {
+			json.NewEncoder(w).Encode(map[string]any{
+				"error": map[string]any{"message": content, "type": "invalid_request_error"},
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+			ID:     "chatcmpl-test",
+			Object: "chat.completion",
+			Model:  req.Model,
+			Choices: []openai.ChatCompletionChoice{{
+				Message:      openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: content},
+				FinishReason: finish,
+			}},
+			Usage: openai.Usage{PromptTokens: 80, CompletionTokens: 20, TotalTokens: 100},
+		})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Requests 返回收到的请求副本
+func (s *aiServer) Requests() []openai.ChatCompletionRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]openai.ChatCompletionRequest(nil), s.requests...)
+}
+
+// fakeStore 内存实现的 dao.ObjectStore
+type fakeStore struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	modTimes map[string]time.Time
+	puts     []string
+	removes  []string
+	options  map[string]dao.PutOptions
+
+	// putErr 不为空时根据对象名返回写入错误
+	putErr func(objectName string) error
+	// listHook 不为空时在列举前调用，可用于模拟慢速或失败的存储
+	listHook func(ctx context.Context, prefix string) error
+	// getHook 不为空时在读取前调用
+	getHook func(objectName string) error
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		objects:  make(map[string][]byte),
+		modTimes: make(map[string]time.Time),
+		options:  make(map[string]dao.PutOptions),
+	}
+}
+
+func (f *fakeStore) PutObject(ctx context.Context, objectName string, data []byte, opts dao.PutOptions) error {
+	if f.putErr != nil {
+		if err := f.putErr(objectName); err != nil {
+			return err
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.objects[objectName] = append([]byte(nil), data...)
+	f.modTimes[objectName] = time.Now()
+	f.puts = append(f.puts, objectName)
+	f.options[objectName] = opts
+	return nil
+}
+
+// Options 返回写入对象时使用的选项
+func (f *fakeStore) Options(objectName string) (dao.PutOptions, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	opts, ok := f.options[objectName]
+	return opts, ok
+}
+
+func (f *fakeStore) GetObject(ctx context.Context, objectName string) (io.Reader, error) {
+	if f.getHook != nil {
+		if err := f.getHook(objectName); err != nil {
+			return nil, err
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, ok := f.objects[objectName]
+	if !ok {
+		return nil, fmt.Errorf("object not found: %s", objectName)
+	}
+	return bytes.NewReader(data), nil
+}
+
+func (f *fakeStore) ListObjects(ctx context.Context, prefix string) ([]minio.ObjectInfo, error) {
+	if f.listHook != nil {
+		if err := f.listHook(ctx, prefix); err != nil {
+			return nil, err
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var objects []minio.ObjectInfo
+	for key, data := range f.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, minio.ObjectInfo{Key: key, Size: int64(len(data)), LastModified: f.modTimes[key]})
+		}
+	}
+	return objects, nil
+}
+
+func (f *fakeStore) RemoveObject(ctx context.Context, objectName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.objects, objectName)
+	delete(f.modTimes, objectName)
+	f.removes = append(f.removes, objectName)
+	return nil
+}
+
+// SetModTime 设置对象的最后修改时间
+func (f *fakeStore) SetModTime(objectName string, t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.modTimes[objectName] = t
+}
+
+// Keys 返回指定前缀下的对象名
+func (f *fakeStore) Keys(prefix string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var keys []string
+	for key := range f.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Puts 返回所有写入过的对象名
+func (f *fakeStore) Puts() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.puts...)
+}
+
+// Removes 返回所有删除过的对象名
+func (f *fakeStore) Removes() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.removes...)
+}
+
+// feedServer 模拟上游 RSS 源，记录请求次数
+type feedServer struct {
+	*httptest.Server
+
+	mu   sync.Mutex
+	hits int
+	body string
+}
+
+// newFeedServer 创建返回固定 RSS 内容的模拟源
+func newFeedServer(t *testing.T, body string) *feedServer {
+	t.Helper()
+	s := &feedServer{body: body}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.hits++
+		body := s.body
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/rss+xml")
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Hits 返回收到的请求次数
+func (s *feedServer) Hits() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits
+}
+
+// SetBody 替换返回的 RSS 内容
+func (s *feedServer) SetBody(body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body = body
+}
+
+// rssItem 用于生成测试 RSS 的条目
+type rssItem struct {
+	GUID        string
+	Title       string
+	Link        string
+	Description string
+	Author      string
+	PubDate     time.Time
+}
+
+// rssXML 生成包含指定条目的 RSS 2.0 文档
+func rssXML(items ...rssItem) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><rss version="2.0"><channel><title>Test</title><link>https://example.com</link><description>Test feed</description>`)
+	for _, item := range items {
+		b.WriteString("<item>")
+		if item.GUID != "" {
+			fmt.Fprintf(&b, "<guid>%s</guid>", html.EscapeString(item.GUID))
+		}
+		fmt.Fprintf(&b, "<title>%s</title>", html.EscapeString(item.Title))
+		if item.Link != "" {
+			fmt.Fprintf(&b, "<link>%s</link>", html.EscapeString(item.Link))
+		}
+		fmt.Fprintf(&b, "<description>%s</description>", html.EscapeString(item.Description))
+		if item.Author != "" {
+			fmt.Fprintf(&b, "<author>%s</author>", html.EscapeString(item.Author))
+		}
+		if !item.PubDate.IsZero() {
+			fmt.Fprintf(&b, "<pubDate>%s</pubDate>", item.PubDate.Format(time.RFC1123Z))
+		}
+		b.WriteString("</item>")
+	}
+	b.WriteString("</channel></rss>")
+	return b.String()
+}
+
+// numberedItems 生成 n 个发布时间递增的条目
+func numberedItems(n int) []rssItem {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	items := make([]rssItem, n)
+	for i := range items {
+		items[i] = rssItem{
+			GUID:        fmt.Sprintf("item-%d", i),
+			Title:       fmt.Sprintf("Item %d", i),
+			Link:        fmt.Sprintf("https://example.com/%d", i),
+			Description: fmt.Sprintf("Content of item %d", i),
+			PubDate:     base.Add(time.Duration(i) * time.Hour),
+		}
+	}
+	return items
+}
+
+// okAIServer 创建总是返回固定摘要的模拟 AI 服务
+func okAIServer(t *testing.T) *aiServer {
+	return newAIServer(t, func(req openai.ChatCompletionRequest) (int, string) {
+		return http.StatusOK, "summary"
+	})
+}
+
+// newTestRssService 创建使用模拟依赖的 RSS 服务
+func newTestRssService(ai *aiServer, store *fakeStore) *service.RssService {
+	aiService := service.NewAIService(conf.AIConfig{Endpoint: ai.URL, APIKey: "key"})
+	aiService.SetMaxRetries(1)
+	return service.NewRssService(aiService, store, service.RssConfig{RetryDelay: time.Millisecond})
+}
+
+// readStoredItem 读取并解析存储的对象
+func readStoredItem(t *testing.T, store *fakeStore, key string, v any) {
+	t.Helper()
+	r, err := store.GetObject(context.Background(), key)
+	if err != nil {
+		t.Fatalf("read %s: %v", key, err)
+	}
+	if err := json.NewDecoder(r).Decode(v); err != nil {
+		t.Fatalf("decode %s: %v", key, err)
+	}
+}
+
+// withConfig 在测试期间替换全局配置
+func withConfig(t *testing.T, cfg conf.Config) {
+	t.Helper()
+	old := conf.Current()
+	conf.Replace(&cfg)
+	t.Cleanup(func() { conf.Replace(old) })
+}
+
+// newTestRouter 创建注册了所有路由的 gin 引擎
+func newTestRouter(rssService *service.RssService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	scheduler := service.NewSchedulerService(rssService, service.SchedulerConfig{RetryDelay: time.Millisecond})
+	unifeedhttp.NewHandler(rssService, scheduler).Router(r)
+	return r
+}
+
+// doRequest 执行请求并返回响应
+func doRequest(r http.Handler, method, target string, body io.Reader) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, body)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// doAdminRequest 携带管理令牌发送请求并返回响应
+func doAdminRequest(r http.Handler, method, target, token string, body io.Reader) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, body)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// mastodonServer 模拟 Mastodon API，记录时间线请求
+type mastodonServer struct {
+	*httptest.Server
+
+	mu    sync.Mutex
+	paths []string
+}
+
+// newMastodonServer 创建模拟 Mastodon 服务，statuses 根据请求返回状态列表
+func newMastodonServer(t *testing.T, statuses func(r *http.Request) []map[string]any) *mastodonServer {
+	t.Helper()
+	s := &mastodonServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.paths = append(s.paths, r.URL.Path)
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(statuses(r))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Paths 返回收到请求的路径
+func (s *mastodonServer) Paths() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.paths...)
+}
+
+// mastodonStatus 生成 Mastodon 状态 JSON
+func mastodonStatus(id, acct, content string) map[string]any {
+	return map[string]any{
+		"id":         id,
+		"url":        "https://mastodon.example/@" + acct + "/" + id,
+		"content":    content,
+		"created_at": "2024-01-01T00:00:00Z",
+		"account": map[string]any{
+			"id":           "acct-" + acct,
+			"acct":         acct,
+			"username":     acct,
+			"display_name": strings.ToUpper(acct),
+		},
+	}
+}
+
+// counterValue 读取计数器当前值
+func counterValue(t *testing.T, c prometheus.Counter) float64 {
+	t.Helper()
+	var m dto.Metric
+	if err := c.Write(&m); err != nil {
+		t.Fatalf("read counter: %v", err)
+	}
+	return m.GetCounter().GetValue()
+}
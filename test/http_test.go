@@ -0,0 +1,1560 @@
+package test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mmcdole/gofeed"
+	"go.orx.me/apps/unifeed/internal/conf"
+	"go.orx.me/apps/unifeed/internal/dao"
+	unifeedhttp "go.orx.me/apps/unifeed/internal/http"
+	"go.orx.me/apps/unifeed/internal/metrics"
+	"go.orx.me/apps/unifeed/internal/service"
+)
+
+func TestHandler_RequestTimeout(t *testing.T) {
+	withConfig(t, conf.Config{
+		Feeds: []conf.Feed{{Name: "slow", RssFeed: "https://example.com/feed.xml"}},
+		HTTP:  conf.HTTPConfig{RequestTimeout: 50 * time.Millisecond},
+	})
+
+	store := newFakeStore()
+	store.listHook = func(ctx context.Context, prefix string) error {
+		// 模拟一直不返回的存储，直到请求被取消
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	r := newTestRouter(newTestRssService(okAIServer(t), store))
+
+	start := time.Now()
+	w := doRequest(r, http.MethodGet, "/feeds/slow", nil)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d: %s", w.Code, w.Body.String())
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("request took too long: %v", elapsed)
+	}
+}
+
+func TestHandler_UpdateRefreshesSocialCache(t *testing.T) {
+	var mu sync.Mutex
+	version := 1
+	mastodon := newMastodonServer(t, func(r *http.Request) []map[string]any {
+		mu.Lock()
+		defer mu.Unlock()
+		return []map[string]any{mastodonStatus("1", "alice", fmt.Sprintf("version %d", version))}
+	})
+	withConfig(t, conf.Config{
+		Feeds: []conf.Feed{{Name: "social", Mastodon: conf.Mastodon{Host: mastodon.URL, Token: "token"}}},
+	})
+	r := newTestRouter(newTestRssService(okAIServer(t), newFakeStore()))
+
+	// 第二次请求命中缓存
+	for i := 0; i < 2; i++ {
+		w := doRequest(r, http.MethodGet, "/feeds/social", nil)
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "version 1") {
+			t.Fatalf("unexpected response %d: %s", w.Code, w.Body.String())
+		}
+	}
+	if got := len(mastodon.Paths()); got != 1 {
+		t.Fatalf("expected cached output to be reused, upstream hits: %d", got)
+	}
+
+	mu.Lock()
+	version = 2
+	mu.Unlock()
+
+	w := doRequest(r, http.MethodPost, "/feeds/social/update", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 from update, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := len(mastodon.Paths()); got != 2 {
+		t.Errorf("expected update to refetch upstream, hits: %d", got)
+	}
+
+	w = doRequest(r, http.MethodGet, "/feeds/social", nil)
+	if !strings.Contains(w.Body.String(), "version 2") {
+		t.Errorf("expected refreshed output, got %s", w.Body.String())
+	}
+	if got := len(mastodon.Paths()); got != 2 {
+		t.Errorf("expected refreshed output to be cached, hits: %d", got)
+	}
+}
+
+func TestHandler_JSONItemsFormat(t *testing.T) {
+	published := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	src := newFeedServer(t, rssXML(rssItem{
+		GUID:        "post-1",
+		Title:       "Hello",
+		Link:        "https://example.com/hello",
+		Description: "Hello body",
+		PubDate:     published,
+	}))
+	withConfig(t, conf.Config{Feeds: []conf.Feed{{Name: "blog", RssFeed: src.URL}}})
+
+	svc := newTestRssService(okAIServer(t), newFakeStore())
+	if err := svc.UpdateFeed(context.Background(), conf.Current().Feeds[0]); err != nil {
+		t.Fatalf("update feed: %v", err)
+	}
+	r := newTestRouter(svc)
+
+	// 先请求旧格式，确认不会影响结构化输出
+	if w := doRequest(r, http.MethodGet, "/feeds/blog", nil); w.Code != http.StatusOK {
+		t.Fatalf("legacy format: %d %s", w.Code, w.Body.String())
+	}
+
+	w := doRequest(r, http.MethodGet, "/feeds/blog?format=json-items", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var items []map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(items))
+	}
+	want := map[string]any{
+		"title":       "Hello",
+		"link":        "https://example.com/hello",
+		"description": "Hello body",
+		"published":   published.Format(time.RFC3339),
+		"summary":     "summary",
+		"guid":        "post-1",
+	}
+	for k, v := range want {
+		if items[0][k] != v {
+			t.Errorf("field %s: got %v, want %v", k, items[0][k], v)
+		}
+	}
+	for _, legacy := range []string{"custom", "publishedParsed", "itunesExt"} {
+		if _, ok := items[0][legacy]; ok {
+			t.Errorf("unexpected legacy field %s in typed output", legacy)
+		}
+	}
+}
+
+func TestHandler_AtomFormat(t *testing.T) {
+	published := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	src := newFeedServer(t, rssXML(
+		rssItem{GUID: "post-1", Title: "Dated", Link: "https://example.com/dated", Description: "Dated body", PubDate: published},
+		rssItem{GUID: "post-2", Title: "Undated", Link: "https://example.com/undated", Description: "Undated body"},
+	))
+	withConfig(t, conf.Config{Feeds: []conf.Feed{{Name: "blog", RssFeed: src.URL}}})
+
+	svc := newTestRssService(okAIServer(t), newFakeStore())
+	if err := svc.UpdateFeed(context.Background(), conf.Current().Feeds[0]); err != nil {
+		t.Fatalf("update feed: %v", err)
+	}
+	r := newTestRouter(svc)
+
+	w := doRequest(r, http.MethodGet, "/feeds/blog?format=atom", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/atom+xml") {
+		t.Errorf("unexpected content type %q", ct)
+	}
+
+	var feed service.AtomFeed
+	if err := xml.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("decode atom: %v", err)
+	}
+	// Atom 1.0 要求 feed 和 entry 都有 id、title 和 updated
+	if feed.XMLName.Space != "http://www.w3.org/2005/Atom" {
+		t.Errorf("unexpected namespace %q", feed.XMLName.Space)
+	}
+	if feed.ID == "" || feed.Title == "" {
+		t.Errorf("feed missing id or title: %+v", feed)
+	}
+	if _, err := time.Parse(time.RFC3339, feed.Updated); err != nil {
+		t.Errorf("feed updated %q is not RFC 3339: %v", feed.Updated, err)
+	}
+	if len(feed.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(feed.Entries))
+	}
+	ids := make(map[string]bool)
+	for _, entry := range feed.Entries {
+		if entry.ID == "" || entry.Title == "" {
+			t.Errorf("entry missing id or title: %+v", entry)
+		}
+		ids[entry.ID] = true
+		if _, err := time.Parse(time.RFC3339, entry.Updated); err != nil {
+			t.Errorf("entry %s updated %q is not RFC 3339: %v", entry.Title, entry.Updated, err)
+		}
+		if entry.Title == "Dated" && entry.Updated != published.Format(time.RFC3339) {
+			t.Errorf("expected updated from pubDate, got %s", entry.Updated)
+		}
+		if entry.Content == nil || entry.Content.Type != "html" || !strings.Contains(entry.Content.Body, "body") {
+			t.Errorf("unexpected content for %s: %+v", entry.Title, entry.Content)
+		}
+	}
+	if len(ids) != 2 {
+		t.Errorf("expected distinct entry ids, got %v", ids)
+	}
+
+	// 其他解析器也能识别为 Atom
+	parsed, err := gofeed.NewParser().ParseString(w.Body.String())
+	if err != nil {
+		t.Fatalf("parse atom: %v", err)
+	}
+	if parsed.FeedType != "atom" || len(parsed.Items) != 2 {
+		t.Errorf("unexpected parsed feed: type=%s items=%d", parsed.FeedType, len(parsed.Items))
+	}
+}
+
+func TestHandler_SocialAtomFormat(t *testing.T) {
+	mastodon := newMastodonServer(t, func(r *http.Request) []map[string]any {
+		return []map[string]any{mastodonStatus("1", "alice", "hello")}
+	})
+	withConfig(t, conf.Config{
+		Feeds: []conf.Feed{{Name: "social", Mastodon: conf.Mastodon{Host: mastodon.URL, Token: "token"}}},
+	})
+	r := newTestRouter(newTestRssService(okAIServer(t), newFakeStore()))
+
+	// RSS 和 Atom 分开缓存
+	rss := doRequest(r, http.MethodGet, "/feeds/social", nil)
+	atom := doRequest(r, http.MethodGet, "/feeds/social?format=atom", nil)
+	if rss.Code != http.StatusOK || !strings.Contains(rss.Body.String(), "<rss") {
+		t.Fatalf("unexpected rss response %d: %s", rss.Code, rss.Body.String())
+	}
+	if atom.Code != http.StatusOK || !strings.Contains(atom.Body.String(), `<feed xmlns="http://www.w3.org/2005/Atom">`) {
+		t.Fatalf("unexpected atom response %d: %s", atom.Code, atom.Body.String())
+	}
+	if !strings.Contains(atom.Body.String(), "hello") {
+		t.Errorf("expected status content in atom output: %s", atom.Body.String())
+	}
+
+	if w := doRequest(r, http.MethodGet, "/feeds/social?format=csv", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unsupported format, got %d", w.Code)
+	}
+}
+
+func TestHandler_GroupMergesMemberFeeds(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	tech := newFeedServer(t, rssXML(
+		rssItem{GUID: "t1", Title: "Tech 1", PubDate: day(1)},
+		rssItem{GUID: "t3", Title: "Tech 3", PubDate: day(3)},
+	))
+	news := newFeedServer(t, rssXML(rssItem{GUID: "n2", Title: "News 2", PubDate: day(2)}))
+	other := newFeedServer(t, rssXML(rssItem{GUID: "o1", Title: "Other", PubDate: day(4)}))
+	withConfig(t, conf.Config{Feeds: []conf.Feed{
+		{Name: "tech", RssFeed: tech.URL, Groups: []string{"reading"}},
+		{Name: "news", RssFeed: news.URL, Groups: []string{"reading", "daily"}},
+		{Name: "other", RssFeed: other.URL},
+	}})
+
+	svc := newTestRssService(okAIServer(t), newFakeStore())
+	for _, feed := range conf.Current().Feeds {
+		if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+			t.Fatalf("update %s: %v", feed.Name, err)
+		}
+	}
+	r := newTestRouter(svc)
+
+	w := doRequest(r, http.MethodGet, "/groups/reading", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var items []map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	want := []struct{ title, feed string }{{"Tech 3", "tech"}, {"News 2", "news"}, {"Tech 1", "tech"}}
+	if len(items) != len(want) {
+		t.Fatalf("expected %d items, got %d", len(want), len(items))
+	}
+	for i, w := range want {
+		if items[i]["title"] != w.title || items[i]["feed"] != w.feed {
+			t.Errorf("item %d: got %v/%v, want %s/%s", i, items[i]["title"], items[i]["feed"], w.title, w.feed)
+		}
+	}
+
+	if w := doRequest(r, http.MethodGet, "/groups/missing", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown group, got %d", w.Code)
+	}
+}
+
+func TestHandler_RawUpstream(t *testing.T) {
+	body := rssXML(numberedItems(2)...) + "\n<!-- trailing bytes kept verbatim -->"
+	src := newFeedServer(t, body)
+	withConfig(t, conf.Config{
+		Feeds: []conf.Feed{{Name: "blog", RssFeed: src.URL}},
+		HTTP:  conf.HTTPConfig{AdminToken: "secret", RawMaxBytes: 1 << 20},
+	})
+	r := newTestRouter(newTestRssService(okAIServer(t), newFakeStore()))
+
+	rawRequest := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/feeds/blog/raw", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := rawRequest(""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without token, got %d", w.Code)
+	}
+	if w := rawRequest("wrong"); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 with wrong token, got %d", w.Code)
+	}
+
+	for i := 0; i < 2; i++ {
+		w := rawRequest("secret")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if w.Body.String() != body {
+			t.Errorf("expected raw upstream body verbatim, got %s", w.Body.String())
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/rss+xml" {
+			t.Errorf("expected upstream content type, got %q", ct)
+		}
+	}
+	// 每次请求都绕过缓存访问上游
+	if got := src.Hits(); got != 2 {
+		t.Errorf("expected 2 upstream hits, got %d", got)
+	}
+}
+
+func TestHandler_RawUpstreamBlueskySession(t *testing.T) {
+	var mu sync.Mutex
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/xrpc/com.atproto.server.createSession":
+			json.NewEncoder(w).Encode(map[string]string{
+				"accessJwt": "session-access", "refreshJwt": "session-refresh",
+				"handle": "alice.bsky.social", "did": "did:plc:ewvi7nxzyoun6zhxrhs64oiz",
+			})
+		case "/xrpc/app.bsky.feed.getTimeline":
+			mu.Lock()
+			auth = r.Header.Get("Authorization")
+			mu.Unlock()
+			w.Write([]byte(`{"feed":[]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	withConfig(t, conf.Config{
+		Feeds: []conf.Feed{{Name: "sky", Bluesky: conf.Bluesky{
+			Host: srv.URL, Handle: "alice.bsky.social", AppSecret: "app-password",
+		}}},
+		HTTP: conf.HTTPConfig{AdminToken: "secret", RawMaxBytes: 1 << 20},
+	})
+	r := newTestRouter(newTestRssService(okAIServer(t), newFakeStore()))
+
+	req := httptest.NewRequest(http.MethodGet, "/feeds/sky/raw", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	// 只配置 AppSecret 的 Feed 使用登录获得的访问令牌
+	mu.Lock()
+	defer mu.Unlock()
+	if auth != "Bearer session-access" {
+		t.Errorf("expected the session access token upstream, got %q", auth)
+	}
+}
+
+func TestHandler_RawUpstreamSizeCap(t *testing.T) {
+	src := newFeedServer(t, strings.Repeat("x", 100))
+	withConfig(t, conf.Config{
+		Feeds: []conf.Feed{{Name: "blog", RssFeed: src.URL}},
+		HTTP:  conf.HTTPConfig{AdminToken: "secret", RawMaxBytes: 10},
+	})
+	r := newTestRouter(newTestRssService(okAIServer(t), newFakeStore()))
+
+	req := httptest.NewRequest(http.MethodGet, "/feeds/blog/raw", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Body.Len() != 10 || w.Header().Get("X-Truncated") != "true" {
+		t.Errorf("expected body capped at 10 bytes with truncation header, got %d bytes, header %q",
+			w.Body.Len(), w.Header().Get("X-Truncated"))
+	}
+}
+
+func TestHandler_StatusReport(t *testing.T) {
+	src := newFeedServer(t, rssXML(numberedItems(1)...))
+	feed := conf.Feed{Name: "blog", RssFeed: src.URL}
+	withConfig(t, conf.Config{Feeds: []conf.Feed{feed}})
+
+	store := newFakeStore()
+	rssService := newTestRssService(okAIServer(t), store)
+	scheduler := service.NewSchedulerService(rssService, service.SchedulerConfig{UpdateInterval: time.Hour, MaxRetries: 1})
+	events := scheduler.Events()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := scheduler.StartJob(ctx, feed); err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+	defer scheduler.StopAllJobs()
+	expectEvent(t, events, service.EventStarted)
+	expectEvent(t, events, service.EventSucceeded)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	unifeedhttp.NewHandler(rssService, scheduler).Router(r)
+
+	var report struct {
+		Status  string `json:"status"`
+		Storage struct {
+			Status string `json:"status"`
+			Error  string `json:"error"`
+		} `json:"storage"`
+		AI struct {
+			Status string `json:"status"`
+		} `json:"ai"`
+		Scheduler struct {
+			Jobs    int `json:"jobs"`
+			Failing int `json:"failing"`
+		} `json:"scheduler"`
+		Cache struct {
+			HitRatio *float64 `json:"hit_ratio"`
+		} `json:"cache"`
+		Goroutines int `json:"goroutines"`
+	}
+	w := doRequest(r, http.MethodGet, "/status", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decode status: %v", err)
+	}
+	if report.Status != "ok" || report.Storage.Status != "ok" || report.AI.Status != "ok" {
+		t.Errorf("expected healthy subsystems, got %s", w.Body.String())
+	}
+	if report.Scheduler.Jobs != 1 || report.Scheduler.Failing != 0 {
+		t.Errorf("expected 1 healthy job, got %+v", report.Scheduler)
+	}
+	if report.Cache.HitRatio == nil || report.Goroutines <= 0 {
+		t.Errorf("expected cache hit ratio and goroutine count, got %s", w.Body.String())
+	}
+
+	// 存储不可用时整体降级
+	store.listHook = func(ctx context.Context, prefix string) error {
+		return fmt.Errorf("bucket unreachable")
+	}
+	w = doRequest(r, http.MethodGet, "/status", nil)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 when storage fails, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decode status: %v", err)
+	}
+	// 错误详情可能包含存储端点，只写入日志
+	if report.Status != "degraded" || report.Storage.Status != "error" || report.Storage.Error != "check failed" {
+		t.Errorf("expected a generic storage error in report, got %s", w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "bucket unreachable") {
+		t.Errorf("expected the storage error detail to be hidden, got %s", w.Body.String())
+	}
+}
+
+func TestHandler_StatusReportAIDisabled(t *testing.T) {
+	withConfig(t, conf.Config{})
+	rssService := service.NewRssService(service.NewAIService(conf.AIConfig{Disabled: true}), newFakeStore(), service.RssConfig{})
+	r := newTestRouter(rssService)
+
+	w := doRequest(r, http.MethodGet, "/status", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"ai":{"status":"disabled"}`) {
+		t.Errorf("expected AI to be reported as disabled, got %s", w.Body.String())
+	}
+}
+
+func TestHandler_HealthProbes(t *testing.T) {
+	withConfig(t, conf.Config{})
+	store := newFakeStore()
+	r := newTestRouter(service.NewRssService(service.NewAIService(conf.AIConfig{Disabled: true}), store, service.RssConfig{}))
+
+	for _, path := range []string{"/healthz", "/readyz"} {
+		if w := doRequest(r, http.MethodGet, path, nil); w.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d: %s", path, w.Code, w.Body.String())
+		}
+	}
+
+	// S3 不可用时就绪探针失败，存活探针不受影响
+	store.listHook = func(context.Context, string) error { return fmt.Errorf("connection refused") }
+	if w := doRequest(r, http.MethodGet, "/healthz", nil); w.Code != http.StatusOK {
+		t.Errorf("expected /healthz to stay 200, got %d", w.Code)
+	}
+	w := doRequest(r, http.MethodGet, "/readyz", nil)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Status    string            `json:"status"`
+		Unhealthy map[string]string `json:"unhealthy"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if body.Status != "unavailable" || body.Unhealthy["storage"] != "check failed" || len(body.Unhealthy) != 1 {
+		t.Errorf("expected only storage to be unhealthy, got %+v", body)
+	}
+}
+
+func TestHandler_ReadyzChecksAI(t *testing.T) {
+	withConfig(t, conf.Config{HTTP: conf.HTTPConfig{ReadyCheckAI: true}})
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	aiService := service.NewAIService(conf.AIConfig{Endpoint: down.URL, APIKey: "key"})
+	r := newTestRouter(service.NewRssService(aiService, newFakeStore(), service.RssConfig{}))
+
+	w := doRequest(r, http.MethodGet, "/readyz", nil)
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), `"ai"`) {
+		t.Errorf("expected 503 listing the AI endpoint, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandler_JobsFilterByStatus(t *testing.T) {
+	good := newFeedServer(t, rssXML(numberedItems(1)...))
+	broken := newFeedServer(t, "not a feed")
+	feeds := []conf.Feed{{Name: "good", RssFeed: good.URL}, {Name: "broken", RssFeed: broken.URL}}
+	withConfig(t, conf.Config{Feeds: feeds})
+
+	rssService := newTestRssService(okAIServer(t), newFakeStore())
+	scheduler := service.NewSchedulerService(rssService, service.SchedulerConfig{
+		UpdateInterval: time.Hour,
+		MaxRetries:     1,
+		RetryDelay:     time.Millisecond,
+	})
+	events := scheduler.Events()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for _, feed := range feeds {
+		if err := scheduler.StartJob(ctx, feed); err != nil {
+			t.Fatalf("start job: %v", err)
+		}
+	}
+	defer scheduler.StopAllJobs()
+
+	// 等待两个任务各完成一次周期
+	for done := 0; done < 2; {
+		select {
+		case ev := <-events:
+			if ev.Type != service.EventStarted {
+				done++
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for update cycles")
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	unifeedhttp.NewHandler(rssService, scheduler).Router(r)
+
+	type jobStatus struct {
+		Name     string `json:"name"`
+		Status   string `json:"status"`
+		Failures int    `json:"failures"`
+		Error    string `json:"error"`
+	}
+	list := func(target string) []jobStatus {
+		t.Helper()
+		w := doRequest(r, http.MethodGet, target, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", target, w.Code, w.Body.String())
+		}
+		var jobs []jobStatus
+		if err := json.Unmarshal(w.Body.Bytes(), &jobs); err != nil {
+			t.Fatalf("%s: decode: %v", target, err)
+		}
+		return jobs
+	}
+
+	failing := list("/jobs?status=failing")
+	if len(failing) != 1 || failing[0].Name != "broken" || failing[0].Failures != 1 || failing[0].Error != "update failed" {
+		t.Errorf("expected only the broken job with a generic error, got %+v", failing)
+	}
+	// 单个任务的状态同样不返回上游错误详情
+	var status struct {
+		Error string `json:"error"`
+	}
+	w := doRequest(r, http.MethodGet, "/feeds/broken/status", nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil || status.Error != "update failed" {
+		t.Errorf("expected a generic job error, got %d: %s", w.Code, w.Body.String())
+	}
+	if healthy := list("/jobs?status=healthy"); len(healthy) != 1 || healthy[0].Name != "good" {
+		t.Errorf("expected only the good job, got %+v", healthy)
+	}
+	if stale := list("/jobs?status=stale"); len(stale) != 0 {
+		t.Errorf("expected no stale jobs, got %+v", stale)
+	}
+	if all := list("/jobs"); len(all) != 2 || all[0].Name != "broken" || all[1].Name != "good" {
+		t.Errorf("expected all jobs sorted by name, got %+v", all)
+	}
+	if w := doRequest(r, http.MethodGet, "/jobs?status=unknown", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown status, got %d", w.Code)
+	}
+}
+
+func TestHandler_ItemPagination(t *testing.T) {
+	src := newFeedServer(t, rssXML(numberedItems(5)...))
+	withConfig(t, conf.Config{Feeds: []conf.Feed{{Name: "blog", RssFeed: src.URL}}})
+
+	svc := newTestRssService(okAIServer(t), newFakeStore())
+	if err := svc.UpdateFeed(context.Background(), conf.Current().Feeds[0]); err != nil {
+		t.Fatalf("update feed: %v", err)
+	}
+	r := newTestRouter(svc)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"Item 4", "Item 3", "Item 2", "Item 1", "Item 0"}},
+		{"limit=2", []string{"Item 4", "Item 3"}},
+		{"limit=2&offset=2", []string{"Item 2", "Item 1"}},
+		{"limit=10&offset=3", []string{"Item 1", "Item 0"}},
+		{"offset=4", []string{"Item 0"}},
+		{"offset=5", []string{}},
+		{"limit=1&offset=100", []string{}},
+	}
+	for _, format := range []string{"", "&format=json-items"} {
+		for _, tt := range tests {
+			target := "/feeds/blog?" + tt.query + format
+			w := doRequest(r, http.MethodGet, target, nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("%s: expected 200, got %d: %s", target, w.Code, w.Body.String())
+			}
+			if got := w.Header().Get("X-Total-Count"); got != "5" {
+				t.Errorf("%s: expected X-Total-Count 5, got %q", target, got)
+			}
+			var items []map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+				t.Fatalf("%s: decode response: %v", target, err)
+			}
+			titles := make([]string, 0, len(items))
+			for _, item := range items {
+				titles = append(titles, fmt.Sprint(item["title"]))
+			}
+			if strings.Join(titles, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("%s: expected %v, got %v", target, tt.want, titles)
+			}
+		}
+	}
+
+	for _, target := range []string{
+		"/feeds/blog?limit=0",
+		"/feeds/blog?limit=-1",
+		"/feeds/blog?limit=abc",
+		"/feeds/blog?offset=-1",
+		"/feeds/blog?limit=2&format=rss",
+	} {
+		if w := doRequest(r, http.MethodGet, target, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", target, w.Code)
+		}
+	}
+}
+
+func TestHandler_FieldProjection(t *testing.T) {
+	published := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	src := newFeedServer(t, rssXML(rssItem{
+		GUID:        "post-1",
+		Title:       "Hello",
+		Link:        "https://example.com/hello",
+		Description: "Hello body",
+		PubDate:     published,
+	}))
+	withConfig(t, conf.Config{Feeds: []conf.Feed{{Name: "blog", RssFeed: src.URL}}})
+
+	svc := newTestRssService(okAIServer(t), newFakeStore())
+	if err := svc.UpdateFeed(context.Background(), conf.Current().Feeds[0]); err != nil {
+		t.Fatalf("update feed: %v", err)
+	}
+	r := newTestRouter(svc)
+
+	for _, target := range []string{
+		"/feeds/blog?fields=title,link,published&format=json-items",
+		"/feeds/blog?fields=title,link,published",
+	} {
+		w := doRequest(r, http.MethodGet, target, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", target, w.Code, w.Body.String())
+		}
+		var items []map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+			t.Fatalf("%s: decode response: %v", target, err)
+		}
+		if len(items) != 1 {
+			t.Fatalf("%s: expected 1 item, got %d", target, len(items))
+		}
+		if len(items[0]) != 3 || items[0]["title"] != "Hello" || items[0]["link"] != "https://example.com/hello" || items[0]["published"] == nil {
+			t.Errorf("%s: expected only title, link and published, got %v", target, items[0])
+		}
+	}
+
+	for _, target := range []string{
+		"/feeds/blog?fields=title,secret",
+		"/feeds/blog?fields=title&format=rss",
+	} {
+		if w := doRequest(r, http.MethodGet, target, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", target, w.Code)
+		}
+	}
+}
+
+func TestHandler_ConfigReplaceDuringRequests(t *testing.T) {
+	src := newFeedServer(t, rssXML(numberedItems(2)...))
+	feeds := func(title string) conf.Config {
+		return conf.Config{Feeds: []conf.Feed{{Name: "blog", Title: title, RssFeed: src.URL, Groups: []string{"tech"}}}}
+	}
+	withConfig(t, feeds("first"))
+
+	svc := newTestRssService(okAIServer(t), newFakeStore())
+	if err := svc.UpdateFeed(context.Background(), conf.Current().Feeds[0]); err != nil {
+		t.Fatalf("update feed: %v", err)
+	}
+	r := newTestRouter(svc)
+
+	// 请求处理期间不断替换配置，-race 下不应报告数据竞争
+	done := make(chan struct{})
+	var reloads sync.WaitGroup
+	reloads.Add(1)
+	go func() {
+		defer reloads.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			cfg := feeds(fmt.Sprintf("reload-%d", i))
+			conf.Replace(&cfg)
+		}
+	}()
+
+	var requests sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		requests.Add(1)
+		go func() {
+			defer requests.Done()
+			for j := 0; j < 20; j++ {
+				for _, target := range []string{"/feeds/blog", "/groups/tech"} {
+					if w := doRequest(r, http.MethodGet, target, nil); w.Code != http.StatusOK {
+						t.Errorf("%s: expected 200, got %d: %s", target, w.Code, w.Body.String())
+						return
+					}
+				}
+			}
+		}()
+	}
+	requests.Wait()
+	close(done)
+	reloads.Wait()
+}
+
+func TestHandler_ReplayDeadLetters(t *testing.T) {
+	withConfig(t, conf.Config{
+		Feeds: []conf.Feed{{Name: "blog", RssFeed: "https://example.com/feed.xml"}},
+		HTTP:  conf.HTTPConfig{AdminToken: "secret"},
+	})
+	store := newFakeStore()
+	seed := func(key string, letter service.DeadLetter) {
+		t.Helper()
+		data, err := json.Marshal(letter)
+		if err != nil {
+			t.Fatalf("marshal dead letter: %v", err)
+		}
+		if err := store.PutObject(context.Background(), key, data, dao.PutOptions{}); err != nil {
+			t.Fatalf("seed dead letter: %v", err)
+		}
+	}
+	seed("deadletter/blog/stored.json", service.DeadLetter{
+		Feed:   "blog",
+		Stage:  service.DeadLetterStore,
+		Reason: "failed to store item in S3: timeout",
+		Item: &gofeed.Item{
+			GUID:        "stored",
+			Title:       "Stored",
+			Description: "Stored body",
+			Custom:      map[string]string{"summary": "kept summary"},
+		},
+	})
+	seed("deadletter/blog/unsummarized.json", service.DeadLetter{
+		Feed:   "blog",
+		Stage:  service.DeadLetterSummarize,
+		Reason: "summary generation failed",
+		Item: &gofeed.Item{
+			GUID:        "unsummarized",
+			Title:       "Unsummarized",
+			Description: "Unsummarized body",
+			Custom:      map[string]string{"summary_pending": "true"},
+		},
+	})
+
+	r := newTestRouter(newTestRssService(okAIServer(t), store))
+	// 重放需要管理令牌
+	if w := doRequest(r, http.MethodPost, "/feeds/blog/replay-deadletter", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without admin token, got %d", w.Code)
+	}
+	if keys := store.Keys("deadletter/"); len(keys) != 2 {
+		t.Errorf("expected dead letters untouched without admin token, got %v", keys)
+	}
+
+	w := doAdminRequest(r, http.MethodPost, "/feeds/blog/replay-deadletter", "secret", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var result service.DeadLetterReplay
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if result != (service.DeadLetterReplay{Total: 2, Replayed: 2}) {
+		t.Errorf("unexpected replay counts: %+v", result)
+	}
+
+	if keys := store.Keys("deadletter/"); len(keys) != 0 {
+		t.Errorf("expected replayed dead letters to be removed, got %v", keys)
+	}
+	for key, summary := range map[string]string{
+		"feeds/blog/items/stored.json":       "kept summary",
+		"feeds/blog/items/unsummarized.json": "summary",
+	} {
+		var item gofeed.Item
+		readStoredItem(t, store, key, &item)
+		if item.Custom["summary"] != summary || item.Custom["summary_pending"] != "" {
+			t.Errorf("%s: expected summary %q without pending flag, got %v", key, summary, item.Custom)
+		}
+	}
+
+	if w := doAdminRequest(r, http.MethodPost, "/feeds/missing/replay-deadletter", "secret", nil); w.Code != http.StatusNotFound {
+		t.Errorf("unknown feed: expected 404, got %d", w.Code)
+	}
+}
+
+func TestHandler_FeedSelfLink(t *testing.T) {
+	src := newFeedServer(t, rssXML(rssItem{GUID: "post-1", Title: "Hello", Link: "https://example.com/hello", Description: "Hello body"}))
+	mastodon := newMastodonServer(t, func(r *http.Request) []map[string]any {
+		return []map[string]any{mastodonStatus("1", "alice", "hello")}
+	})
+	withConfig(t, conf.Config{Feeds: []conf.Feed{
+		{Name: "blog", RssFeed: src.URL, Link: "https://blog.example.com/"},
+		{Name: "social", Mastodon: conf.Mastodon{Host: mastodon.URL, Token: "token"}},
+	}})
+
+	svc := newTestRssService(okAIServer(t), newFakeStore())
+	if err := svc.UpdateFeed(context.Background(), conf.Current().Feeds[0]); err != nil {
+		t.Fatalf("update feed: %v", err)
+	}
+	r := newTestRouter(svc)
+
+	rss := doRequest(r, http.MethodGet, "/feeds/blog?format=rss", nil)
+	if rss.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rss.Code, rss.Body.String())
+	}
+	for _, want := range []string{
+		`xmlns:atom="http://www.w3.org/2005/Atom"`,
+		`<atom:link href="http://example.com/feeds/blog?format=rss" rel="self" type="application/rss+xml"></atom:link>`,
+		`<link>https://blog.example.com/</link>`,
+	} {
+		if !strings.Contains(rss.Body.String(), want) {
+			t.Errorf("expected %s in rss output: %s", want, rss.Body.String())
+		}
+	}
+	var parsed struct {
+		Channel struct {
+			Links []struct {
+				Href string `xml:"href,attr"`
+				Rel  string `xml:"rel,attr"`
+			} `xml:"http://www.w3.org/2005/Atom link"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(rss.Body.Bytes(), &parsed); err != nil {
+		t.Fatalf("parse rss: %v", err)
+	}
+	if len(parsed.Channel.Links) != 1 || parsed.Channel.Links[0].Rel != "self" {
+		t.Errorf("expected a namespaced self link, got %+v", parsed.Channel.Links)
+	}
+
+	atom := doRequest(r, http.MethodGet, "/feeds/blog?format=atom", nil)
+	if want := `<link href="http://example.com/feeds/blog?format=atom" rel="self" type="application/atom+xml"></link>`; !strings.Contains(atom.Body.String(), want) {
+		t.Errorf("expected %s in atom output: %s", want, atom.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/feeds/social", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	social := httptest.NewRecorder()
+	r.ServeHTTP(social, req)
+	if want := `<atom:link href="https://example.com/feeds/social" rel="self"`; !strings.Contains(social.Body.String(), want) {
+		t.Errorf("expected %s in social output: %s", want, social.Body.String())
+	}
+}
+
+func TestHandler_WebSubNotificationTriggersUpdate(t *testing.T) {
+	subscriptions := make(chan url.Values, 1)
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		subscriptions <- r.PostForm
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer hub.Close()
+
+	// 声明 hub 和 self 链接的 RSS
+	const topic = "https://example.com/feed.xml"
+	withHub := func(body string) string {
+		return strings.Replace(body, `<rss version="2.0"><channel>`,
+			`<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom"><channel>`+
+				`<atom:link rel="hub" href="`+hub.URL+`"/><atom:link rel="self" href="`+topic+`"/>`, 1)
+	}
+	src := newFeedServer(t, withHub(rssXML(numberedItems(1)...)))
+	withConfig(t, conf.Config{
+		Feeds:  []conf.Feed{{Name: "blog", RssFeed: src.URL}},
+		HTTP:   conf.HTTPConfig{BaseURL: "https://unifeed.example"},
+		WebSub: conf.WebSubConfig{Enabled: true},
+	})
+
+	store := newFakeStore()
+	svc := newTestRssService(okAIServer(t), store)
+	r := newTestRouter(svc)
+	if err := svc.UpdateFeed(context.Background(), conf.Current().Feeds[0]); err != nil {
+		t.Fatalf("update feed: %v", err)
+	}
+
+	var form url.Values
+	select {
+	case form = <-subscriptions:
+	case <-time.After(time.Second):
+		t.Fatal("expected a subscription request to the discovered hub")
+	}
+	if form.Get("hub.mode") != "subscribe" || form.Get("hub.topic") != topic || form.Get("hub.callback") != "https://unifeed.example/websub/blog" {
+		t.Fatalf("unexpected subscription request: %v", form)
+	}
+	secret := form.Get("hub.secret")
+	if secret == "" {
+		t.Fatal("expected a subscription secret")
+	}
+
+	// hub 验证订阅
+	verify := "/websub/blog?hub.mode=subscribe&hub.topic=" + url.QueryEscape(topic) + "&hub.challenge=c123&hub.lease_seconds=3600"
+	if w := doRequest(r, http.MethodGet, verify, nil); w.Code != http.StatusOK || w.Body.String() != "c123" {
+		t.Fatalf("expected the challenge to be echoed, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doRequest(r, http.MethodGet, "/websub/blog?hub.mode=subscribe&hub.topic=other&hub.challenge=x", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown topic, got %d", w.Code)
+	}
+
+	// 签名错误的通知被忽略
+	rejected := metrics.WebSubNotifications.WithLabelValues("blog", "rejected")
+	rejectedBefore := counterValue(t, rejected)
+	notify := func(body, signature string) int {
+		req := httptest.NewRequest(http.MethodPost, "/websub/blog", strings.NewReader(body))
+		req.Header.Set("X-Hub-Signature", signature)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	body := withHub(rssXML(numberedItems(3)...))
+	src.SetBody(body)
+	if code := notify("forged", "sha256=00"); code != http.StatusAccepted {
+		t.Errorf("expected 202 for a bad signature, got %d", code)
+	}
+	if got := counterValue(t, rejected) - rejectedBefore; got != 1 {
+		t.Errorf("expected 1 rejected notification, got %v", got)
+	}
+
+	// 签名正确的通知立即触发更新
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	if code := notify(body, "sha256="+hex.EncodeToString(mac.Sum(nil))); code != http.StatusAccepted {
+		t.Fatalf("expected 202 for a signed notification, got %d", code)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(store.Keys("feeds/blog/")) < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if keys := store.Keys("feeds/blog/"); len(keys) != 3 {
+		t.Errorf("expected the notification to store the new items, got %v", keys)
+	}
+}
+
+func TestHandler_DeleteStoredItem(t *testing.T) {
+	src := newFeedServer(t, rssXML(numberedItems(2)...))
+	withConfig(t, conf.Config{
+		Feeds: []conf.Feed{{Name: "blog", RssFeed: src.URL}},
+		HTTP:  conf.HTTPConfig{AdminToken: "secret"},
+	})
+
+	store := newFakeStore()
+	svc := newTestRssService(okAIServer(t), store)
+	if err := svc.UpdateFeed(context.Background(), conf.Current().Feeds[0]); err != nil {
+		t.Fatalf("update feed: %v", err)
+	}
+	r := newTestRouter(svc)
+
+	countItems := func() int {
+		w := doRequest(r, http.MethodGet, "/feeds/blog", nil)
+		var items []map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		return len(items)
+	}
+	// 读取一次使条目进入缓存
+	if n := countItems(); n != 2 {
+		t.Fatalf("expected 2 items before delete, got %d", n)
+	}
+
+	// 删除条目需要管理令牌
+	if w := doRequest(r, http.MethodDelete, "/feeds/blog/items/item-0", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without admin token, got %d", w.Code)
+	}
+	if removes := store.Removes(); len(removes) != 0 {
+		t.Errorf("expected nothing removed without admin token, got %v", removes)
+	}
+
+	if w := doAdminRequest(r, http.MethodDelete, "/feeds/blog/items/item-0", "secret", nil); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if removes := store.Removes(); len(removes) != 1 || removes[0] != "feeds/blog/items/item-0.json" {
+		t.Errorf("expected the item object to be removed, got %v", removes)
+	}
+	if n := countItems(); n != 1 {
+		t.Errorf("expected the cached items to be invalidated, got %d items", n)
+	}
+
+	for _, target := range []string{"/feeds/blog/items/item-0", "/feeds/blog/items/missing", "/feeds/unknown/items/item-1"} {
+		if w := doAdminRequest(r, http.MethodDelete, target, "secret", nil); w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", target, w.Code)
+		}
+	}
+}
+
+func TestHandler_PreviewRendersWithoutStoring(t *testing.T) {
+	src := newFeedServer(t, rssXML(numberedItems(3)...))
+	withConfig(t, conf.Config{
+		Preview: conf.PreviewConfig{AllowPrivate: true, MaxSummaries: 1},
+		HTTP:    conf.HTTPConfig{AdminToken: "secret"},
+	})
+
+	store := newFakeStore()
+	store.putErr = func(objectName string) error {
+		t.Errorf("preview wrote %s to storage", objectName)
+		return nil
+	}
+	ai := okAIServer(t)
+	r := newTestRouter(newTestRssService(ai, store))
+
+	// 预览会拉取任意地址并调用 AI，需要管理令牌
+	if w := doRequest(r, http.MethodGet, "/preview?summaries=5&url="+url.QueryEscape(src.URL), nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without admin token, got %d", w.Code)
+	}
+	if n := len(ai.Requests()); n != 0 {
+		t.Errorf("expected no AI requests without admin token, got %d", n)
+	}
+
+	w := doAdminRequest(r, http.MethodGet, "/preview?summaries=5&url="+url.QueryEscape(src.URL), "secret", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/xml") {
+		t.Errorf("expected RSS content type, got %q", ct)
+	}
+	parsed, err := gofeed.NewParser().ParseString(w.Body.String())
+	if err != nil {
+		t.Fatalf("parse preview: %v", err)
+	}
+	if len(parsed.Items) != 3 {
+		t.Fatalf("expected 3 items, got %d", len(parsed.Items))
+	}
+	if !strings.Contains(parsed.Items[0].Description, "summary") {
+		t.Errorf("expected the first item to be summarized, got %q", parsed.Items[0].Description)
+	}
+	if n := len(ai.Requests()); n != 1 {
+		t.Errorf("expected summaries capped at 1, got %d AI requests", n)
+	}
+
+	w = doAdminRequest(r, http.MethodGet, "/preview?format=json&url="+url.QueryEscape(src.URL), "secret", nil)
+	var feed struct {
+		Items []map[string]any `json:"items"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &feed); err != nil || len(feed.Items) != 3 {
+		t.Errorf("expected a JSON Feed with 3 items, got %d: %s", w.Code, w.Body.String())
+	}
+	if keys := store.Keys(""); len(keys) != 0 {
+		t.Errorf("expected no stored objects, got %v", keys)
+	}
+
+	for target, want := range map[string]int{
+		"/preview":                       http.StatusBadRequest,
+		"/preview?url=file:///etc/hosts": http.StatusBadRequest,
+		"/preview?format=xml&url=" + url.QueryEscape(src.URL): http.StatusBadRequest,
+	} {
+		if w := doAdminRequest(r, http.MethodGet, target, "secret", nil); w.Code != want {
+			t.Errorf("%s: expected %d, got %d", target, want, w.Code)
+		}
+	}
+
+	// 默认拒绝回环地址
+	withConfig(t, conf.Config{HTTP: conf.HTTPConfig{AdminToken: "secret"}})
+	r = newTestRouter(newTestRssService(ai, store))
+	if w := doAdminRequest(r, http.MethodGet, "/preview?url="+url.QueryEscape(src.URL), "secret", nil); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a loopback URL, got %d: %s", w.Code, w.Body.String())
+	}
+	if hits := src.Hits(); hits != 2 {
+		t.Errorf("expected the blocked request not to reach the upstream, got %d hits", hits)
+	}
+}
+
+func TestHandler_AccessLog(t *testing.T) {
+	withConfig(t, conf.Config{HTTP: conf.HTTPConfig{AccessLog: true}})
+	buf := captureLogs(t)
+	r := newTestRouter(service.NewRssService(service.NewAIService(conf.AIConfig{Disabled: true}), newFakeStore(), service.RssConfig{}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "req-123")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if got := w.Header().Get("X-Request-ID"); got != "req-123" {
+		t.Errorf("expected the request ID echoed, got %q", got)
+	}
+
+	var entry map[string]any
+	for _, line := range logLines(buf) {
+		if strings.Contains(line, `"msg":"HTTP request"`) {
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				t.Fatalf("decode log line: %v", err)
+			}
+		}
+	}
+	if entry == nil {
+		t.Fatalf("expected an access log line, got:\n%s", buf.String())
+	}
+	if entry["level"] != "INFO" || entry["method"] != "GET" || entry["path"] != "/" || entry["request_id"] != "req-123" {
+		t.Errorf("unexpected access log fields: %v", entry)
+	}
+	if entry["status"] != float64(http.StatusOK) || entry["bytes"] != float64(w.Body.Len()) {
+		t.Errorf("expected status 200 and %d bytes, got %v", w.Body.Len(), entry)
+	}
+	if _, ok := entry["latency_ms"].(float64); !ok {
+		t.Errorf("expected a numeric latency, got %v", entry["latency_ms"])
+	}
+
+	// 未开启时不输出
+	withConfig(t, conf.Config{})
+	buf.Reset()
+	r = newTestRouter(service.NewRssService(service.NewAIService(conf.AIConfig{Disabled: true}), newFakeStore(), service.RssConfig{}))
+	doRequest(r, http.MethodGet, "/", nil)
+	if strings.Contains(buf.String(), "HTTP request") {
+		t.Errorf("expected no access log when disabled, got:\n%s", buf.String())
+	}
+}
+
+func TestHandler_OPMLListsConfiguredFeeds(t *testing.T) {
+	withConfig(t, conf.Config{
+		HTTP: conf.HTTPConfig{BaseURL: "https://feeds.example.com/"},
+		Feeds: []conf.Feed{
+			{Name: "blog", RssFeed: "https://example.com/feed.xml", Link: "https://example.com"},
+			{Name: "my toots", Mastodon: conf.Mastodon{Host: "mastodon.social", Token: "token"}},
+		},
+	})
+	r := newTestRouter(newTestRssService(okAIServer(t), newFakeStore()))
+
+	w := doRequest(r, http.MethodGet, "/opml", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/x-opml") {
+		t.Errorf("expected OPML content type, got %q", ct)
+	}
+
+	var doc struct {
+		XMLName xml.Name `xml:"opml"`
+		Version string   `xml:"version,attr"`
+		Title   string   `xml:"head>title"`
+		Body    []struct {
+			Type    string `xml:"type,attr"`
+			Text    string `xml:"text,attr"`
+			Title   string `xml:"title,attr"`
+			XMLURL  string `xml:"xmlUrl,attr"`
+			HTMLURL string `xml:"htmlUrl,attr"`
+		} `xml:"body>outline"`
+	}
+	if err := xml.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("parse OPML: %v", err)
+	}
+	if doc.Version != "2.0" || doc.Title == "" {
+		t.Errorf("expected an OPML 2.0 document with a title, got version %q title %q", doc.Version, doc.Title)
+	}
+	if len(doc.Body) != 2 {
+		t.Fatalf("expected one outline per feed, got %d", len(doc.Body))
+	}
+	want := []struct{ name, xmlURL, htmlURL string }{
+		{"blog", "https://feeds.example.com/feeds/blog?format=rss", "https://example.com"},
+		{"my toots", "https://feeds.example.com/feeds/my%20toots", ""},
+	}
+	for i, o := range doc.Body {
+		if o.Type != "rss" || o.Text != want[i].name || o.Title != want[i].name {
+			t.Errorf("outline %d: unexpected type/text/title %q/%q/%q", i, o.Type, o.Text, o.Title)
+		}
+		if o.XMLURL != want[i].xmlURL || o.HTMLURL != want[i].htmlURL {
+			t.Errorf("outline %d: got xmlUrl %q htmlUrl %q, want %q %q", i, o.XMLURL, o.HTMLURL, want[i].xmlURL, want[i].htmlURL)
+		}
+	}
+}
+
+func TestHandler_OPMLImportRegistersFeeds(t *testing.T) {
+	a := newFeedServer(t, rssXML(numberedItems(1)...))
+	b := newFeedServer(t, rssXML(numberedItems(1)...))
+	withConfig(t, conf.Config{
+		HTTP:  conf.HTTPConfig{AdminToken: "secret"},
+		Feeds: []conf.Feed{{Name: "existing", RssFeed: "https://example.com/feed.xml"}},
+	})
+
+	rssService := newTestRssService(okAIServer(t), newFakeStore())
+	scheduler := service.NewSchedulerService(rssService, service.SchedulerConfig{UpdateInterval: time.Hour, RetryDelay: time.Millisecond})
+	events := scheduler.Events()
+	defer scheduler.StopAllJobs()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	unifeedhttp.NewHandler(rssService, scheduler).Router(r)
+
+	body := `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="2.0">
+  <head><title>subscriptions</title></head>
+  <body>
+    <outline text="Tech">
+      <outline type="rss" text="Feed A" title="alpha" xmlUrl="` + a.URL + `" htmlUrl="https://a.example.com"/>
+      <outline type="rss" text="existing" xmlUrl="https://example.com/other.xml"/>
+    </outline>
+    <outline type="rss" text="beta" xmlUrl="` + b.URL + `"/>
+    <outline type="rss" text="bad" xmlUrl="ftp://example.com/feed.xml"/>
+  </body>
+</opml>`
+
+	if w := doRequest(r, http.MethodPost, "/opml", strings.NewReader(body)); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected import to require the admin token, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/opml", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Content-Type", "text/x-opml")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var result struct {
+		Added   []string `json:"added"`
+		Skipped []struct {
+			Name   string `json:"name"`
+			Reason string `json:"reason"`
+		} `json:"skipped"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !slices.Equal(result.Added, []string{"alpha", "beta"}) {
+		t.Errorf("expected alpha and beta to be added, got %v", result.Added)
+	}
+	if len(result.Skipped) != 2 || result.Skipped[0].Name != "existing" || result.Skipped[0].Reason != "duplicate name" ||
+		result.Skipped[1].Name != "bad" || result.Skipped[1].Reason != "invalid xmlUrl" {
+		t.Errorf("unexpected skipped feeds: %+v", result.Skipped)
+	}
+
+	// 导入的 Feed 加入配置并启动任务
+	feeds := conf.Current().Feeds
+	if len(feeds) != 3 || feeds[1].Name != "alpha" || feeds[1].RssFeed != a.URL || !feeds[1].InGroup("Tech") {
+		t.Errorf("expected imported feeds in the config, got %+v", feeds)
+	}
+	for done := 0; done < 2; {
+		select {
+		case ev := <-events:
+			if ev.Type != service.EventStarted {
+				done++
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for imported jobs to run")
+		}
+	}
+	jobs := scheduler.GetAllJobs()
+	if len(jobs) != 2 {
+		t.Fatalf("expected a job per imported feed, got %d", len(jobs))
+	}
+	if a.Hits() == 0 || b.Hits() == 0 {
+		t.Errorf("expected imported feeds to be fetched, got hits a=%d b=%d", a.Hits(), b.Hits())
+	}
+
+	// 再次导入时全部按名称跳过
+	req = httptest.NewRequest(http.MethodPost, "/opml", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(result.Added) != 0 || len(scheduler.GetAllJobs()) != 2 {
+		t.Errorf("expected re-import to add nothing, got %v", result.Added)
+	}
+}
+
+func TestHandler_RuntimeFeedManagement(t *testing.T) {
+	src := newFeedServer(t, rssXML(numberedItems(1)...))
+	base := conf.Config{
+		HTTP: conf.HTTPConfig{AdminToken: "secret"},
+		Feeds: []conf.Feed{{
+			Name:     "existing",
+			Mastodon: conf.Mastodon{Host: "mastodon.social", Token: "mastodon-token"},
+		}},
+	}
+	withConfig(t, base)
+
+	store := newFakeStore()
+	rssService := newTestRssService(okAIServer(t), newFakeStore())
+	scheduler := service.NewSchedulerService(rssService, service.SchedulerConfig{UpdateInterval: time.Hour, RetryDelay: time.Millisecond})
+	events := scheduler.Events()
+	defer scheduler.StopAllJobs()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	handler := unifeedhttp.NewHandler(rssService, scheduler)
+	handler.SetFeedRegistry(service.NewFeedRegistry(store, nil))
+	handler.Router(r)
+
+	admin := func(method, target, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := doRequest(r, http.MethodPost, "/feeds", strings.NewReader(`{"name":"added"}`)); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected feed management to require the admin token, got %d", w.Code)
+	}
+
+	// 增加 Feed 后启动任务
+	w := admin(http.MethodPost, "/feeds", `{"name":"added","rss_feed":"`+src.URL+`","bluesky":{"app_key":"bsky-key","app_secret":"bsky-secret"}}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "bsky-key") || strings.Contains(w.Body.String(), "bsky-secret") {
+		t.Errorf("expected Bluesky credentials to be redacted from the response, got %s", w.Body.String())
+	}
+	if ev := expectEvent(t, events, service.EventStarted); ev.FeedName != "added" {
+		t.Errorf("expected the added feed's job to start, got %+v", ev)
+	}
+	// 等待首次更新完成，避免删除时仍有更新在进行
+	expectEvent(t, events, service.EventSucceeded)
+	if jobs := scheduler.GetAllJobs(); len(jobs) != 1 {
+		t.Errorf("expected one job, got %d", len(jobs))
+	}
+
+	// 重名和无效的 Feed 被拒绝
+	if w := admin(http.MethodPost, "/feeds", `{"name":"existing","rss_feed":"`+src.URL+`"}`); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a duplicate name, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := admin(http.MethodPost, "/feeds", `{"name":"no-source"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a feed without a source, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = admin(http.MethodGet, "/feeds", "")
+	var list []struct {
+		Name     string `json:"name"`
+		Runtime  bool   `json:"runtime"`
+		Mastodon struct {
+			Token string `json:"token"`
+		} `json:"mastodon"`
+		Bluesky struct {
+			AppKey    string `json:"app_key"`
+			AppSecret string `json:"app_secret"`
+		} `json:"bluesky"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(list) != 2 || list[0].Name != "existing" || list[0].Runtime || list[1].Name != "added" || !list[1].Runtime {
+		t.Errorf("unexpected feed list: %+v", list)
+	}
+	if list[0].Mastodon.Token != "" {
+		t.Error("expected tokens to be redacted from the feed list")
+	}
+	if list[1].Bluesky.AppKey != "" || list[1].Bluesky.AppSecret != "" {
+		t.Errorf("expected bluesky.app_key and bluesky.app_secret to be redacted, got %+v", list[1].Bluesky)
+	}
+
+	// 变更写入存储，重启后恢复
+	withConfig(t, base)
+	if err := service.NewFeedRegistry(store, nil).Load(context.Background()); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if feeds := conf.Current().Feeds; len(feeds) != 2 || feeds[1].Name != "added" || feeds[1].RssFeed != src.URL {
+		t.Errorf("expected the added feed to survive a restart, got %+v", feeds)
+	}
+
+	// 删除 Feed 时停止任务
+	if w := admin(http.MethodDelete, "/feeds/added", ""); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if jobs := scheduler.GetAllJobs(); len(jobs) != 0 {
+		t.Errorf("expected the removed feed's job to stop, got %d jobs", len(jobs))
+	}
+	if w := admin(http.MethodDelete, "/feeds/existing", ""); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := admin(http.MethodDelete, "/feeds/existing", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown feed, got %d", w.Code)
+	}
+	if feeds := conf.Current().Feeds; len(feeds) != 0 {
+		t.Errorf("expected no feeds after removal, got %+v", feeds)
+	}
+
+	// 配置文件中的 Feed 删除后重启也不恢复
+	withConfig(t, base)
+	if err := service.NewFeedRegistry(store, nil).Load(context.Background()); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if feeds := conf.Current().Feeds; len(feeds) != 0 {
+		t.Errorf("expected removals to survive a restart, got %+v", feeds)
+	}
+}
+
+func TestHandler_ErrorEnvelope(t *testing.T) {
+	withConfig(t, conf.Config{
+		Preview: conf.PreviewConfig{AllowPrivate: true},
+		HTTP:    conf.HTTPConfig{AdminToken: "secret"},
+	})
+	r := newTestRouter(newTestRssService(okAIServer(t), newFakeStore()))
+
+	decode := func(w *httptest.ResponseRecorder) (body struct {
+		Error struct {
+			Code      string `json:"code"`
+			Message   string `json:"message"`
+			RequestID string `json:"request_id"`
+		} `json:"error"`
+	}) {
+		t.Helper()
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode %s: %v", w.Body.String(), err)
+		}
+		if body.Error.RequestID == "" || body.Error.RequestID != w.Header().Get("X-Request-ID") {
+			t.Errorf("expected the error to carry the response's request ID, got %q", body.Error.RequestID)
+		}
+		return body
+	}
+
+	w := doRequest(r, http.MethodGet, "/feeds/missing", nil)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+	if body := decode(w); body.Error.Code != "not_found" || body.Error.Message != "feed not found" {
+		t.Errorf("unexpected not-found error: %+v", body.Error)
+	}
+
+	// 上游错误只返回通用信息，不暴露内部地址
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	w = doAdminRequest(r, http.MethodGet, "/preview?url="+url.QueryEscape(closed.URL), "secret", nil)
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502, got %d: %s", w.Code, w.Body.String())
+	}
+	body := decode(w)
+	if body.Error.Code != "upstream_error" || body.Error.Message != "upstream request failed" {
+		t.Errorf("unexpected upstream error: %+v", body.Error)
+	}
+	if strings.Contains(w.Body.String(), "127.0.0.1") {
+		t.Errorf("expected upstream details to stay out of the response, got %s", w.Body.String())
+	}
+}
+
+func TestHandler_BasePath(t *testing.T) {
+	src := newFeedServer(t, rssXML(rssItem{GUID: "post-1", Title: "Hello", Link: "https://example.com/hello", Description: "Hello body"}))
+	withConfig(t, conf.Config{
+		Feeds: []conf.Feed{{Name: "blog", RssFeed: src.URL}},
+		HTTP:  conf.HTTPConfig{BasePath: "/unifeed/"},
+	})
+
+	svc := newTestRssService(okAIServer(t), newFakeStore())
+	if err := svc.UpdateFeed(context.Background(), conf.Current().Feeds[0]); err != nil {
+		t.Fatalf("update feed: %v", err)
+	}
+	r := newTestRouter(svc)
+
+	rss := doRequest(r, http.MethodGet, "/unifeed/feeds/blog?format=rss", nil)
+	if rss.Code != http.StatusOK {
+		t.Fatalf("expected 200 under the base path, got %d: %s", rss.Code, rss.Body.String())
+	}
+	if want := `<atom:link href="http://example.com/unifeed/feeds/blog?format=rss" rel="self"`; !strings.Contains(rss.Body.String(), want) {
+		t.Errorf("expected %s in rss output: %s", want, rss.Body.String())
+	}
+	if w := doRequest(r, http.MethodGet, "/unifeed/healthz", nil); w.Code != http.StatusOK {
+		t.Errorf("expected probes under the base path, got %d", w.Code)
+	}
+	opml := doRequest(r, http.MethodGet, "/unifeed/opml", nil)
+	if want := `xmlUrl="http://example.com/unifeed/feeds/blog?format=rss"`; !strings.Contains(opml.Body.String(), want) {
+		t.Errorf("expected %s in OPML: %s", want, opml.Body.String())
+	}
+
+	// 不带前缀的路径不再响应
+	if w := doRequest(r, http.MethodGet, "/feeds/blog?format=rss", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 outside the base path, got %d", w.Code)
+	}
+}
+
+func TestHandler_UpdateTriggersExistingJob(t *testing.T) {
+	src := newFeedServer(t, rssXML(numberedItems(1)...))
+	withConfig(t, conf.Config{Feeds: []conf.Feed{
+		{Name: "running", RssFeed: src.URL},
+		{Name: "idle", RssFeed: src.URL + "/?idle"},
+	}})
+	rssService := newTestRssService(okAIServer(t), newFakeStore())
+	scheduler := service.NewSchedulerService(rssService, service.SchedulerConfig{UpdateInterval: time.Hour, RetryDelay: time.Millisecond})
+	events := scheduler.Events()
+	defer scheduler.StopAllJobs()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	unifeedhttp.NewHandler(rssService, scheduler).Router(r)
+
+	if err := scheduler.StartJob(context.Background(), conf.Current().Feeds[0]); err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+	expectEvent(t, events, service.EventStarted)
+	expectEvent(t, events, service.EventSucceeded)
+
+	// 已有任务时触发一次更新，而不是因任务已存在失败
+	if w := doRequest(r, http.MethodPost, "/feeds/running/update", nil); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for a feed with a running job, got %d: %s", w.Code, w.Body.String())
+	}
+	if ev := expectEvent(t, events, service.EventStarted); ev.FeedName != "running" {
+		t.Errorf("expected the running job to update, got %+v", ev)
+	}
+	expectEvent(t, events, service.EventSucceeded)
+
+	// 没有任务时启动的任务不随请求结束
+	if w := doRequest(r, http.MethodPost, "/feeds/idle/update", nil); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for a feed without a job, got %d: %s", w.Code, w.Body.String())
+	}
+	expectEvent(t, events, service.EventStarted)
+	expectEvent(t, events, service.EventSucceeded)
+	if _, err := scheduler.GetJobStatus("idle"); err != nil {
+		t.Errorf("expected the started job to outlive the request: %v", err)
+	}
+}
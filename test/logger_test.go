@@ -0,0 +1,70 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"go.orx.me/apps/unifeed/internal/logger"
+)
+
+// captureLogs 将默认 logger 输出重定向到缓冲区
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	old := logger.Log
+	logger.Log = slog.New(slog.NewJSONHandler(&buf, nil))
+	t.Cleanup(func() { logger.Log = old })
+	return &buf
+}
+
+func logLines(buf *bytes.Buffer) []string {
+	return strings.Split(strings.TrimSpace(buf.String()), "\n")
+}
+
+func TestSampler_ReducesRepeatedErrors(t *testing.T) {
+	buf := captureLogs(t)
+	sampler := logger.NewSampler(time.Hour, 5)
+	err := errors.New("connection refused")
+
+	for i := 0; i < 10; i++ {
+		sampler.Warn("broken", "Feed update cycle failed", err, "feed_name", "broken")
+	}
+	lines := logLines(buf)
+	// 首次以及第 5、10 次输出
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 log lines for 10 identical errors, got %d:\n%s", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[1], `"suppressed":3`) {
+		t.Errorf("expected suppressed count in sampled line, got %s", lines[1])
+	}
+
+	// 不同的错误和不同的 Feed 不受影响
+	sampler.Warn("broken", "Feed update cycle failed", errors.New("timeout"))
+	sampler.Warn("other", "Feed update cycle failed", err)
+	if got := len(logLines(buf)); got != 5 {
+		t.Errorf("expected distinct errors to be logged, got %d lines", got)
+	}
+}
+
+func TestSampler_WindowExpiry(t *testing.T) {
+	buf := captureLogs(t)
+	sampler := logger.NewSampler(20*time.Millisecond, 0)
+	err := errors.New("boom")
+
+	sampler.Warn("feed", "failed", err)
+	sampler.Warn("feed", "failed", err)
+	time.Sleep(30 * time.Millisecond)
+	sampler.Warn("feed", "failed", err)
+
+	lines := logLines(buf)
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines across windows, got %d:\n%s", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[1], `"suppressed":1`) {
+		t.Errorf("expected suppressed count after window expiry, got %s", lines[1])
+	}
+}
@@ -0,0 +1,131 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.orx.me/apps/unifeed/internal/clock"
+	"go.orx.me/apps/unifeed/internal/retry"
+)
+
+func TestRetrier_SucceedsAfterFailures(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	var delays []time.Duration
+	var attempts []int
+	r := retry.Retrier{
+		MaxAttempts: 5,
+		Backoff: func(attempt int, err error) time.Duration {
+			d := retry.Exponential(time.Second)(attempt, err)
+			delays = append(delays, d)
+			return d
+		},
+		Clock: fake,
+		OnAttempt: func(attempt int, err error) {
+			attempts = append(attempts, attempt)
+		},
+	}
+
+	calls := 0
+	done := make(chan error, 1)
+	go func() {
+		done <- r.Do(context.Background(), func(context.Context) error {
+			calls++
+			if calls < 3 {
+				return errors.New("temporary")
+			}
+			return nil
+		})
+	}()
+
+	// 每次失败后按指数退避等待
+	for _, wait := range []time.Duration{time.Second, 2 * time.Second} {
+		fake.BlockUntil(1)
+		fake.Advance(wait)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("expected success after retries, got %v", err)
+	}
+	if calls != 3 || len(attempts) != 3 || attempts[2] != 3 {
+		t.Errorf("expected 3 attempts reported to OnAttempt, got calls=%d attempts=%v", calls, attempts)
+	}
+	if len(delays) != 2 || delays[0] != time.Second || delays[1] != 2*time.Second {
+		t.Errorf("expected exponential backoff of 1s and 2s, got %v", delays)
+	}
+}
+
+func TestRetrier_Exhausted(t *testing.T) {
+	last := errors.New("still failing")
+	calls := 0
+	err := retry.Retrier{MaxAttempts: 3}.Do(context.Background(), func(context.Context) error {
+		calls++
+		return last
+	})
+	if calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls)
+	}
+	if !errors.Is(err, retry.ErrExhausted) || !errors.Is(err, last) {
+		t.Errorf("expected exhausted error wrapping the last error, got %v", err)
+	}
+}
+
+func TestRetrier_NonRetryableShortCircuits(t *testing.T) {
+	permanent := errors.New("bad request")
+	calls := 0
+	r := retry.Retrier{
+		MaxAttempts: 5,
+		Backoff:     retry.Constant(time.Hour),
+		Retryable:   func(err error) bool { return !errors.Is(err, permanent) },
+	}
+	err := r.Do(context.Background(), func(context.Context) error {
+		calls++
+		return permanent
+	})
+	if calls != 1 {
+		t.Errorf("expected a single attempt, got %d", calls)
+	}
+	if err != permanent {
+		t.Errorf("expected the non-retryable error unchanged, got %v", err)
+	}
+}
+
+func TestRetrier_ContextCancellation(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	r := retry.Retrier{MaxAttempts: 5, Backoff: retry.Constant(time.Minute), Jitter: 0.5, Clock: fake}
+	ctx, cancel := context.WithCancel(context.Background())
+	last := errors.New("temporary")
+
+	calls := 0
+	done := make(chan error, 1)
+	go func() {
+		done <- r.Do(ctx, func(context.Context) error {
+			calls++
+			return last
+		})
+	}()
+
+	// 等待重试期间取消，不再尝试
+	fake.BlockUntil(1)
+	cancel()
+	err := <-done
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, last) {
+		t.Errorf("expected cancellation wrapping the last error, got %v", err)
+	}
+	if errors.Is(err, retry.ErrExhausted) {
+		t.Errorf("cancellation must not be reported as exhausted: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected no attempts after cancellation, got %d", calls)
+	}
+
+	// ctx 已结束时 fn 返回的 ctx 错误默认不重试
+	calls = 0
+	err = retry.Retrier{MaxAttempts: 5}.Do(ctx, func(ctx context.Context) error {
+		calls++
+		return ctx.Err()
+	})
+	if calls != 1 || !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context errors not to be retried, got calls=%d err=%v", calls, err)
+	}
+}
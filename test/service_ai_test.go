@@ -0,0 +1,563 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"go.orx.me/apps/unifeed/internal/clock"
+	"go.orx.me/apps/unifeed/internal/conf"
+	"go.orx.me/apps/unifeed/internal/metrics"
+	"go.orx.me/apps/unifeed/internal/service"
+)
+
+func TestAiService_SummarizeBatch(t *testing.T) {
+	srv := newAIServer(t, func(req openai.ChatCompletionRequest) (int, string) {
+		out, _ := json.Marshal([]string{"summary 1", "summary 2", "summary 3"})
+		return http.StatusOK, string(out)
+	})
+
+	svc := service.NewAIService(conf.AIConfig{Endpoint: srv.URL, APIKey: "key", BatchSize: 10})
+	summaries, err := svc.SummarizeBatch(context.Background(), []string{"first", "second", "third"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := len(srv.Requests()); got != 1 {
+		t.Fatalf("expected a single batched request, got %d", got)
+	}
+	if !strings.Contains(srv.Requests()[0].Messages[0].Content, "third") {
+		t.Error("expected batched prompt to contain every item")
+	}
+	want := []string{"summary 1", "summary 2", "summary 3"}
+	for i := range want {
+		if summaries[i] != want[i] {
+			t.Errorf("summary %d: got %q, want %q", i, summaries[i], want[i])
+		}
+	}
+}
+
+func TestAiService_SummarizeBatchFallback(t *testing.T) {
+	srv := newAIServer(t, func(req openai.ChatCompletionRequest) (int, string) {
+		// 批量请求返回无法解析的内容，逐条请求正常返回
+		if strings.Contains(req.Messages[0].Content, "JSON") {
+			return http.StatusOK, "not json"
+		}
+		return http.StatusOK, "single"
+	})
+
+	svc := service.NewAIService(conf.AIConfig{Endpoint: srv.URL, APIKey: "key", BatchSize: 10})
+	summaries, err := svc.SummarizeBatch(context.Background(), []string{"first", "second"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := len(srv.Requests()); got != 3 {
+		t.Errorf("expected 1 batch request and 2 per-item requests, got %d", got)
+	}
+	for i, s := range summaries {
+		if s != "single" {
+			t.Errorf("summary %d: got %q", i, s)
+		}
+	}
+}
+
+func TestAiService_InvalidRequestNotRetried(t *testing.T) {
+	srv := newAIServer(t, func(req openai.ChatCompletionRequest) (int, string) {
+		return http.StatusBadRequest, "context length exceeded"
+	})
+	before := counterValue(t, metrics.AISummaryErrors.WithLabelValues("invalid_request"))
+
+	svc := service.NewAIService(conf.AIConfig{Endpoint: srv.URL, APIKey: "key", MaxRetries: 3, RetryDelay: time.Millisecond})
+	if _, err := svc.Summarize(context.Background(), "content"); err == nil {
+		t.Fatal("expected error for invalid request")
+	}
+
+	if got := len(srv.Requests()); got != 1 {
+		t.Errorf("expected invalid request not to be retried, got %d requests", got)
+	}
+	after := counterValue(t, metrics.AISummaryErrors.WithLabelValues("invalid_request"))
+	if after-before != 1 {
+		t.Errorf("expected invalid_request metric to increment by 1, got %v", after-before)
+	}
+}
+
+func TestAiService_RateLimitRetried(t *testing.T) {
+	var calls int
+	srv := newAIServer(t, func(req openai.ChatCompletionRequest) (int, string) {
+		calls++
+		if calls == 1 {
+			return http.StatusTooManyRequests, "rate limited"
+		}
+		return http.StatusOK, "summary"
+	})
+
+	svc := service.NewAIService(conf.AIConfig{Endpoint: srv.URL, APIKey: "key", MaxRetries: 3, RateLimitDelay: time.Millisecond})
+	summary, err := svc.Summarize(context.Background(), "content")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary != "summary" || len(srv.Requests()) != 2 {
+		t.Errorf("expected retry after rate limit, got %q after %d requests", summary, len(srv.Requests()))
+	}
+}
+
+func TestAiService_SummaryCacheEviction(t *testing.T) {
+	srv := okAIServer(t)
+	evictions := metrics.FeedCacheEvictions.WithLabelValues("summary")
+	before := counterValue(t, evictions)
+
+	svc := service.NewAIService(conf.AIConfig{Endpoint: srv.URL, APIKey: "key", SummaryCacheSize: 2})
+	ctx := context.Background()
+	for _, content := range []string{"a", "b", "a"} {
+		if _, err := svc.Summarize(ctx, content); err != nil {
+			t.Fatalf("summarize %s: %v", content, err)
+		}
+	}
+	if got := len(srv.Requests()); got != 2 {
+		t.Fatalf("expected cached summary to be reused, got %d requests", got)
+	}
+
+	// 第三个内容超出容量，淘汰最久未使用的 b
+	if _, err := svc.Summarize(ctx, "c"); err != nil {
+		t.Fatalf("summarize c: %v", err)
+	}
+	if got := counterValue(t, evictions) - before; got != 1 {
+		t.Errorf("expected 1 summary eviction, got %v", got)
+	}
+	if _, err := svc.Summarize(ctx, "b"); err != nil {
+		t.Fatalf("summarize b: %v", err)
+	}
+	if got := len(srv.Requests()); got != 4 {
+		t.Errorf("expected evicted summary to be regenerated, got %d requests", got)
+	}
+}
+
+func TestAiService_TemperatureZeroAndSeed(t *testing.T) {
+	srv := okAIServer(t)
+	temperature := float32(0)
+	seed := 42
+	svc := service.NewAIService(conf.AIConfig{Endpoint: srv.URL, APIKey: "key", Temperature: &temperature, Seed: &seed})
+	if _, err := svc.Summarize(context.Background(), "deterministic"); err != nil {
+		t.Fatalf("summarize: %v", err)
+	}
+
+	// 未设置温度时使用默认值 0.7
+	def := service.NewAIService(conf.AIConfig{Endpoint: srv.URL, APIKey: "key"})
+	if _, err := def.Summarize(context.Background(), "default"); err != nil {
+		t.Fatalf("summarize: %v", err)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(reqs))
+	}
+	if got := reqs[0].Temperature; got > 1e-6 {
+		t.Errorf("expected temperature 0 to reach the request, got %v", got)
+	}
+	if reqs[0].Seed == nil || *reqs[0].Seed != 42 {
+		t.Errorf("expected seed 42, got %v", reqs[0].Seed)
+	}
+	if got := reqs[1].Temperature; got != 0.7 {
+		t.Errorf("expected default temperature 0.7, got %v", got)
+	}
+	if reqs[1].Seed != nil {
+		t.Errorf("expected no seed by default, got %v", *reqs[1].Seed)
+	}
+}
+
+func TestAiService_GlobalConcurrencyAcrossFeeds(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	srv := newAIServer(t, func(req openai.ChatCompletionRequest) (int, string) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		return http.StatusOK, "summary"
+	})
+
+	aiService := service.NewAIService(conf.AIConfig{Endpoint: srv.URL, APIKey: "key", GlobalConcurrency: 1, SummaryCacheSize: -1})
+	rssService := service.NewRssService(aiService, newFakeStore(), service.RssConfig{})
+
+	feeds := []conf.Feed{
+		{Name: "a", RssFeed: newFeedServer(t, rssXML(numberedItems(2)...)).URL},
+		{Name: "b", RssFeed: newFeedServer(t, rssXML(numberedItems(2)...)).URL},
+	}
+	var wg sync.WaitGroup
+	for _, feed := range feeds {
+		wg.Add(1)
+		go func(feed conf.Feed) {
+			defer wg.Done()
+			if err := rssService.UpdateFeed(context.Background(), feed); err != nil {
+				t.Errorf("update %s: %v", feed.Name, err)
+			}
+		}(feed)
+	}
+	wg.Wait()
+
+	if got := len(srv.Requests()); got != 4 {
+		t.Fatalf("expected 4 AI calls, got %d", got)
+	}
+	if got := peak.Load(); got != 1 {
+		t.Errorf("expected at most 1 concurrent AI call, got %d", got)
+	}
+}
+
+func TestAiService_DisabledUsesExtractiveSummary(t *testing.T) {
+	src := newFeedServer(t, rssXML(rssItem{
+		GUID:        "post-1",
+		Title:       "Post",
+		Link:        "https://example.com/post",
+		Description: "<p>First sentence. Second &amp; final sentence!</p><p>Third sentence is dropped.</p>",
+		PubDate:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}))
+
+	aiService := service.NewAIService(conf.AIConfig{Disabled: true, FallbackSentences: 2})
+	store := newFakeStore()
+	rssService := service.NewRssService(aiService, store, service.RssConfig{})
+	if err := rssService.UpdateFeed(context.Background(), conf.Feed{Name: "offline", RssFeed: src.URL}); err != nil {
+		t.Fatalf("update feed: %v", err)
+	}
+
+	items, err := rssService.GetFeedItems(context.Background(), "offline")
+	if err != nil {
+		t.Fatalf("get feed items: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(items))
+	}
+	if want := "First sentence. Second & final sentence!"; items[0].Summary != want {
+		t.Errorf("expected extractive summary %q, got %q", want, items[0].Summary)
+	}
+}
+
+func TestConfigValidate_AIKeyOptionalWhenDisabled(t *testing.T) {
+	cfg := conf.Config{
+		Feeds: []conf.Feed{{Name: "blog", RssFeed: "https://example.com/feed.xml"}},
+		S3:    conf.S3Config{Endpoint: "s3", AccessKeyID: "id", SecretAccessKey: "secret", BucketName: "bucket"},
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected missing API key to be rejected when AI is enabled")
+	}
+	cfg.AI.Disabled = true
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected config without API key to be valid when AI is disabled, got %v", err)
+	}
+}
+
+func TestExtractiveSummary(t *testing.T) {
+	cases := []struct {
+		content string
+		n       int
+		want    string
+	}{
+		{"Pi is 3.14 today. Next one.", 1, "Pi is 3.14 today."},
+		{"第一句。第二句！第三句？", 2, "第一句。第二句！"},
+		{"No terminator here", 3, "No terminator here"},
+	}
+	for _, tc := range cases {
+		if got := service.ExtractiveSummary(tc.content, tc.n); got != tc.want {
+			t.Errorf("ExtractiveSummary(%q, %d) = %q, want %q", tc.content, tc.n, got, tc.want)
+		}
+	}
+}
+
+func TestAiService_FallbackModelMetrics(t *testing.T) {
+	srv := newAIServer(t, func(req openai.ChatCompletionRequest) (int, string) {
+		if req.Model == "primary-model" {
+			return http.StatusInternalServerError, "unavailable"
+		}
+		return http.StatusOK, "summary from " + req.Model
+	})
+	primaryErrors := metrics.AISummaryTotal.WithLabelValues("primary-model", "error")
+	fallbackSuccess := metrics.AISummaryTotal.WithLabelValues("backup-model", "success")
+	primarySuccess := metrics.AISummaryTotal.WithLabelValues("primary-model", "success")
+	beforeErrors, beforeFallback, beforePrimary := counterValue(t, primaryErrors), counterValue(t, fallbackSuccess), counterValue(t, primarySuccess)
+
+	svc := service.NewAIService(conf.AIConfig{
+		Endpoint:       srv.URL,
+		APIKey:         "key",
+		Model:          "primary-model",
+		FallbackModels: []string{"backup-model"},
+		MaxRetries:     2,
+		RetryDelay:     time.Millisecond,
+	})
+	summary, err := svc.Summarize(context.Background(), "content")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary != "summary from backup-model" {
+		t.Errorf("expected fallback model summary, got %q", summary)
+	}
+	if got := counterValue(t, fallbackSuccess) - beforeFallback; got != 1 {
+		t.Errorf("expected success metric labeled with fallback model, got %v", got)
+	}
+	if got := counterValue(t, primaryErrors) - beforeErrors; got != 2 {
+		t.Errorf("expected 2 primary model errors, got %v", got)
+	}
+	if got := counterValue(t, primarySuccess) - beforePrimary; got != 0 {
+		t.Errorf("expected no success attributed to primary model, got %v", got)
+	}
+}
+
+func TestAiService_DryRunLogsPrompt(t *testing.T) {
+	buf := captureLogs(t)
+	srv := okAIServer(t)
+	svc := service.NewAIService(conf.AIConfig{Endpoint: srv.URL, APIKey: "key", DryRun: true, BatchSize: 5})
+
+	summary, err := svc.Summarize(context.Background(), "dry run article body")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary != service.DryRunSummary {
+		t.Errorf("expected placeholder summary, got %q", summary)
+	}
+	summaries, err := svc.SummarizeBatch(context.Background(), []string{"first body", "second body"})
+	if err != nil {
+		t.Fatalf("unexpected batch error: %v", err)
+	}
+	for i, s := range summaries {
+		if s != service.DryRunSummary {
+			t.Errorf("expected placeholder for batch item %d, got %q", i, s)
+		}
+	}
+
+	if got := len(srv.Requests()); got != 0 {
+		t.Errorf("expected no API calls in dry run, got %d", got)
+	}
+	for _, want := range []string{"dry run article body", "second body"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected prompt containing %q to be logged, got %s", want, buf.String())
+		}
+	}
+}
+
+func TestAiService_FakeClockBackoff(t *testing.T) {
+	var calls atomic.Int32
+	srv := newAIServer(t, func(req openai.ChatCompletionRequest) (int, string) {
+		if calls.Add(1) == 1 {
+			return http.StatusTooManyRequests, "rate limited"
+		}
+		return http.StatusOK, "summary"
+	})
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	svc := service.NewAIService(conf.AIConfig{Endpoint: srv.URL, APIKey: "key", MaxRetries: 2, RateLimitDelay: time.Hour})
+	svc.SetClock(fake)
+
+	done := make(chan string, 1)
+	go func() {
+		summary, _ := svc.Summarize(context.Background(), "content")
+		done <- summary
+	}()
+
+	// 被限流后等待 RateLimitDelay，推进时间前不会重试
+	fake.BlockUntil(1)
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("expected 1 call before backoff elapses, got %d", got)
+	}
+	fake.Advance(time.Hour)
+
+	select {
+	case summary := <-done:
+		if summary != "summary" || calls.Load() != 2 {
+			t.Errorf("expected retry after backoff, got %q after %d calls", summary, calls.Load())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for retry")
+	}
+}
+
+func TestAiService_TruncateMode(t *testing.T) {
+	long := strings.Repeat("long content ", 20)
+	srv := okAIServer(t)
+
+	for _, tc := range []struct {
+		mode    string
+		logged  bool
+		wantErr bool
+	}{
+		{mode: "", logged: false},
+		{mode: service.TruncateSilent, logged: false},
+		{mode: service.TruncateWarn, logged: true},
+		{mode: service.TruncateError, wantErr: true},
+	} {
+		buf := captureLogs(t)
+		svc := service.NewAIService(conf.AIConfig{Endpoint: srv.URL, APIKey: "key", MaxContentLength: 100, TruncateMode: tc.mode, SummaryCacheSize: -1})
+		before := counterValue(t, metrics.AIContentTruncated)
+
+		_, err := svc.Summarize(context.Background(), long)
+		if tc.wantErr != (err != nil) {
+			t.Errorf("mode %q: unexpected error %v", tc.mode, err)
+		}
+		if got := counterValue(t, metrics.AIContentTruncated) - before; got != 1 {
+			t.Errorf("mode %q: expected truncation metric to increase by 1, got %v", tc.mode, got)
+		}
+		if logged := strings.Contains(buf.String(), "Content truncated"); logged != tc.logged {
+			t.Errorf("mode %q: expected truncation logged=%v, got logs %s", tc.mode, tc.logged, buf.String())
+		}
+	}
+
+	requests := srv.Requests()
+	if len(requests) != 3 {
+		t.Fatalf("expected 3 API calls, got %d", len(requests))
+	}
+	if prompt := requests[0].Messages[len(requests[0].Messages)-1].Content; strings.Contains(prompt, long) || !strings.Contains(prompt, "...") {
+		t.Errorf("expected truncated prompt, got %q", prompt)
+	}
+}
+
+func TestAiService_CancelDuringRetry(t *testing.T) {
+	var mu sync.Mutex
+	var cancel context.CancelFunc
+	srv := newAIServer(t, func(req openai.ChatCompletionRequest) (int, string) {
+		// 第一次失败后取消，此时正在等待重试
+		mu.Lock()
+		cancel()
+		mu.Unlock()
+		return http.StatusInternalServerError, "unavailable"
+	})
+	svc := service.NewAIService(conf.AIConfig{Endpoint: srv.URL, APIKey: "key", SummaryCacheSize: -1})
+	svc.SetMaxRetries(3)
+	svc.SetRetryDelay(5 * time.Second)
+
+	for name, summarize := range map[string]func(context.Context, string) (string, error){
+		"Summarize":        svc.Summarize,
+		"SummarizeArticle": svc.SummarizeArticle,
+	} {
+		mu.Lock()
+		ctx, c := context.WithCancel(context.Background())
+		cancel = c
+		mu.Unlock()
+		start := time.Now()
+		_, err := summarize(ctx, "article body")
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: expected context.Canceled, got %v", name, err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("%s: expected prompt return after cancel, took %v", name, elapsed)
+		}
+		c()
+	}
+	if got := len(srv.Requests()); got != 2 {
+		t.Errorf("expected one attempt per call, got %d", got)
+	}
+}
+
+func TestAiService_PromptConfig(t *testing.T) {
+	custom := service.NewAIService(conf.AIConfig{APIKey: "key", PromptTemplate: "TL;DR in 100% plain words:\n%s\n---"})
+	if got, want := custom.BuildPrompt("body"), "TL;DR in 100% plain words:\nbody\n---"; got != want {
+		t.Errorf("custom template: got %q, want %q", got, want)
+	}
+
+	english := service.NewAIService(conf.AIConfig{APIKey: "key", Language: service.LanguageEnglish})
+	want := "Summarize the main content of the following article in English, highlighting the key points and keeping it concise:\n\nbody"
+	if got := english.BuildPrompt("body"); got != want {
+		t.Errorf("english default: got %q, want %q", got, want)
+	}
+
+	chinese := service.NewAIService(conf.AIConfig{APIKey: "key"})
+	if got := chinese.BuildPrompt("body"); got != "请用中文总结以下文章的主要内容，突出关键点，并保持简洁：\n\nbody" {
+		t.Errorf("chinese default: got %q", got)
+	}
+
+	// 批量请求使用对应语言的说明
+	srv := newAIServer(t, func(req openai.ChatCompletionRequest) (int, string) {
+		return http.StatusOK, `["one", "two"]`
+	})
+	batch := service.NewAIService(conf.AIConfig{Endpoint: srv.URL, APIKey: "key", Language: service.LanguageEnglish, BatchSize: 5})
+	if _, err := batch.SummarizeBatch(context.Background(), []string{"first", "second"}); err != nil {
+		t.Fatalf("summarize batch: %v", err)
+	}
+	requests := srv.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(requests))
+	}
+	prompt := requests[0].Messages[len(requests[0].Messages)-1].Content
+	if !strings.Contains(prompt, "in English") || !strings.Contains(prompt, "### Article 2\nsecond") {
+		t.Errorf("unexpected batch prompt %q", prompt)
+	}
+
+	cfg := conf.Config{
+		Feeds: []conf.Feed{{Name: "blog", RssFeed: "https://example.com/feed.xml"}},
+		S3:    conf.S3Config{Endpoint: "s3", AccessKeyID: "id", SecretAccessKey: "secret", BucketName: "bucket"},
+		AI:    conf.AIConfig{APIKey: "key", PromptTemplate: "no placeholder"},
+	}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "prompt_template") {
+		t.Errorf("expected prompt_template validation error, got %v", err)
+	}
+}
+
+func TestAiService_TruncatedResponse(t *testing.T) {
+	newServer := func() *aiServer {
+		srv := newAIServer(t, func(req openai.ChatCompletionRequest) (int, string) {
+			if len(req.Messages) > 1 {
+				return http.StatusOK, " and the rest."
+			}
+			return http.StatusOK, "A summary cut"
+		})
+		// 首次请求因 max_tokens 截断，续写请求正常结束
+		srv.finish = func(req openai.ChatCompletionRequest) openai.FinishReason {
+			if len(req.Messages) > 1 {
+				return openai.FinishReasonStop
+			}
+			return openai.FinishReasonLength
+		}
+		return srv
+	}
+
+	t.Run("flagged", func(t *testing.T) {
+		srv := newServer()
+		svc := service.NewAIService(conf.AIConfig{Endpoint: srv.URL, APIKey: "key", Model: "truncated-flag"})
+		flagged := metrics.AISummaryTruncated.WithLabelValues("truncated-flag", "flagged")
+		before := counterValue(t, flagged)
+
+		summary, err := svc.Summarize(context.Background(), "truncated flagged content")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if summary != "A summary cut…" {
+			t.Errorf("expected truncated summary to be flagged, got %q", summary)
+		}
+		if got := len(srv.Requests()); got != 1 {
+			t.Errorf("expected no continuation request, got %d requests", got)
+		}
+		if got := counterValue(t, flagged) - before; got != 1 {
+			t.Errorf("expected truncated metric to increase by 1, got %v", got)
+		}
+	})
+
+	t.Run("continued", func(t *testing.T) {
+		srv := newServer()
+		svc := service.NewAIService(conf.AIConfig{Endpoint: srv.URL, APIKey: "key", Model: "truncated-continue", ContinueTruncated: true})
+		continued := metrics.AISummaryTruncated.WithLabelValues("truncated-continue", "continued")
+		before := counterValue(t, continued)
+
+		summary, err := svc.Summarize(context.Background(), "truncated continued content")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if summary != "A summary cut and the rest." {
+			t.Errorf("expected continuation to be appended, got %q", summary)
+		}
+		requests := srv.Requests()
+		if len(requests) != 2 {
+			t.Fatalf("expected a continuation request, got %d requests", len(requests))
+		}
+		if msgs := requests[1].Messages; len(msgs) != 3 || msgs[1].Role != openai.ChatMessageRoleAssistant || msgs[1].Content != "A summary cut" {
+			t.Errorf("expected continuation to include the partial reply, got %+v", msgs)
+		}
+		if got := counterValue(t, continued) - before; got != 1 {
+			t.Errorf("expected continued metric to increase by 1, got %v", got)
+		}
+	})
+}
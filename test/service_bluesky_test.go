@@ -1,6 +1,12 @@
 package test
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 
 	"go.orx.me/apps/unifeed/internal/conf"
@@ -9,9 +15,249 @@ import (
 
 func TestBlueskyService_TimelineToRSS(t *testing.T) {
 	svc := service.NewBlueskyService()
-	_, err := svc.TimelineToRSS(conf.Feed{})
+	_, err := svc.TimelineToRSS(context.Background(), conf.Feed{})
 	if err == nil {
 		t.Error("expected error for empty config")
 	}
-	// TODO: 可用 httptest.Server mock Bluesky API 进一步测试
+}
+
+const blueskyCID = "bafyreie5737gdxlw5i64vzichcalba3z2v5n6icifvx5xytvske7mr3hpm"
+
+// blueskyPost 生成 timeline 中的帖子
+func blueskyPost(did, rkey, text string) map[string]any {
+	return map[string]any{
+		"post": map[string]any{
+			"uri":       "at://" + did + "/app.bsky.feed.post/" + rkey,
+			"cid":       blueskyCID,
+			"author":    map[string]any{"did": did, "handle": "alice.bsky.social"},
+			"record":    map[string]any{"$type": "app.bsky.feed.post", "text": text, "createdAt": "2024-01-01T00:00:00Z"},
+			"indexedAt": "2024-01-01T00:00:00Z",
+		},
+	}
+}
+
+// newBlueskyServer 模拟 Bluesky XRPC 接口，记录请求路径
+func newBlueskyServer(t *testing.T, did string) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/xrpc/com.atproto.identity.resolveHandle":
+			if r.URL.Query().Get("handle") != "alice.bsky.social" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"InvalidRequest","message":"unknown handle"}`))
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"did": did})
+		case "/xrpc/app.bsky.feed.getTimeline":
+			reply := blueskyPost(did, "3kreply", "replying")
+			reply["post"].(map[string]any)["record"].(map[string]any)["reply"] = map[string]any{
+				"root":   map[string]any{"uri": "at://" + did + "/app.bsky.feed.post/3kabc", "cid": blueskyCID},
+				"parent": map[string]any{"uri": "at://" + did + "/app.bsky.feed.post/3kabc", "cid": blueskyCID},
+			}
+			json.NewEncoder(w).Encode(map[string]any{
+				"feed": []map[string]any{blueskyPost(did, "3kabc", "hello bluesky"), reply},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), paths...)
+	}
+}
+
+func TestBlueskyService_ResolvesHandleBeforeTimeline(t *testing.T) {
+	const did = "did:plc:ewvi7nxzyoun6zhxrhs64oiz"
+	srv, paths := newBlueskyServer(t, did)
+
+	svc := service.NewBlueskyService()
+	got, err := svc.ResolveDID(context.Background(), srv.URL, "@alice.bsky.social")
+	if err != nil {
+		t.Fatalf("ResolveDID returned error: %v", err)
+	}
+	if got != did {
+		t.Errorf("expected %s, got %s", did, got)
+	}
+
+	out, err := svc.TimelineToRSS(context.Background(), conf.Feed{
+		Name:    "sky",
+		Bluesky: conf.Bluesky{Host: srv.URL, Handle: "alice.bsky.social", AppKey: "token"},
+	})
+	if err != nil {
+		t.Fatalf("TimelineToRSS returned error: %v", err)
+	}
+	requests := paths()
+	if len(requests) != 3 || requests[1] != "/xrpc/com.atproto.identity.resolveHandle" || requests[2] != "/xrpc/app.bsky.feed.getTimeline" {
+		t.Errorf("expected handle resolution before timeline fetch, got %v", requests)
+	}
+	if !strings.Contains(out, "https://bsky.app/profile/alice.bsky.social/post/3kabc") {
+		t.Errorf("expected post link in output, got %s", out)
+	}
+}
+
+func TestBlueskyService_DIDSkipsResolution(t *testing.T) {
+	const did = "did:plc:ewvi7nxzyoun6zhxrhs64oiz"
+	srv, paths := newBlueskyServer(t, did)
+
+	svc := service.NewBlueskyService()
+	got, err := svc.ResolveDID(context.Background(), srv.URL, did)
+	if err != nil {
+		t.Fatalf("ResolveDID returned error: %v", err)
+	}
+	if got != did || len(paths()) != 0 {
+		t.Errorf("expected DID to be used as is without requests, got %s after %v", got, paths())
+	}
+}
+
+func TestBlueskyService_IncludeReplies(t *testing.T) {
+	srv, _ := newBlueskyServer(t, "did:plc:ewvi7nxzyoun6zhxrhs64oiz")
+	svc := service.NewBlueskyService()
+	include := false
+	feed := conf.Feed{Name: "sky", Bluesky: conf.Bluesky{Host: srv.URL, Handle: "alice.bsky.social"}}
+
+	out, err := svc.TimelineToRSS(context.Background(), feed)
+	if err != nil {
+		t.Fatalf("TimelineToRSS returned error: %v", err)
+	}
+	if !strings.Contains(out, "post/3kreply") {
+		t.Errorf("expected replies to be kept by default, got %s", out)
+	}
+
+	feed.Bluesky.IncludeReplies = &include
+	out, err = svc.TimelineToRSS(context.Background(), feed)
+	if err != nil {
+		t.Fatalf("TimelineToRSS returned error: %v", err)
+	}
+	if strings.Contains(out, "post/3kreply") {
+		t.Errorf("expected reply to be dropped, got %s", out)
+	}
+	if !strings.Contains(out, "post/3kabc") {
+		t.Errorf("expected top-level post to be kept, got %s", out)
+	}
+}
+
+func TestConfigValidate_BlueskyHandle(t *testing.T) {
+	base := func(handle string) *conf.Config {
+		return &conf.Config{
+			Feeds: []conf.Feed{{Name: "sky", Bluesky: conf.Bluesky{Host: "https://bsky.social", Handle: handle}}},
+			S3:    conf.S3Config{Endpoint: "s3", AccessKeyID: "id", SecretAccessKey: "secret", BucketName: "bucket"},
+			AI:    conf.AIConfig{APIKey: "key"},
+		}
+	}
+	for _, handle := range []string{"alice.bsky.social", "@alice.bsky.social", "did:plc:ewvi7nxzyoun6zhxrhs64oiz"} {
+		if err := base(handle).Validate(); err != nil {
+			t.Errorf("expected %q to be valid, got %v", handle, err)
+		}
+	}
+	for _, handle := range []string{"", "alice", "did:bad"} {
+		if err := base(handle).Validate(); err == nil {
+			t.Errorf("expected %q to be rejected", handle)
+		}
+	}
+}
+
+func TestBlueskyService_AppPasswordSession(t *testing.T) {
+	const did = "did:plc:ewvi7nxzyoun6zhxrhs64oiz"
+	var mu sync.Mutex
+	var paths []string
+	validAccess := "access-1"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/xrpc/com.atproto.identity.resolveHandle":
+			json.NewEncoder(w).Encode(map[string]string{"did": did})
+		case "/xrpc/com.atproto.server.createSession":
+			var in struct{ Identifier, Password string }
+			json.NewDecoder(r.Body).Decode(&in)
+			if in.Identifier != "alice.bsky.social" || in.Password != "app-password" {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error":"AuthenticationRequired","message":"invalid identifier or password"}`))
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{
+				"accessJwt": "access-1", "refreshJwt": "refresh-1", "handle": "alice.bsky.social", "did": did,
+			})
+		case "/xrpc/com.atproto.server.refreshSession":
+			if r.Header.Get("Authorization") != "Bearer refresh-1" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"InvalidToken","message":"bad refresh token"}`))
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{
+				"accessJwt": "access-2", "refreshJwt": "refresh-2", "handle": "alice.bsky.social", "did": did,
+			})
+		case "/xrpc/app.bsky.feed.getTimeline":
+			if r.Header.Get("Authorization") != "Bearer "+validAccess {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error":"ExpiredToken","message":"token has expired"}`))
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{"feed": []map[string]any{blueskyPost(did, "3kabc", "hello bluesky")}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	calls := func(path string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		n := 0
+		for _, p := range paths {
+			if p == path {
+				n++
+			}
+		}
+		return n
+	}
+
+	svc := service.NewBlueskyService()
+	feed := conf.Feed{
+		Name:    "sky",
+		Bluesky: conf.Bluesky{Host: srv.URL, Handle: "alice.bsky.social", AppSecret: "app-password"},
+	}
+	out, err := svc.TimelineToRSS(context.Background(), feed)
+	if err != nil {
+		t.Fatalf("TimelineToRSS returned error: %v", err)
+	}
+	if !strings.Contains(out, "hello bluesky") {
+		t.Errorf("expected post in output, got %s", out)
+	}
+
+	// 会话被复用，访问令牌过期后通过 refreshSession 刷新
+	mu.Lock()
+	validAccess = "access-2"
+	mu.Unlock()
+	if _, err := svc.TimelineToRSS(context.Background(), feed); err != nil {
+		t.Fatalf("TimelineToRSS after expiry returned error: %v", err)
+	}
+	if got := calls("/xrpc/com.atproto.server.createSession"); got != 1 {
+		t.Errorf("expected 1 createSession call, got %d", got)
+	}
+	if got := calls("/xrpc/com.atproto.server.refreshSession"); got != 1 {
+		t.Errorf("expected 1 refreshSession call, got %d", got)
+	}
+	if got := calls("/xrpc/app.bsky.feed.getTimeline"); got != 3 {
+		t.Errorf("expected 3 timeline requests, got %d", got)
+	}
+
+	// 同名 Feed 换了凭据后不再复用旧会话，需要重新登录
+	feed.Bluesky.AppSecret = "revoked-password"
+	if _, err := svc.TimelineToRSS(context.Background(), feed); err == nil {
+		t.Error("expected changed credentials to log in again and fail")
+	}
+	if got := calls("/xrpc/com.atproto.server.createSession"); got != 2 {
+		t.Errorf("expected a new createSession call after credentials changed, got %d", got)
+	}
 }
@@ -1,8 +1,18 @@
 package test
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/mattn/go-mastodon"
+	"github.com/mmcdole/gofeed"
 	"go.orx.me/apps/unifeed/internal/conf"
 	"go.orx.me/apps/unifeed/internal/service"
 )
@@ -10,9 +20,418 @@ import (
 func TestMastodonService_TimelineToRSS(t *testing.T) {
 	svc := service.NewMastodonService()
 	// 由于没有真实 API，这里只测试参数校验分支
-	_, err := svc.TimelineToRSS(conf.Feed{})
+	_, err := svc.TimelineToRSS(context.Background(), conf.Feed{})
 	if err == nil {
 		t.Error("expected error for empty config")
 	}
 	// TODO: 可用 httptest.Server mock Mastodon API 进一步测试
 }
+
+func TestMastodonService_TitleDescriptionOverride(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("[]"))
+	}))
+	defer srv.Close()
+
+	svc := service.NewMastodonService()
+	out, err := svc.TimelineToRSS(context.Background(), conf.Feed{
+		Name:        "home",
+		Title:       "My Home",
+		Description: "Friends on Mastodon",
+		Mastodon:    conf.Mastodon{Host: srv.URL, Token: "token"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "<title>My Home</title>") {
+		t.Errorf("expected title override in channel, got %s", out)
+	}
+	if !strings.Contains(out, "<description>Friends on Mastodon</description>") {
+		t.Errorf("expected description override in channel, got %s", out)
+	}
+
+	// 未配置覆盖时回退到 name
+	out, err = svc.TimelineToRSS(context.Background(), conf.Feed{
+		Name:     "home",
+		Mastodon: conf.Mastodon{Host: srv.URL, Token: "token"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "<title>home</title>") {
+		t.Errorf("expected title to fall back to name, got %s", out)
+	}
+}
+
+func TestMastodonService_EnclosureLengthFromHEAD(t *testing.T) {
+	media := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("expected HEAD request, got %s", r.Method)
+		}
+		if r.URL.Path == "/missing.mp3" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "audio/mpeg; charset=binary")
+		w.Header().Set("Content-Length", "12345")
+	}))
+	defer media.Close()
+
+	withMedia := func(id, path string) map[string]any {
+		st := mastodonStatus(id, "alice", "listen")
+		st["media_attachments"] = []map[string]any{{"id": "m" + id, "type": "audio", "url": media.URL + path}}
+		return st
+	}
+	srv := newMastodonServer(t, func(r *http.Request) []map[string]any {
+		return []map[string]any{withMedia("1", "/episode.mp3"), withMedia("2", "/missing.mp3")}
+	})
+
+	svc := service.NewMastodonService()
+	svc.SetEnclosureResolver(service.NewEnclosureResolver(time.Second))
+	out, err := svc.TimelineToRSS(context.Background(), conf.Feed{Name: "home", Mastodon: conf.Mastodon{Host: srv.URL, Token: "token"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `<enclosure url="` + media.URL + `/episode.mp3" type="audio/mpeg" length="12345"></enclosure>`
+	if !strings.Contains(out, want) {
+		t.Errorf("expected resolved enclosure %s, got %s", want, out)
+	}
+	// HEAD 失败时保留原始附件信息
+	fallback := `<enclosure url="` + media.URL + `/missing.mp3" type="audio"></enclosure>`
+	if !strings.Contains(out, fallback) {
+		t.Errorf("expected unresolved enclosure %s, got %s", fallback, out)
+	}
+}
+
+func TestMastodonService_IncludeReplies(t *testing.T) {
+	srv := newMastodonServer(t, func(r *http.Request) []map[string]any {
+		reply := mastodonStatus("2", "bob", "replying")
+		reply["in_reply_to_id"] = "1"
+		return []map[string]any{mastodonStatus("1", "alice", "hello"), reply}
+	})
+	svc := service.NewMastodonService()
+	feed := conf.Feed{Name: "home", Mastodon: conf.Mastodon{Host: srv.URL, Token: "token"}}
+
+	out, err := svc.TimelineToRSS(context.Background(), feed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "replying") {
+		t.Errorf("expected replies to be kept by default, got %s", out)
+	}
+
+	include := false
+	feed.Mastodon.IncludeReplies = &include
+	out, err = svc.TimelineToRSS(context.Background(), feed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(out, "replying") {
+		t.Errorf("expected reply to be dropped, got %s", out)
+	}
+	if !strings.Contains(out, "hello") {
+		t.Errorf("expected top-level status to be kept, got %s", out)
+	}
+}
+
+func TestMastodonService_ContentTemplate(t *testing.T) {
+	srv := newMastodonServer(t, func(r *http.Request) []map[string]any {
+		return []map[string]any{mastodonStatus("1", "alice", "hello")}
+	})
+	svc := service.NewMastodonService()
+	out, err := svc.TimelineToRSS(context.Background(), conf.Feed{
+		Name:            "home",
+		Mastodon:        conf.Mastodon{Host: srv.URL, Token: "token"},
+		ContentTemplate: `[{{.Author}}] {{.Content}} via {{.Link}}`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "<description>[ALICE] hello via https://mastodon.example/@alice/1</description>"
+	if !strings.Contains(out, want) {
+		t.Errorf("expected templated description %s, got %s", want, out)
+	}
+}
+
+func TestMastodonService_PartialPagination(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("max_id") != "" {
+			// 第二页失败
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error":"boom"}`))
+			return
+		}
+		w.Header().Set("Link", `<`+srv.URL+`/api/v1/timelines/home?max_id=1>; rel="next"`)
+		json.NewEncoder(w).Encode([]map[string]any{mastodonStatus("2", "alice", "first page")})
+	}))
+	defer srv.Close()
+
+	svc := service.NewMastodonService()
+	feed := conf.Feed{Name: "home", Mastodon: conf.Mastodon{Host: srv.URL, Token: "token", Pages: 3}}
+	out, err := svc.TimelineToRSS(context.Background(), feed)
+	if err != nil {
+		t.Fatalf("expected partial results, got error: %v", err)
+	}
+	if !strings.Contains(out, "first page") {
+		t.Errorf("expected first page items to be rendered, got %s", out)
+	}
+
+	feed.Mastodon.StrictPagination = true
+	if _, err := svc.TimelineToRSS(context.Background(), feed); err == nil {
+		t.Error("expected strict pagination to fail on second page error")
+	}
+}
+
+func TestMastodonService_MaxItemsPagination(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("max_id") {
+		case "":
+			w.Header().Set("Link", `<`+srv.URL+`/api/v1/timelines/home?max_id=3>; rel="next"`)
+			json.NewEncoder(w).Encode([]map[string]any{
+				mastodonStatus("5", "alice", "post 5"),
+				mastodonStatus("4", "alice", "post 4"),
+				mastodonStatus("3", "alice", "post 3"),
+			})
+		case "3":
+			// 第二页与第一页重叠一条，且没有下一页
+			json.NewEncoder(w).Encode([]map[string]any{
+				mastodonStatus("3", "alice", "post 3"),
+				mastodonStatus("2", "bob", "post 2"),
+				mastodonStatus("1", "bob", "post 1"),
+			})
+		default:
+			t.Errorf("unexpected max_id %q", r.URL.Query().Get("max_id"))
+		}
+	}))
+	defer srv.Close()
+
+	svc := service.NewMastodonService()
+	feed := conf.Feed{Name: "home", Mastodon: conf.Mastodon{Host: srv.URL, Token: "token", MaxItems: 10}}
+	channel, err := svc.Timeline(context.Background(), feed)
+	if err != nil {
+		t.Fatalf("Timeline failed: %v", err)
+	}
+	if len(channel.Items) != 5 {
+		t.Fatalf("expected 5 deduplicated items across two pages, got %d", len(channel.Items))
+	}
+
+	feed.Mastodon.MaxItems = 4
+	channel, err = svc.Timeline(context.Background(), feed)
+	if err != nil {
+		t.Fatalf("Timeline failed: %v", err)
+	}
+	if len(channel.Items) != 4 {
+		t.Errorf("expected max_items to cap the timeline at 4, got %d", len(channel.Items))
+	}
+}
+
+func TestMastodonService_TimelineTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 慢速实例，直到客户端放弃请求
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	svc := service.NewMastodonService()
+	svc.SetTimeout(50 * time.Millisecond)
+	feed := conf.Feed{Name: "slow", Mastodon: conf.Mastodon{Host: srv.URL, Token: "token"}}
+
+	start := time.Now()
+	_, err := svc.TimelineToRSS(context.Background(), feed)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected render to abort at the timeout, took %s", elapsed)
+	}
+
+	// 调用方取消同样中止拉取
+	svc.SetTimeout(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start = time.Now()
+	if _, err := svc.TimelineToRSS(ctx, feed); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected render to abort on cancellation, took %s", elapsed)
+	}
+}
+
+// fakeMastodonClient 记录调用的时间线方法，canned 不为空时返回预设的状态
+type fakeMastodonClient struct {
+	calls  []string
+	canned []*mastodon.Status
+}
+
+func (c *fakeMastodonClient) statuses(call string) []*mastodon.Status {
+	c.calls = append(c.calls, call)
+	if c.canned != nil {
+		return c.canned
+	}
+	return []*mastodon.Status{{ID: "1", Content: call}}
+}
+
+func (c *fakeMastodonClient) GetTimelineHome(ctx context.Context, pg *mastodon.Pagination) ([]*mastodon.Status, error) {
+	return c.statuses("home"), nil
+}
+
+func (c *fakeMastodonClient) GetTimelinePublic(ctx context.Context, isLocal bool, pg *mastodon.Pagination) ([]*mastodon.Status, error) {
+	return c.statuses(fmt.Sprintf("public(local=%t)", isLocal)), nil
+}
+
+func (c *fakeMastodonClient) GetTimelineHashtag(ctx context.Context, tag string, isLocal bool, pg *mastodon.Pagination) ([]*mastodon.Status, error) {
+	return c.statuses(fmt.Sprintf("hashtag(%s, local=%t)", tag, isLocal)), nil
+}
+
+func TestMastodonService_FakeClientRSS(t *testing.T) {
+	alice := mastodon.Account{Acct: "alice", DisplayName: "Alice"}
+	bob := mastodon.Account{Acct: "bob@remote.example", DisplayName: "Bob"}
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	client := &fakeMastodonClient{canned: []*mastodon.Status{
+		{
+			ID:        "1",
+			URL:       "https://mastodon.example/@alice/1",
+			Account:   alice,
+			Content:   "<p>hello</p>",
+			CreatedAt: created,
+			MediaAttachments: []mastodon.Attachment{
+				{Type: "image", URL: "https://cdn.example/cat.png", Description: "a cat"},
+			},
+		},
+		{
+			ID:        "2",
+			Account:   alice,
+			CreatedAt: created,
+			Reblog: &mastodon.Status{
+				ID:        "99",
+				URL:       "https://remote.example/@bob/99",
+				Account:   bob,
+				Content:   "<p>boosted</p>",
+				CreatedAt: created,
+			},
+		},
+	}}
+	svc := service.NewMastodonService()
+	svc.SetClientFactory(func(cfg conf.Mastodon) service.MastodonClient { return client })
+
+	out, err := svc.TimelineToRSS(context.Background(), conf.Feed{Name: "home", Mastodon: conf.Mastodon{Host: "https://mastodon.example", Token: "token"}})
+	if err != nil {
+		t.Fatalf("TimelineToRSS failed: %v", err)
+	}
+	parsed, err := gofeed.NewParser().ParseString(out)
+	if err != nil {
+		t.Fatalf("parse rendered RSS: %v", err)
+	}
+	if len(parsed.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(parsed.Items))
+	}
+
+	post, reblog := parsed.Items[0], parsed.Items[1]
+	if post.Title != "Alice (@alice)" {
+		t.Errorf("unexpected post title %q", post.Title)
+	}
+	if want := `<p>hello</p><br><img src="https://cdn.example/cat.png" alt="a cat"/>`; post.Description != want {
+		t.Errorf("expected media HTML in description, got %q", post.Description)
+	}
+	// 转嘟使用原作者作为标题，正文带转嘟前缀
+	if reblog.Title != "Bob (@bob@remote.example)" {
+		t.Errorf("unexpected reblog title %q", reblog.Title)
+	}
+	if want := "@alice 转嘟 @bob@remote.example: <p>boosted</p>"; reblog.Description != want {
+		t.Errorf("expected reblog prefix, got %q", reblog.Description)
+	}
+	if reblog.Link != "https://remote.example/@bob/99" {
+		t.Errorf("expected reblog to link to the original status, got %q", reblog.Link)
+	}
+}
+
+func TestMastodonService_TimelineSelection(t *testing.T) {
+	tests := []struct {
+		timeline string
+		want     string
+	}{
+		{"", "home"},
+		{"home", "home"},
+		{"public", "public(local=false)"},
+		{"local", "public(local=true)"},
+		{"tag:golang", "hashtag(golang, local=false)"},
+	}
+	for _, tt := range tests {
+		client := &fakeMastodonClient{}
+		svc := service.NewMastodonService()
+		svc.SetClientFactory(func(cfg conf.Mastodon) service.MastodonClient { return client })
+
+		feed := conf.Feed{Name: "timeline", Mastodon: conf.Mastodon{Host: "https://mastodon.example", Token: "token", Timeline: tt.timeline}}
+		channel, err := svc.Timeline(context.Background(), feed)
+		if err != nil {
+			t.Fatalf("timeline %q: %v", tt.timeline, err)
+		}
+		if len(client.calls) != 1 || client.calls[0] != tt.want {
+			t.Errorf("timeline %q: expected call %s, got %v", tt.timeline, tt.want, client.calls)
+		}
+		if len(channel.Items) != 1 || channel.Items[0].Description != tt.want {
+			t.Errorf("timeline %q: expected the selected timeline's status, got %+v", tt.timeline, channel.Items)
+		}
+	}
+
+	for _, timeline := range []string{"trending", "tag:"} {
+		client := &fakeMastodonClient{}
+		svc := service.NewMastodonService()
+		svc.SetClientFactory(func(cfg conf.Mastodon) service.MastodonClient { return client })
+
+		feed := conf.Feed{Name: "timeline", Mastodon: conf.Mastodon{Host: "https://mastodon.example", Token: "token", Timeline: timeline}}
+		if _, err := svc.Timeline(context.Background(), feed); err == nil || !strings.Contains(err.Error(), "timeline") {
+			t.Errorf("timeline %q: expected a timeline error, got %v", timeline, err)
+		}
+		if len(client.calls) != 0 {
+			t.Errorf("timeline %q: expected no client calls, got %v", timeline, client.calls)
+		}
+	}
+}
+
+func TestMastodonService_AuthorFilters(t *testing.T) {
+	srv := newMastodonServer(t, func(r *http.Request) []map[string]any {
+		return []map[string]any{
+			mastodonStatus("1", "alice", "from alice"),
+			mastodonStatus("2", "spammer@example.social", "from spammer"),
+			mastodonStatus("3", "bob", "from bob"),
+		}
+	})
+	svc := service.NewMastodonService()
+	feed := conf.Feed{
+		Name:           "home",
+		Mastodon:       conf.Mastodon{Host: srv.URL, Token: "token"},
+		ExcludeAuthors: []string{"@Spammer@example.social"},
+	}
+
+	out, err := svc.TimelineToRSS(context.Background(), feed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(out, "from spammer") {
+		t.Errorf("expected excluded author to be dropped, got %s", out)
+	}
+	if !strings.Contains(out, "from alice") || !strings.Contains(out, "from bob") {
+		t.Errorf("expected other authors to be kept, got %s", out)
+	}
+
+	feed.ExcludeAuthors = nil
+	feed.IncludeAuthors = []string{"alice"}
+	out, err = svc.TimelineToRSS(context.Background(), feed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "from alice") || strings.Contains(out, "from bob") {
+		t.Errorf("expected only included author, got %s", out)
+	}
+}
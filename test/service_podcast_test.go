@@ -0,0 +1,131 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"go.orx.me/apps/unifeed/internal/conf"
+	"go.orx.me/apps/unifeed/internal/service"
+)
+
+func TestRssService_PodcastExtensions(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/podcast.xml")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	src := newFeedServer(t, string(fixture))
+	feed := conf.Feed{Name: "podcast", RssFeed: src.URL}
+	withConfig(t, conf.Config{Feeds: []conf.Feed{feed}})
+
+	svc := newTestRssService(okAIServer(t), newFakeStore())
+	if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+		t.Fatalf("update feed: %v", err)
+	}
+
+	items, err := svc.GetFeedItems(context.Background(), "podcast")
+	if err != nil {
+		t.Fatalf("get feed items: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(items))
+	}
+	item := items[0]
+	if item.Duration != "01:02:03" || item.Episode != "42" {
+		t.Errorf("unexpected itunes fields: duration=%q episode=%q", item.Duration, item.Episode)
+	}
+	if item.Enclosure == nil || item.Enclosure.URL != "https://cdn.example.com/ep42.mp3" ||
+		item.Enclosure.Length != "12345678" || item.Enclosure.Type != "audio/mpeg" {
+		t.Errorf("unexpected enclosure: %+v", item.Enclosure)
+	}
+
+	// 渲染为 RSS 时保留 enclosure 和 itunes 元素
+	r := newTestRouter(svc)
+	w := doRequest(r, http.MethodGet, "/feeds/podcast?format=rss", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		`xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd"`,
+		`<enclosure url="https://cdn.example.com/ep42.mp3" type="audio/mpeg" length="12345678"></enclosure>`,
+		`<itunes:duration>01:02:03</itunes:duration>`,
+		`<itunes:episode>42</itunes:episode>`,
+		`<itunes:image href="https://cdn.example.com/ep42.jpg"></itunes:image>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected rendered output to contain %s\n%s", want, body)
+		}
+	}
+}
+
+func TestHandler_JSONFeedFormat(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/podcast.xml")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	src := newFeedServer(t, string(fixture))
+	feed := conf.Feed{Name: "podcast", RssFeed: src.URL}
+	withConfig(t, conf.Config{Feeds: []conf.Feed{feed}})
+
+	svc := newTestRssService(okAIServer(t), newFakeStore())
+	if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+		t.Fatalf("update feed: %v", err)
+	}
+
+	w := doRequest(newTestRouter(svc), http.MethodGet, "/feeds/podcast?format=jsonfeed", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/feed+json") {
+		t.Errorf("unexpected content type %q", ct)
+	}
+	var out service.JSONFeed
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatalf("decode json feed: %v", err)
+	}
+	if out.Version != "https://jsonfeed.org/version/1.1" {
+		t.Errorf("unexpected version %q", out.Version)
+	}
+	if len(out.Items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(out.Items))
+	}
+	item := out.Items[0]
+	if item.ID != "episode-42" || item.URL != "https://podcast.example.com/42" || item.Title != "Episode 42" {
+		t.Errorf("unexpected item: %+v", item)
+	}
+	if item.DatePublished != "2024-01-01T08:00:00Z" {
+		t.Errorf("unexpected date_published %q", item.DatePublished)
+	}
+	want := service.JSONFeedAttachment{URL: "https://cdn.example.com/ep42.mp3", MimeType: "audio/mpeg", SizeInBytes: 12345678}
+	if len(item.Attachments) != 1 || item.Attachments[0] != want {
+		t.Errorf("unexpected attachments: %+v", item.Attachments)
+	}
+}
+
+func TestRenderJSONFeed_Tags(t *testing.T) {
+	out, err := service.RenderJSONFeed(service.Channel{
+		Title: "Social",
+		Items: []service.RSSItem{{
+			GUID:       "https://social.example.com/1",
+			Title:      "Post",
+			Categories: []string{"go", "feeds"},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("render json feed: %v", err)
+	}
+	var feed service.JSONFeed
+	if err := json.Unmarshal([]byte(out), &feed); err != nil {
+		t.Fatalf("decode json feed: %v", err)
+	}
+	if len(feed.Items) != 1 || strings.Join(feed.Items[0].Tags, ",") != "go,feeds" {
+		t.Errorf("expected categories as tags, got %+v", feed.Items)
+	}
+	if feed.Items[0].Attachments != nil {
+		t.Errorf("expected no attachments without enclosure, got %+v", feed.Items[0].Attachments)
+	}
+}
@@ -0,0 +1,1386 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/sashabaranov/go-openai"
+	"go.orx.me/apps/unifeed/internal/clock"
+	"go.orx.me/apps/unifeed/internal/conf"
+	"go.orx.me/apps/unifeed/internal/dao"
+	"go.orx.me/apps/unifeed/internal/metrics"
+	"go.orx.me/apps/unifeed/internal/service"
+)
+
+func TestRssService_MaxFetchItems(t *testing.T) {
+	src := newFeedServer(t, rssXML(numberedItems(100)...))
+	ai := okAIServer(t)
+	store := newFakeStore()
+	svc := newTestRssService(ai, store)
+
+	feed := conf.Feed{Name: "capped", RssFeed: src.URL, MaxFetchItems: 20}
+	if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	keys := store.Keys("feeds/capped/items/")
+	if len(keys) != 20 {
+		t.Fatalf("expected 20 stored items, got %d", len(keys))
+	}
+	if got := len(ai.Requests()); got != 20 {
+		t.Errorf("expected 20 summarize requests, got %d", got)
+	}
+	// 应保留最新的条目（item-80 ~ item-99）
+	for _, key := range keys {
+		if !strings.Contains(key, "item-8") && !strings.Contains(key, "item-9") {
+			t.Errorf("unexpected older item stored: %s", key)
+		}
+	}
+}
+
+func TestRssService_RegexTransform(t *testing.T) {
+	src := newFeedServer(t, rssXML(rssItem{
+		GUID:        "ad",
+		Title:       "Post",
+		Description: "Hello world. SPONSORED: buy now!",
+	}))
+	ai := okAIServer(t)
+	store := newFakeStore()
+	svc := newTestRssService(ai, store)
+
+	feed := conf.Feed{
+		Name:    "transformed",
+		RssFeed: src.URL,
+		Transforms: []conf.Transform{{
+			Type:    service.TransformRegexReplace,
+			Pattern: `\s*SPONSORED:.*$`,
+		}},
+	}
+	if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var item gofeed.Item
+	readStoredItem(t, store, "feeds/transformed/items/ad.json", &item)
+	if item.Description != "Hello world." {
+		t.Errorf("expected transformed description, got %q", item.Description)
+	}
+	// 摘要请求应使用转换后的内容
+	if strings.Contains(ai.Requests()[0].Messages[0].Content, "SPONSORED") {
+		t.Error("expected summarization to see transformed content")
+	}
+}
+
+func TestRssService_UnescapeDoubleEscapedHTML(t *testing.T) {
+	src := newFeedServer(t, rssXML(
+		rssItem{GUID: "double", Title: "Double", Description: "&lt;p&gt;Fish &amp;amp; chips&lt;/p&gt;"},
+		rssItem{GUID: "normal", Title: "Normal", Description: "<p>Write &lt;b&gt; for bold</p>"},
+	))
+	store := newFakeStore()
+	aiService := service.NewAIService(conf.AIConfig{Disabled: true})
+	svc := service.NewRssService(aiService, store, service.RssConfig{})
+
+	feed := conf.Feed{
+		Name:       "escaped",
+		RssFeed:    src.URL,
+		Transforms: []conf.Transform{{Type: service.TransformUnescapeHTML}},
+	}
+	if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var item gofeed.Item
+	readStoredItem(t, store, "feeds/escaped/items/double.json", &item)
+	if item.Description != "<p>Fish &amp; chips</p>" {
+		t.Errorf("expected the description unescaped once, got %q", item.Description)
+	}
+	readStoredItem(t, store, "feeds/escaped/items/normal.json", &item)
+	if item.Description != "<p>Write &lt;b&gt; for bold</p>" {
+		t.Errorf("expected correctly escaped HTML unchanged, got %q", item.Description)
+	}
+}
+
+func TestStripTrackingParams(t *testing.T) {
+	got := service.StripTrackingParams("https://example.com/a?id=1&utm_source=x&fbclid=y")
+	if got != "https://example.com/a?id=1" {
+		t.Errorf("unexpected url: %s", got)
+	}
+	if got := service.StripTrackingParams("https://example.com/a"); got != "https://example.com/a" {
+		t.Errorf("unexpected url: %s", got)
+	}
+}
+
+func TestRssService_ParseFeedRefetchesTruncatedBody(t *testing.T) {
+	body := rssXML(numberedItems(3)...)
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		if hits.Add(1) == 1 {
+			// 第一次只返回一半内容，模拟连接中断
+			io.WriteString(w, body[:len(body)/2])
+			return
+		}
+		io.WriteString(w, body)
+	}))
+	defer srv.Close()
+
+	svc := newTestRssService(okAIServer(t), newFakeStore())
+	feed, err := svc.ParseFeed(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("ParseFeed returned error: %v", err)
+	}
+	if got := hits.Load(); got != 2 {
+		t.Errorf("expected one refetch, got %d requests", got)
+	}
+	if len(feed.Items) != 3 {
+		t.Errorf("expected 3 items after refetch, got %d", len(feed.Items))
+	}
+}
+
+func TestRssService_ParseFeedGivesUpAfterOneRefetch(t *testing.T) {
+	body := rssXML(numberedItems(3)...)
+	srv := newFeedServer(t, body[:len(body)/2])
+
+	svc := newTestRssService(okAIServer(t), newFakeStore())
+	if _, err := svc.ParseFeed(context.Background(), srv.URL); err == nil {
+		t.Fatal("expected parse error for persistently truncated body")
+	}
+	if got := srv.Hits(); got != 2 {
+		t.Errorf("expected 2 requests, got %d", got)
+	}
+}
+
+func TestRssService_ContentTemplate(t *testing.T) {
+	src := newFeedServer(t, rssXML(numberedItems(1)...))
+	feed := conf.Feed{
+		Name:            "blog",
+		RssFeed:         src.URL,
+		ContentTemplate: `<h1>{{.Title}}</h1>{{if .Summary}}<p>{{.Summary}}</p>{{end}}{{.Description}}`,
+	}
+	svc := newTestRssService(okAIServer(t), newFakeStore())
+	if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+		t.Fatalf("update feed: %v", err)
+	}
+
+	channel, err := svc.GetChannel(context.Background(), feed)
+	if err != nil {
+		t.Fatalf("get channel: %v", err)
+	}
+	if len(channel.Items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(channel.Items))
+	}
+	want := "<h1>Item 0</h1><p>summary</p>Content of item 0"
+	if got := channel.Items[0].Description; got != want {
+		t.Errorf("expected templated description %q, got %q", want, got)
+	}
+}
+
+func TestRssService_ChannelUsesUpstreamMeta(t *testing.T) {
+	src := newFeedServer(t, rssXML(numberedItems(1)...))
+	feed := conf.Feed{Name: "blog", RssFeed: src.URL}
+	store := newFakeStore()
+	svc := newTestRssService(okAIServer(t), store)
+	if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+		t.Fatalf("update feed: %v", err)
+	}
+
+	// 未配置标题和描述时使用上游的频道信息，重启后从存储读取
+	for _, svc := range []*service.RssService{svc, newTestRssService(okAIServer(t), store)} {
+		channel, err := svc.GetChannel(context.Background(), feed)
+		if err != nil {
+			t.Fatalf("get channel: %v", err)
+		}
+		if channel.Title != "Test" || channel.Description != "Test feed" {
+			t.Errorf("expected upstream title and description, got %q / %q", channel.Title, channel.Description)
+		}
+	}
+
+	// 配置中的覆盖值优先于上游
+	feed.Title, feed.Description = "My Blog", "Curated"
+	channel, err := svc.GetChannel(context.Background(), feed)
+	if err != nil {
+		t.Fatalf("get channel: %v", err)
+	}
+	if channel.Title != "My Blog" || channel.Description != "Curated" {
+		t.Errorf("expected configured title and description, got %q / %q", channel.Title, channel.Description)
+	}
+
+	// 从未更新过的 Feed 退回到名称
+	channel, err = svc.GetChannel(context.Background(), conf.Feed{Name: "fresh", RssFeed: src.URL})
+	if err != nil {
+		t.Fatalf("get channel: %v", err)
+	}
+	if channel.Title != "fresh" || channel.Description != "fresh" {
+		t.Errorf("expected the feed name as fallback, got %q / %q", channel.Title, channel.Description)
+	}
+}
+
+func TestRssService_ParseFeedSharesConcurrentFetches(t *testing.T) {
+	body := rssXML(numberedItems(2)...)
+	release := make(chan struct{})
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		io.WriteString(w, body)
+	}))
+	defer srv.Close()
+
+	svc := newTestRssService(okAIServer(t), newFakeStore())
+	const callers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := svc.ParseFeed(context.Background(), srv.URL); err != nil {
+				errs <- err
+			}
+		}()
+	}
+
+	// 等待首个请求到达上游后再放行，让其余调用有机会并入
+	for hits.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("ParseFeed returned error: %v", err)
+	}
+	if got := hits.Load(); got != 1 {
+		t.Errorf("expected a single upstream fetch, got %d", got)
+	}
+}
+
+func TestRssService_SkipUnchangedBody(t *testing.T) {
+	src := newFeedServer(t, rssXML(numberedItems(2)...))
+	ai := okAIServer(t)
+	store := newFakeStore()
+	aiService := service.NewAIService(conf.AIConfig{Endpoint: ai.URL, APIKey: "key", SummaryCacheSize: -1})
+	svc := service.NewRssService(aiService, store, service.RssConfig{SkipUnchanged: true})
+	feed := conf.Feed{Name: "unchanged", RssFeed: src.URL}
+	unchanged := metrics.FeedUpdateTotal.WithLabelValues(feed.Name, "unchanged")
+
+	if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+		t.Fatalf("first update: %v", err)
+	}
+	aiCalls, puts := len(ai.Requests()), len(store.Puts())
+	if aiCalls == 0 || puts == 0 {
+		t.Fatalf("expected first run to summarize and store, got %d AI calls and %d puts", aiCalls, puts)
+	}
+
+	before := counterValue(t, unchanged)
+	if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+		t.Fatalf("second update: %v", err)
+	}
+	if got := len(ai.Requests()); got != aiCalls {
+		t.Errorf("expected no new AI calls for unchanged body, got %d", got-aiCalls)
+	}
+	if got := len(store.Puts()); got != puts {
+		t.Errorf("expected no new writes for unchanged body, got %d", got-puts)
+	}
+	if got := counterValue(t, unchanged) - before; got != 1 {
+		t.Errorf("expected unchanged metric to increase by 1, got %v", got)
+	}
+}
+
+func TestRssService_SkipUnchangedRetriesFailedSummaries(t *testing.T) {
+	src := newFeedServer(t, rssXML(numberedItems(2)...))
+	var fail atomic.Bool
+	fail.Store(true)
+	ai := newAIServer(t, func(req openai.ChatCompletionRequest) (int, string) {
+		if fail.Load() {
+			return http.StatusInternalServerError, ""
+		}
+		return http.StatusOK, "summary"
+	})
+	store := newFakeStore()
+	aiService := service.NewAIService(conf.AIConfig{Endpoint: ai.URL, APIKey: "key", SummaryCacheSize: -1})
+	aiService.SetMaxRetries(1)
+	svc := service.NewRssService(aiService, store, service.RssConfig{SkipUnchanged: true, RetryDelay: time.Millisecond})
+	feed := conf.Feed{Name: "flaky-ai", RssFeed: src.URL}
+	prefix := "feeds/flaky-ai/items/"
+
+	// AI 不可用时条目原样存储
+	if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+		t.Fatalf("first update: %v", err)
+	}
+	if got := len(summarizedKeys(t, store, prefix)); got != 0 {
+		t.Fatalf("expected no summaries while the AI fails, got %d", got)
+	}
+
+	// 内容不变但上次摘要失败，恢复后重新生成
+	fail.Store(false)
+	if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+		t.Fatalf("second update: %v", err)
+	}
+	if got := len(summarizedKeys(t, store, prefix)); got != 2 {
+		t.Errorf("expected failed summaries to be retried on an unchanged body, got %d summarized", got)
+	}
+
+	// 全部完成后相同内容再次跳过
+	calls := len(ai.Requests())
+	if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+		t.Fatalf("third update: %v", err)
+	}
+	if got := len(ai.Requests()); got != calls {
+		t.Errorf("expected the completed body to be skipped, got %d new AI calls", got-calls)
+	}
+}
+
+// summarizedKeys 返回已存储且带摘要的条目
+func summarizedKeys(t *testing.T, store *fakeStore, prefix string) []string {
+	t.Helper()
+	var keys []string
+	for _, key := range store.Keys(prefix) {
+		var item gofeed.Item
+		readStoredItem(t, store, key, &item)
+		if item.Custom["summary"] != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func TestRssService_FastStartBackfillsSummaries(t *testing.T) {
+	src := newFeedServer(t, rssXML(numberedItems(5)...))
+	ai := okAIServer(t)
+	store := newFakeStore()
+	aiService := service.NewAIService(conf.AIConfig{Endpoint: ai.URL, APIKey: "key", SummaryCacheSize: -1})
+	svc := service.NewRssService(aiService, store, service.RssConfig{FastStart: true, BackfillBatch: 2})
+	feed := conf.Feed{Name: "fast", RssFeed: src.URL}
+	prefix := "feeds/fast/items/"
+
+	if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+		t.Fatalf("first update: %v", err)
+	}
+	if got := len(store.Keys(prefix)); got != 5 {
+		t.Fatalf("expected first run to store 5 items, got %d", got)
+	}
+	if got := len(ai.Requests()); got != 0 {
+		t.Errorf("expected no AI calls on first run, got %d", got)
+	}
+
+	// 后续更新每次补全 BackfillBatch 个摘要，已有摘要不会被覆盖
+	for run, want := range []int{2, 4, 5} {
+		if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+			t.Fatalf("update %d: %v", run+2, err)
+		}
+		if got := len(summarizedKeys(t, store, prefix)); got != want {
+			t.Errorf("after update %d expected %d summarized items, got %d", run+2, want, got)
+		}
+	}
+	if got := len(ai.Requests()); got != 5 {
+		t.Errorf("expected each item to be summarized once, got %d AI calls", got)
+	}
+}
+
+func TestRssService_FastStartBackfillsUnchangedFeed(t *testing.T) {
+	src := newFeedServer(t, rssXML(numberedItems(2)...))
+	ai := okAIServer(t)
+	store := newFakeStore()
+	aiService := service.NewAIService(conf.AIConfig{Endpoint: ai.URL, APIKey: "key", SummaryCacheSize: -1})
+	svc := service.NewRssService(aiService, store, service.RssConfig{FastStart: true, SkipUnchanged: true})
+	feed := conf.Feed{Name: "quiet", RssFeed: src.URL}
+	prefix := "feeds/quiet/items/"
+
+	if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+		t.Fatalf("first update: %v", err)
+	}
+	if got := len(summarizedKeys(t, store, prefix)); got != 0 {
+		t.Fatalf("expected the fast start run to store items without summaries, got %d summarized", got)
+	}
+
+	// 上游内容不变时仍补全快速启动留下的摘要
+	if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+		t.Fatalf("second update: %v", err)
+	}
+	if got := len(summarizedKeys(t, store, prefix)); got != 2 {
+		t.Errorf("expected an unchanged feed to be backfilled, got %d summarized items", got)
+	}
+}
+
+func TestRssService_RetriesPendingSummaries(t *testing.T) {
+	src := newFeedServer(t, rssXML(numberedItems(2)...))
+	var fail atomic.Bool
+	fail.Store(true)
+	ai := newAIServer(t, func(req openai.ChatCompletionRequest) (int, string) {
+		if fail.Load() && strings.Contains(req.Messages[len(req.Messages)-1].Content, "item 1") {
+			return http.StatusInternalServerError, ""
+		}
+		return http.StatusOK, "summary"
+	})
+	store := newFakeStore()
+	svc := newTestRssService(ai, store)
+	feed := conf.Feed{Name: "pending", RssFeed: src.URL}
+
+	if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+		t.Fatalf("first update: %v", err)
+	}
+	items, err := svc.GetFeedItems(context.Background(), feed.Name)
+	if err != nil {
+		t.Fatalf("get items: %v", err)
+	}
+	pending := 0
+	for _, item := range items {
+		if item.SummaryPending {
+			pending++
+			if item.Summary != "" {
+				t.Errorf("pending item should have no summary, got %q", item.Summary)
+			}
+		}
+	}
+	if pending != 1 {
+		t.Fatalf("expected 1 pending item after failed summary, got %d", pending)
+	}
+
+	// 第二次更新只重试待生成的条目
+	fail.Store(false)
+	calls := len(ai.Requests())
+	if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+		t.Fatalf("second update: %v", err)
+	}
+	if got := len(ai.Requests()) - calls; got != 1 {
+		t.Errorf("expected only the pending item to be retried, got %d AI calls", got)
+	}
+	items, err = svc.GetFeedItems(context.Background(), feed.Name)
+	if err != nil {
+		t.Fatalf("get items: %v", err)
+	}
+	for _, item := range items {
+		if item.Summary != "summary" || item.SummaryPending {
+			t.Errorf("expected %s to be summarized, got summary %q pending %v", item.Title, item.Summary, item.SummaryPending)
+		}
+	}
+}
+
+func TestRssService_ExcludeAuthors(t *testing.T) {
+	src := newFeedServer(t, rssXML(
+		rssItem{GUID: "a", Title: "Kept", Description: "kept", Author: "alice@example.com (Alice)"},
+		rssItem{GUID: "b", Title: "Dropped", Description: "dropped", Author: "bot@example.com (Release Bot)"},
+		rssItem{GUID: "c", Title: "Anonymous", Description: "no author"},
+	))
+	store := newFakeStore()
+	svc := newTestRssService(okAIServer(t), store)
+	feed := conf.Feed{Name: "authors", RssFeed: src.URL, ExcludeAuthors: []string{"release bot"}}
+
+	if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	keys := store.Keys("feeds/authors/items/")
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 stored items, got %v", keys)
+	}
+	for _, key := range keys {
+		if strings.HasSuffix(key, "/b.json") {
+			t.Errorf("expected excluded author's item to be dropped, got %s", key)
+		}
+	}
+}
+
+func TestRssService_ConditionalGet(t *testing.T) {
+	body := rssXML(numberedItems(2)...)
+	const etag = `"v1"`
+	const lastModified = "Mon, 01 Jan 2024 00:00:00 GMT"
+	var full, notModified atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag && r.Header.Get("If-Modified-Since") == lastModified {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full.Add(1)
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastModified)
+		io.WriteString(w, body)
+	}))
+	defer srv.Close()
+
+	svc := newTestRssService(okAIServer(t), newFakeStore())
+	hits := metrics.FeedNotModified.WithLabelValues(srv.URL)
+	before := counterValue(t, hits)
+
+	first, err := svc.ParseFeed(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("first parse: %v", err)
+	}
+
+	// 丢弃解析缓存后重新请求，上游返回 304 时复用上次的结果
+	svc.InvalidateFeedCache(srv.URL)
+	second, err := svc.ParseFeed(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("second parse: %v", err)
+	}
+	if full.Load() != 1 || notModified.Load() != 1 {
+		t.Fatalf("expected 1 full and 1 conditional response, got %d and %d", full.Load(), notModified.Load())
+	}
+	if second != first || len(second.Items) != 2 {
+		t.Errorf("expected cached feed on 304, got %+v", second)
+	}
+	if got := counterValue(t, hits) - before; got != 1 {
+		t.Errorf("expected 1 not-modified hit, got %v", got)
+	}
+}
+
+func TestRssService_FeedAIEndpointOverride(t *testing.T) {
+	src := newFeedServer(t, rssXML(numberedItems(2)...))
+	global := okAIServer(t)
+	private := newAIServer(t, func(req openai.ChatCompletionRequest) (int, string) {
+		return http.StatusOK, "private summary"
+	})
+	logs := captureLogs(t)
+
+	store := newFakeStore()
+	svc := newTestRssService(global, store)
+	feed := conf.Feed{
+		Name:    "private",
+		RssFeed: src.URL,
+		AI:      conf.FeedAIConfig{Endpoint: private.URL, APIKey: "sk-private-secret-1234", Model: "internal-model"},
+	}
+	svc.SetFeedAIService(feed.Name, svc.AIService().ForFeed(feed))
+
+	if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+		t.Fatalf("update feed: %v", err)
+	}
+
+	if got := len(global.Requests()); got != 0 {
+		t.Errorf("expected no requests to the global endpoint, got %d", got)
+	}
+	requests := private.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 requests to the feed endpoint, got %d", len(requests))
+	}
+	if requests[0].Model != "internal-model" {
+		t.Errorf("expected feed model override, got %q", requests[0].Model)
+	}
+	for _, key := range store.Keys("feeds/private/items/") {
+		var item gofeed.Item
+		readStoredItem(t, store, key, &item)
+		if item.Custom["summary"] != "private summary" {
+			t.Errorf("%s: expected summary from feed endpoint, got %q", key, item.Custom["summary"])
+		}
+	}
+
+	if strings.Contains(logs.String(), "sk-private-secret-1234") {
+		t.Errorf("api key leaked into logs: %s", logs.String())
+	}
+	if !strings.Contains(logs.String(), "****1234") {
+		t.Errorf("expected masked api key in logs: %s", logs.String())
+	}
+}
+
+func TestRssService_FetchTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	aiService := service.NewAIService(conf.AIConfig{Endpoint: okAIServer(t).URL, APIKey: "key"})
+	svc := service.NewRssService(aiService, newFakeStore(), service.RssConfig{HTTPTimeout: 50 * time.Millisecond})
+
+	start := time.Now()
+	_, err := svc.ParseFeed(context.Background(), srv.URL)
+	if err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("fetch took too long: %v", elapsed)
+	}
+
+	// 调用方取消也会中止请求
+	svc = service.NewRssService(aiService, newFakeStore(), service.RssConfig{})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	if _, err := svc.ParseFeed(ctx, srv.URL); err == nil {
+		t.Fatal("expected cancellation error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("cancelled fetch took too long: %v", elapsed)
+	}
+}
+
+func TestRssService_PermanentRedirect(t *testing.T) {
+	moved := newFeedServer(t, rssXML(numberedItems(1)...))
+	var oldHits atomic.Int32
+	old := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		oldHits.Add(1)
+		http.Redirect(w, r, moved.URL+"/feed.xml", http.StatusMovedPermanently)
+	}))
+	defer old.Close()
+
+	svc := newTestRssService(okAIServer(t), newFakeStore())
+	if _, err := svc.ParseFeed(context.Background(), old.URL); err != nil {
+		t.Fatalf("first parse: %v", err)
+	}
+	if got := svc.FeedURL(old.URL); got != moved.URL+"/feed.xml" {
+		t.Errorf("expected new feed URL to be recorded, got %s", got)
+	}
+
+	// 之后直接请求新地址
+	svc.InvalidateFeedCache(old.URL)
+	if _, err := svc.ParseFeed(context.Background(), old.URL); err != nil {
+		t.Fatalf("second parse: %v", err)
+	}
+	if got := oldHits.Load(); got != 1 {
+		t.Errorf("expected the old URL to be fetched once, got %d", got)
+	}
+	if got := moved.Hits(); got != 2 {
+		t.Errorf("expected both fetches to reach the new URL, got %d", got)
+	}
+}
+
+func TestRssService_TemporaryRedirectNotRecorded(t *testing.T) {
+	moved := newFeedServer(t, rssXML(numberedItems(1)...))
+	old := httptest.NewServer(http.RedirectHandler(moved.URL, http.StatusFound))
+	defer old.Close()
+
+	svc := newTestRssService(okAIServer(t), newFakeStore())
+	if _, err := svc.ParseFeed(context.Background(), old.URL); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got := svc.FeedURL(old.URL); got != old.URL {
+		t.Errorf("expected temporary redirect to keep the configured URL, got %s", got)
+	}
+}
+
+func TestRssService_CacheSummaries(t *testing.T) {
+	src := newFeedServer(t, rssXML(numberedItems(2)...))
+	ai := okAIServer(t)
+	store := newFakeStore()
+	aiService := service.NewAIService(conf.AIConfig{Endpoint: ai.URL, APIKey: "key", SummaryCacheSize: -1})
+	svc := service.NewRssService(aiService, store, service.RssConfig{RetryDelay: time.Millisecond, CacheSummaries: true})
+
+	if err := svc.UpdateFeed(context.Background(), conf.Feed{Name: "first", RssFeed: src.URL}); err != nil {
+		t.Fatalf("update first feed: %v", err)
+	}
+	if got := len(ai.Requests()); got != 2 {
+		t.Fatalf("expected 2 AI calls, got %d", got)
+	}
+	if got := len(store.Keys("summaries/")); got != 2 {
+		t.Fatalf("expected 2 cached summaries, got %d", got)
+	}
+
+	// 相同内容的另一个 Feed 直接使用缓存的摘要
+	if err := svc.UpdateFeed(context.Background(), conf.Feed{Name: "second", RssFeed: src.URL}); err != nil {
+		t.Fatalf("update second feed: %v", err)
+	}
+	if got := len(ai.Requests()); got != 2 {
+		t.Errorf("expected cached summaries to skip the AI, got %d calls", got)
+	}
+	if got := len(summarizedKeys(t, store, "feeds/second/")); got != 2 {
+		t.Errorf("expected both items of the second feed to carry summaries, got %d", got)
+	}
+
+	// 切换摘要语言后不再使用旧提示词生成的摘要
+	english := service.NewAIService(conf.AIConfig{Endpoint: ai.URL, APIKey: "key", SummaryCacheSize: -1, Language: "en"})
+	svc = service.NewRssService(english, store, service.RssConfig{RetryDelay: time.Millisecond, CacheSummaries: true})
+	if err := svc.UpdateFeed(context.Background(), conf.Feed{Name: "third", RssFeed: src.URL}); err != nil {
+		t.Fatalf("update third feed: %v", err)
+	}
+	if got := len(ai.Requests()); got != 4 {
+		t.Errorf("expected a language change to miss the cache, got %d AI calls", got)
+	}
+	if got := len(store.Keys("summaries/")); got != 4 {
+		t.Errorf("expected summaries cached per language, got %d", got)
+	}
+}
+
+func TestRssService_CacheEvictsLeastRecentlyUsed(t *testing.T) {
+	src := newFeedServer(t, rssXML(numberedItems(1)...))
+	svc := service.NewRssService(service.NewAIService(conf.AIConfig{Disabled: true}), newFakeStore(), service.RssConfig{
+		MaxCacheSize: 2,
+	})
+	evictions := metrics.FeedCacheEvictions.WithLabelValues("size")
+	before := counterValue(t, evictions)
+
+	ctx := context.Background()
+	parse := func(name string) {
+		t.Helper()
+		if _, err := svc.ParseFeed(ctx, src.URL+"/"+name); err != nil {
+			t.Fatalf("parse %s: %v", name, err)
+		}
+	}
+	parse("a")
+	parse("b")
+	parse("a") // a 成为最近使用
+	parse("c") // 淘汰 b
+	if got := src.Hits(); got != 3 {
+		t.Fatalf("expected 3 fetches, got %d", got)
+	}
+	if got := counterValue(t, evictions) - before; got != 1 {
+		t.Errorf("expected 1 size eviction, got %v", got)
+	}
+
+	parse("a")
+	parse("c")
+	if got := src.Hits(); got != 3 {
+		t.Errorf("expected a and c to stay cached, got %d fetches", got)
+	}
+	parse("b")
+	if got := src.Hits(); got != 4 {
+		t.Errorf("expected evicted b to be fetched again, got %d fetches", got)
+	}
+}
+
+func TestRssService_CacheExpires(t *testing.T) {
+	src := newFeedServer(t, rssXML(numberedItems(1)...))
+	svc := service.NewRssService(service.NewAIService(conf.AIConfig{Disabled: true}), newFakeStore(), service.RssConfig{
+		CacheDuration: time.Minute,
+	})
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	svc.SetClock(fake)
+	evictions := metrics.FeedCacheEvictions.WithLabelValues("expired")
+	before := counterValue(t, evictions)
+	expiredMisses := metrics.FeedCacheMisses.WithLabelValues(metrics.CacheMissExpired)
+	missesBefore := counterValue(t, expiredMisses)
+
+	ctx := context.Background()
+	for _, step := range []struct {
+		advance time.Duration
+		hits    int
+	}{
+		{0, 1},
+		{time.Minute, 1}, // 恰好到期时仍有效
+		{time.Second, 2},
+		{30 * time.Second, 2},
+	} {
+		fake.Advance(step.advance)
+		if _, err := svc.ParseFeed(ctx, src.URL); err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		if got := src.Hits(); got != step.hits {
+			t.Fatalf("after %v: expected %d fetches, got %d", step.advance, step.hits, got)
+		}
+	}
+	if got := counterValue(t, evictions) - before; got != 1 {
+		t.Errorf("expected 1 expired eviction, got %v", got)
+	}
+	// 过期的条目单独计为 expired 未命中
+	if got := counterValue(t, expiredMisses) - missesBefore; got != 1 {
+		t.Errorf("expected 1 expired cache miss, got %v", got)
+	}
+}
+
+func TestRssService_DeadLetterAfterRepeatedStoreFailures(t *testing.T) {
+	src := newFeedServer(t, rssXML(
+		rssItem{GUID: "good", Title: "Good", Link: "https://example.com/good", Description: "Good body"},
+		rssItem{GUID: "bad", Title: "Bad", Link: "https://example.com/bad", Description: "Bad body"},
+	))
+	store := newFakeStore()
+	store.putErr = func(objectName string) error {
+		if objectName == "feeds/blog/items/bad.json" {
+			return fmt.Errorf("object rejected")
+		}
+		return nil
+	}
+	aiService := service.NewAIService(conf.AIConfig{Disabled: true})
+	svc := service.NewRssService(aiService, store, service.RssConfig{DeadLetterAfter: 2})
+	feed := conf.Feed{Name: "blog", RssFeed: src.URL}
+
+	if err := svc.UpdateFeed(context.Background(), feed); err == nil {
+		t.Fatal("expected the first update to fail")
+	}
+	if keys := store.Keys("deadletter/"); len(keys) != 0 {
+		t.Fatalf("expected no dead letters after one failure, got %v", keys)
+	}
+
+	if err := svc.UpdateFeed(context.Background(), feed); err == nil {
+		t.Fatal("expected the second update to fail")
+	}
+	keys := store.Keys(service.DeadLetterPrefix("blog"))
+	if len(keys) != 1 || keys[0] != "deadletter/blog/bad.json" {
+		t.Fatalf("expected only the failing item in the dead-letter prefix, got %v", keys)
+	}
+
+	var letter service.DeadLetter
+	readStoredItem(t, store, keys[0], &letter)
+	if letter.Stage != service.DeadLetterStore || letter.Attempts != 2 || letter.Item == nil || letter.Item.GUID != "bad" {
+		t.Errorf("unexpected dead letter: %+v", letter)
+	}
+	if !strings.Contains(letter.Reason, "object rejected") {
+		t.Errorf("expected the store error as reason, got %q", letter.Reason)
+	}
+}
+
+func TestRssService_ItemCountAlarm(t *testing.T) {
+	src := newFeedServer(t, rssXML(numberedItems(50)...))
+	aiService := service.NewAIService(conf.AIConfig{Disabled: true})
+	svc := service.NewRssService(aiService, newFakeStore(), service.RssConfig{ItemCountAlarmRatio: 0.2})
+	feed := conf.Feed{Name: "item-count-alarm", RssFeed: src.URL}
+	alarms := metrics.FeedItemCountAlarms.WithLabelValues(feed.Name)
+	before := counterValue(t, alarms)
+
+	for run := 0; run < 4; run++ {
+		if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+			t.Fatalf("update %d: %v", run+1, err)
+		}
+		svc.InvalidateFeedCache(src.URL)
+	}
+	if got := counterValue(t, alarms) - before; got != 0 {
+		t.Fatalf("expected no alarm for steady item counts, got %v", got)
+	}
+
+	// 上游突然只返回 1 个条目
+	src.SetBody(rssXML(numberedItems(1)...))
+	if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if got := counterValue(t, alarms) - before; got != 1 {
+		t.Errorf("expected the item count alarm to fire once, got %v", got)
+	}
+}
+
+func TestRssService_StoredItemsSortedByPublished(t *testing.T) {
+	store := newFakeStore()
+	svc := newTestRssService(okAIServer(t), store)
+	stored := []struct {
+		key  string
+		item map[string]any
+	}{
+		{"b", map[string]any{"guid": "b", "title": "No date B"}},
+		{"old", map[string]any{"guid": "old", "title": "Old", "publishedParsed": "2024-01-01T00:00:00Z"}},
+		{"a", map[string]any{"guid": "a", "title": "Bad date A", "published": "yesterday"}},
+		{"new", map[string]any{"guid": "new", "title": "New", "publishedParsed": "2024-03-01T00:00:00Z"}},
+		{"mid", map[string]any{"guid": "mid", "title": "Mid", "published": "Thu, 01 Feb 2024 00:00:00 +0000"}},
+	}
+	for _, s := range stored {
+		data, _ := json.Marshal(s.item)
+		if err := store.PutObject(context.Background(), "feeds/blog/items/"+s.key+".json", data, dao.PutOptions{}); err != nil {
+			t.Fatalf("put %s: %v", s.key, err)
+		}
+	}
+
+	items, err := svc.GetStoredFeedItems(context.Background(), "blog")
+	if err != nil {
+		t.Fatalf("GetStoredFeedItems failed: %v", err)
+	}
+	var titles []string
+	for _, item := range items {
+		titles = append(titles, item["title"].(string))
+	}
+	want := []string{"New", "Mid", "Old", "Bad date A", "No date B"}
+	if strings.Join(titles, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, titles)
+	}
+}
+
+// peakTracker 记录同时进行的调用数峰值
+type peakTracker struct {
+	inFlight, peak atomic.Int32
+}
+
+// hook 返回模拟慢速存储的钩子，调用期间计入并发数
+func (p *peakTracker) hook(string) error {
+	n := p.inFlight.Add(1)
+	defer p.inFlight.Add(-1)
+	for {
+		peak := p.peak.Load()
+		if n <= peak || p.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond)
+	return nil
+}
+
+func TestRssService_StoreConcurrencyLimit(t *testing.T) {
+	store := newFakeStore()
+	var writes peakTracker
+	store.putErr = writes.hook
+	aiService := service.NewAIService(conf.AIConfig{Disabled: true})
+	svc := service.NewRssService(aiService, store, service.RssConfig{StoreConcurrency: 4, StoreBatchSize: 2})
+
+	var items []*gofeed.Item
+	for i := 0; i < 50; i++ {
+		items = append(items, &gofeed.Item{GUID: fmt.Sprintf("item-%d", i), Title: fmt.Sprintf("Item %d", i)})
+	}
+	if err := svc.StoreFeedItems(context.Background(), "concurrency", items); err != nil {
+		t.Fatalf("StoreFeedItems failed: %v", err)
+	}
+
+	if got := writes.peak.Load(); got > 4 {
+		t.Errorf("expected at most 4 concurrent writes, got %d", got)
+	}
+	if keys := store.Keys("feeds/concurrency/items/"); len(keys) != 50 {
+		t.Errorf("expected 50 stored items, got %d", len(keys))
+	}
+}
+
+func TestRssService_MaxConcurrencyBoundsReadsAndWrites(t *testing.T) {
+	store := newFakeStore()
+	var writes, reads peakTracker
+	store.putErr = writes.hook
+	store.getHook = reads.hook
+	aiService := service.NewAIService(conf.AIConfig{Disabled: true})
+	svc := service.NewRssService(aiService, store, service.RssConfig{MaxConcurrency: 8})
+
+	var items []*gofeed.Item
+	for i := 0; i < 1000; i++ {
+		items = append(items, &gofeed.Item{GUID: fmt.Sprintf("item-%d", i), Title: fmt.Sprintf("Item %d", i)})
+	}
+	if err := svc.StoreFeedItems(context.Background(), "bounded", items); err != nil {
+		t.Fatalf("StoreFeedItems failed: %v", err)
+	}
+	stored, err := svc.GetStoredFeedItems(context.Background(), "bounded")
+	if err != nil {
+		t.Fatalf("GetStoredFeedItems failed: %v", err)
+	}
+	if len(stored) != 1000 {
+		t.Errorf("expected 1000 items, got %d", len(stored))
+	}
+
+	if got := writes.peak.Load(); got > 8 {
+		t.Errorf("expected at most 8 concurrent writes, got %d", got)
+	}
+	if got := reads.peak.Load(); got > 8 {
+		t.Errorf("expected at most 8 concurrent reads, got %d", got)
+	}
+
+	// 读取失败仍返回错误
+	store.getHook = func(objectName string) error {
+		if objectName == "feeds/bounded/items/item-500.json" {
+			return fmt.Errorf("read failed")
+		}
+		return nil
+	}
+	svc = service.NewRssService(aiService, store, service.RssConfig{MaxConcurrency: 8, MaxRetries: 1, RetryDelay: time.Millisecond})
+	if _, err := svc.GetStoredFeedItems(context.Background(), "bounded"); err == nil || !strings.Contains(err.Error(), "read failed") {
+		t.Errorf("expected the read error to be returned, got %v", err)
+	}
+}
+
+func TestRssService_PrunesItemsBeyondMaxStored(t *testing.T) {
+	src := newFeedServer(t, rssXML(numberedItems(5)...))
+	store := newFakeStore()
+	aiService := service.NewAIService(conf.AIConfig{Disabled: true})
+	svc := service.NewRssService(aiService, store, service.RssConfig{})
+	feed := conf.Feed{Name: "retention", RssFeed: src.URL, MaxStoredItems: 3}
+	pruned := metrics.FeedItemsPruned.WithLabelValues(feed.Name)
+	before := counterValue(t, pruned)
+
+	if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	want := "feeds/retention/items/item-2.json,feeds/retention/items/item-3.json,feeds/retention/items/item-4.json"
+	if keys := store.Keys("feeds/retention/items/"); strings.Join(keys, ",") != want {
+		t.Fatalf("expected the 3 newest items to remain, got %v", keys)
+	}
+
+	// 新条目到达后继续删除最旧的条目
+	src.SetBody(rssXML(numberedItems(7)...))
+	svc.InvalidateFeedCache(src.URL)
+	if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	want = "feeds/retention/items/item-4.json,feeds/retention/items/item-5.json,feeds/retention/items/item-6.json"
+	if keys := store.Keys("feeds/retention/items/"); strings.Join(keys, ",") != want {
+		t.Errorf("expected the 3 newest items to remain, got %v", keys)
+	}
+	if got := counterValue(t, pruned) - before; got != 6 {
+		t.Errorf("expected 6 pruned items, got %v", got)
+	}
+
+	items, err := svc.GetStoredFeedItems(context.Background(), feed.Name)
+	if err != nil || len(items) != 3 {
+		t.Errorf("expected the item cache to reflect pruning, got %d items: %v", len(items), err)
+	}
+}
+
+func TestRssService_DailyAIBudget(t *testing.T) {
+	src := newFeedServer(t, rssXML(numberedItems(5)...))
+	ai := okAIServer(t)
+	store := newFakeStore()
+	svc := newTestRssService(ai, store)
+	fake := clock.NewFake(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+	svc.SetClock(fake)
+	// 每次请求消耗 100 token，第三次请求后超出上限
+	svc.SetFeedAIBudget("budget", 250, 0)
+	feed := conf.Feed{Name: "budget", RssFeed: src.URL}
+	skipped := metrics.AIBudgetSkippedItems.WithLabelValues(feed.Name)
+	before := counterValue(t, skipped)
+
+	summarized := func() int {
+		n := 0
+		for _, key := range store.Keys("feeds/budget/items/") {
+			var item gofeed.Item
+			readStoredItem(t, store, key, &item)
+			if item.Custom["summary"] != "" {
+				n++
+			}
+		}
+		return n
+	}
+
+	if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if n := len(ai.Requests()); n != 3 {
+		t.Fatalf("expected summarization to stop after 3 requests, got %d", n)
+	}
+	if n := summarized(); n != 3 {
+		t.Errorf("expected 3 summarized items, got %d", n)
+	}
+	if keys := store.Keys("feeds/budget/items/"); len(keys) != 5 {
+		t.Errorf("expected all 5 items stored, got %d", len(keys))
+	}
+	if got := counterValue(t, skipped) - before; got != 2 {
+		t.Errorf("expected 2 skipped items, got %v", got)
+	}
+
+	// 同一天内不再调用 AI
+	svc.InvalidateFeedCache(src.URL)
+	if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if n := len(ai.Requests()); n != 3 {
+		t.Errorf("expected no requests once the budget is exhausted, got %d", n)
+	}
+
+	// 次日重新计数，补全剩余摘要
+	fake.Advance(24 * time.Hour)
+	svc.InvalidateFeedCache(src.URL)
+	if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if n := len(ai.Requests()); n != 5 {
+		t.Errorf("expected summarization to resume the next day, got %d requests", n)
+	}
+	if n := summarized(); n != 5 {
+		t.Errorf("expected all items summarized the next day, got %d", n)
+	}
+}
+
+func TestRssService_StoredItemReadErrorsAggregated(t *testing.T) {
+	store := newFakeStore()
+	for i := 0; i < 6; i++ {
+		data, _ := json.Marshal(map[string]any{"guid": fmt.Sprintf("item-%d", i), "title": fmt.Sprintf("Item %d", i)})
+		if err := store.PutObject(context.Background(), fmt.Sprintf("feeds/blog/items/item-%d.json", i), data, dao.PutOptions{}); err != nil {
+			t.Fatalf("put: %v", err)
+		}
+	}
+	// 偶数条目读取失败
+	failing := map[string]bool{
+		"feeds/blog/items/item-0.json": true,
+		"feeds/blog/items/item-2.json": true,
+		"feeds/blog/items/item-4.json": true,
+	}
+	store.getHook = func(objectName string) error {
+		if failing[objectName] {
+			return fmt.Errorf("access denied")
+		}
+		return nil
+	}
+	aiService := service.NewAIService(conf.AIConfig{Disabled: true})
+	config := service.RssConfig{MaxRetries: 1, RetryDelay: time.Millisecond}
+
+	svc := service.NewRssService(aiService, store, config)
+	items, err := svc.GetStoredFeedItems(context.Background(), "blog")
+	if err == nil || items != nil {
+		t.Fatalf("expected an error and no items, got %d items, err %v", len(items), err)
+	}
+	if !strings.Contains(err.Error(), "3 of 6") || !strings.Contains(err.Error(), "3 succeeded") {
+		t.Errorf("expected failed and succeeded counts in %q", err)
+	}
+	for key := range failing {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("expected the error to mention %s, got %q", key, err)
+		}
+	}
+
+	config.PartialResults = true
+	svc = service.NewRssService(aiService, store, config)
+	items, err = svc.GetStoredFeedItems(context.Background(), "blog")
+	if err == nil || len(items) != 3 {
+		t.Fatalf("expected 3 partial items with an error, got %d items, err %v", len(items), err)
+	}
+	feedItems, err := svc.GetFeedItems(context.Background(), "blog")
+	if err != nil || len(feedItems) != 3 {
+		t.Errorf("expected partial items to be served, got %d items, err %v", len(feedItems), err)
+	}
+}
+
+func TestRssService_StoreDedupesByGUID(t *testing.T) {
+	store := newFakeStore()
+	svc := service.NewRssService(service.NewAIService(conf.AIConfig{Disabled: true}), store, service.RssConfig{})
+	items := []*gofeed.Item{
+		{GUID: "a", Title: "First", Description: "one"},
+		{GUID: "a", Title: "First (repeat)", Description: "one again"},
+		{GUID: "b", Title: "Second", Description: "two"},
+	}
+	if err := svc.StoreFeedItems(context.Background(), "dedup", items); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	if puts := store.Puts(); len(puts) != 2 {
+		t.Fatalf("expected one write per GUID, got %v", puts)
+	}
+	var item gofeed.Item
+	readStoredItem(t, store, "feeds/dedup/items/a.json", &item)
+	if item.Title != "First" {
+		t.Errorf("expected the first occurrence to be kept, got %q", item.Title)
+	}
+
+	// 内容不变的条目不再写入，变化的条目重新写入
+	again := []*gofeed.Item{
+		{GUID: "a", Title: "First", Description: "one"},
+		{GUID: "b", Title: "Second", Description: "two, edited"},
+	}
+	if err := svc.StoreFeedItems(context.Background(), "dedup", again); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	puts := store.Puts()
+	if len(puts) != 3 || puts[2] != "feeds/dedup/items/b.json" {
+		t.Errorf("expected only the changed item to be rewritten, got %v", puts)
+	}
+}
+
+func TestRssService_StoreDedupesByLinkWithoutGUID(t *testing.T) {
+	store := newFakeStore()
+	svc := service.NewRssService(service.NewAIService(conf.AIConfig{Disabled: true}), store, service.RssConfig{})
+	items := []*gofeed.Item{
+		{Link: "https://example.com/post", Title: "Post", Description: "body"},
+		{Link: "https://example.com/post", Title: "Post", Description: "body"},
+		{Link: "https://example.com/other", Title: "Post", Description: "body"},
+	}
+	if err := svc.StoreFeedItems(context.Background(), "links", items); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	want := "feeds/links/items/https___example.com_other.json,feeds/links/items/https___example.com_post.json"
+	if keys := store.Keys("feeds/links/items/"); strings.Join(keys, ",") != want {
+		t.Errorf("expected items keyed by link, got %v", keys)
+	}
+	if puts := store.Puts(); len(puts) != 2 {
+		t.Errorf("expected duplicate links written once, got %v", puts)
+	}
+}
+
+func TestRssService_StoreKeepsNewestDuplicateGUID(t *testing.T) {
+	day := func(d int) *time.Time {
+		ts := time.Date(2024, 3, d, 12, 0, 0, 0, time.UTC)
+		return &ts
+	}
+	for _, tc := range []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"flat", "", "feeds/dups/items/post.json"},
+		// 日期分区时重复条目的日期不同，同样按 ID 去重
+		{"dated", "feeds/{feed}/items/{yyyy}/{mm}/{dd}/{id}.json", "feeds/dups/items/2024/03/02/post.json"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newFakeStore()
+			svc := service.NewRssService(service.NewAIService(conf.AIConfig{Disabled: true}), store, service.RssConfig{ItemKeyTemplate: tc.template})
+			items := []*gofeed.Item{
+				{GUID: "post", Title: "Original", Description: "v1", PublishedParsed: day(1)},
+				{GUID: "post", Title: "Edited", Description: "v2", PublishedParsed: day(2)},
+				{GUID: "post", Title: "Stale", Description: "v0", PublishedParsed: day(1)},
+			}
+			if err := svc.StoreFeedItems(context.Background(), "dups", items); err != nil {
+				t.Fatalf("store: %v", err)
+			}
+			keys := store.Keys("feeds/dups/")
+			if len(keys) != 1 || keys[0] != tc.want {
+				t.Fatalf("expected one stored object at %s, got %v", tc.want, keys)
+			}
+			if puts := store.Puts(); len(puts) != 1 {
+				t.Errorf("expected the duplicate GUID written once, got %v", puts)
+			}
+			var item gofeed.Item
+			readStoredItem(t, store, keys[0], &item)
+			if item.Title != "Edited" {
+				t.Errorf("expected the newest duplicate to be kept, got %q", item.Title)
+			}
+		})
+	}
+}
+
+func TestRssService_StoreKeysItemsWithoutGUIDOrLinkByContentHash(t *testing.T) {
+	store := newFakeStore()
+	svc := service.NewRssService(service.NewAIService(conf.AIConfig{Disabled: true}), store, service.RssConfig{})
+	// 标题相同但正文不同的条目不应互相覆盖
+	items := []*gofeed.Item{
+		{Title: "Daily update", Description: "Monday"},
+		{Title: "Daily update", Description: "Tuesday"},
+		{Title: "Daily update", Description: "Monday"},
+	}
+	if err := svc.StoreFeedItems(context.Background(), "hashed", items); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	keys := store.Keys("feeds/hashed/items/")
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 distinct items, got %v", keys)
+	}
+	descriptions := map[string]bool{}
+	for _, key := range keys {
+		if !strings.HasPrefix(key, "feeds/hashed/items/hash-") {
+			t.Errorf("expected a content hash key, got %s", key)
+		}
+		var item gofeed.Item
+		readStoredItem(t, store, key, &item)
+		descriptions[item.Description] = true
+	}
+	if !descriptions["Monday"] || !descriptions["Tuesday"] {
+		t.Errorf("expected both items stored, got %v", descriptions)
+	}
+}
+
+func TestRssService_DatePartitionedItemKeys(t *testing.T) {
+	store := newFakeStore()
+	svc := service.NewRssService(service.NewAIService(conf.AIConfig{Disabled: true}), store, service.RssConfig{
+		ItemKeyTemplate: "feeds/{feed}/{yyyy}/{mm}/{id}.json",
+	})
+	may := time.Date(2024, time.May, 3, 10, 0, 0, 0, time.UTC)
+	june := time.Date(2024, time.June, 1, 8, 0, 0, 0, time.UTC)
+	items := []*gofeed.Item{
+		{GUID: "a", Title: "May", Description: "one", PublishedParsed: &may},
+		{GUID: "b", Title: "June", Description: "two", UpdatedParsed: &june},
+		{GUID: "c", Title: "Undated", Description: "three"},
+	}
+	if err := svc.StoreFeedItems(context.Background(), "dated", items); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	want := []string{
+		"feeds/dated/0001/01/c.json",
+		"feeds/dated/2024/05/a.json",
+		"feeds/dated/2024/06/b.json",
+	}
+	if got := store.Keys("feeds/"); !slices.Equal(got, want) {
+		t.Fatalf("expected date-partitioned keys %v, got %v", want, got)
+	}
+
+	stored, err := svc.GetStoredFeedItems(context.Background(), "dated")
+	if err != nil {
+		t.Fatalf("get stored items: %v", err)
+	}
+	if len(stored) != 3 {
+		t.Fatalf("expected all partitioned items to be read back, got %d", len(stored))
+	}
+
+	// 内容不变时按相同路径识别，不重复写入
+	if err := svc.StoreFeedItems(context.Background(), "dated", items); err != nil {
+		t.Fatalf("store again: %v", err)
+	}
+	if puts := store.Puts(); len(puts) != 3 {
+		t.Errorf("expected unchanged items to be skipped, got %v", puts)
+	}
+
+	// 删除时不需要知道日期
+	if err := svc.DeleteStoredItem(context.Background(), "dated", "a"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if removes := store.Removes(); len(removes) != 1 || removes[0] != "feeds/dated/2024/05/a.json" {
+		t.Errorf("expected partitioned item to be removed, got %v", removes)
+	}
+	if err := svc.DeleteStoredItem(context.Background(), "dated", "missing"); !errors.Is(err, service.ErrItemNotFound) {
+		t.Errorf("expected ErrItemNotFound, got %v", err)
+	}
+
+	// 模板必须能区分各 Feed 的条目
+	cfg := conf.Config{
+		Feeds:   []conf.Feed{{Name: "blog", RssFeed: "https://example.com/feed.xml"}},
+		S3:      conf.S3Config{Endpoint: "s3", AccessKeyID: "id", SecretAccessKey: "secret", BucketName: "bucket"},
+		AI:      conf.AIConfig{Disabled: true},
+		Storage: conf.StorageConfig{ItemKeyTemplate: "feeds/{yyyy}/{feed}/{id}.json"},
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected template without a per-feed prefix to be rejected")
+	}
+	cfg.Storage.ItemKeyTemplate = "feeds/{feed}/{yyyy}/{mm}/{dd}/{id}.json"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected date-partitioned template to be valid, got %v", err)
+	}
+}
+
+func TestRssService_ItemsMissingUpstream(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		removeMissing bool
+	}{
+		{"archive", false},
+		{"remove", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			src := newFeedServer(t, rssXML(numberedItems(3)...))
+			store := newFakeStore()
+			svc := service.NewRssService(service.NewAIService(conf.AIConfig{Disabled: true}), store, service.RssConfig{})
+			svc.SetClock(clock.NewFake(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)))
+			feed := conf.Feed{Name: "missing-" + tc.name, RssFeed: src.URL, RemoveMissing: tc.removeMissing}
+			prefix := "feeds/" + feed.Name + "/items/"
+
+			if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+				t.Fatalf("update: %v", err)
+			}
+
+			// item-2 从上游消失
+			src.SetBody(rssXML(numberedItems(2)...))
+			svc.InvalidateFeedCache(src.URL)
+			if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+				t.Fatalf("update: %v", err)
+			}
+
+			keys := store.Keys(prefix)
+			if tc.removeMissing {
+				if strings.Join(keys, ",") != prefix+"item-0.json,"+prefix+"item-1.json" {
+					t.Fatalf("expected the vanished item to be removed, got %v", keys)
+				}
+				return
+			}
+			if len(keys) != 3 {
+				t.Fatalf("expected the vanished item to be kept, got %v", keys)
+			}
+			var item gofeed.Item
+			readStoredItem(t, store, prefix+"item-2.json", &item)
+			if item.Custom["archived_at"] != "2024-05-01T10:00:00Z" {
+				t.Errorf("expected the vanished item to be archived, got %v", item.Custom)
+			}
+			item = gofeed.Item{}
+			readStoredItem(t, store, prefix+"item-1.json", &item)
+			if item.Custom["archived_at"] != "" {
+				t.Errorf("expected items still upstream not to be archived, got %v", item.Custom)
+			}
+
+			// 已归档的条目不重复写入，重新出现时清除标记
+			puts := len(store.Puts())
+			svc.InvalidateFeedCache(src.URL)
+			if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+				t.Fatalf("update: %v", err)
+			}
+			if got := len(store.Puts()); got != puts {
+				t.Errorf("expected archived item not to be rewritten, got %d new writes", got-puts)
+			}
+			src.SetBody(rssXML(numberedItems(3)...))
+			svc.InvalidateFeedCache(src.URL)
+			if err := svc.UpdateFeed(context.Background(), feed); err != nil {
+				t.Fatalf("update: %v", err)
+			}
+			item = gofeed.Item{}
+			readStoredItem(t, store, prefix+"item-2.json", &item)
+			if item.Custom["archived_at"] != "" {
+				t.Errorf("expected a reappearing item to be unarchived, got %v", item.Custom)
+			}
+		})
+	}
+}
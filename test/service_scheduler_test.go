@@ -0,0 +1,668 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"go.orx.me/apps/unifeed/internal/clock"
+	"go.orx.me/apps/unifeed/internal/conf"
+	"go.orx.me/apps/unifeed/internal/metrics"
+	"go.orx.me/apps/unifeed/internal/service"
+)
+
+func TestSchedulerService_RetriesFailedCycleBeforeInterval(t *testing.T) {
+	feedSrv := newFeedServer(t, "not a feed")
+	store := newFakeStore()
+	rssService := newTestRssService(okAIServer(t), store)
+	scheduler := service.NewSchedulerService(rssService, service.SchedulerConfig{
+		UpdateInterval: time.Hour,
+		MaxRetries:     1,
+		RetryDelay:     time.Millisecond,
+		FailureBackoff: 20 * time.Millisecond,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := scheduler.StartJob(ctx, conf.Feed{Name: "blog", RssFeed: feedSrv.URL}); err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+	defer scheduler.StopAllJobs()
+
+	// 等待首个周期失败后修复上游
+	deadline := time.Now().Add(time.Second)
+	for feedSrv.Hits() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	feedSrv.SetBody(rssXML(numberedItems(1)...))
+
+	for len(store.Keys("feeds/blog/")) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if len(store.Keys("feeds/blog/")) == 0 {
+		t.Fatalf("expected failed cycle to be retried well before the hourly interval, got %d fetches", feedSrv.Hits())
+	}
+}
+
+func TestSchedulerService_Events(t *testing.T) {
+	feedSrv := newFeedServer(t, rssXML(numberedItems(1)...))
+	scheduler := service.NewSchedulerService(newTestRssService(okAIServer(t), newFakeStore()), service.SchedulerConfig{
+		UpdateInterval: time.Hour,
+		MaxRetries:     1,
+		RetryDelay:     time.Millisecond,
+	})
+	events := scheduler.Events()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := scheduler.StartJob(ctx, conf.Feed{Name: "blog", RssFeed: feedSrv.URL}); err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+	defer scheduler.StopAllJobs()
+
+	want := []service.EventType{service.EventStarted, service.EventSucceeded}
+	for _, typ := range want {
+		select {
+		case ev := <-events:
+			if ev.Type != typ || ev.FeedName != "blog" {
+				t.Fatalf("expected %s event for blog, got %+v", typ, ev)
+			}
+			if ev.Err != nil {
+				t.Errorf("unexpected error in %s event: %v", typ, ev.Err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %s event", typ)
+		}
+	}
+}
+
+func TestSchedulerService_StoresSummaries(t *testing.T) {
+	feedSrv := newFeedServer(t, rssXML(numberedItems(2)...))
+	store := newFakeStore()
+	ai := okAIServer(t)
+	scheduler := service.NewSchedulerService(newTestRssService(ai, store), service.SchedulerConfig{
+		UpdateInterval: time.Hour,
+		MaxRetries:     1,
+		RetryDelay:     time.Millisecond,
+	})
+	events := scheduler.Events()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := scheduler.StartJob(ctx, conf.Feed{Name: "blog", RssFeed: feedSrv.URL}); err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+	defer scheduler.StopAllJobs()
+
+	// 等待首个周期完成
+	for {
+		select {
+		case ev := <-events:
+			if ev.Type == service.EventFailed {
+				t.Fatalf("scheduled update failed: %v", ev.Err)
+			}
+			if ev.Type != service.EventSucceeded {
+				continue
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for scheduled update")
+		}
+		break
+	}
+
+	keys := store.Keys("feeds/blog/")
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 stored items, got %v", keys)
+	}
+	for _, key := range keys {
+		var item gofeed.Item
+		readStoredItem(t, store, key, &item)
+		if item.Custom["summary"] != "summary" {
+			t.Errorf("expected scheduler-driven update to store a summary for %s, got %q", key, item.Custom["summary"])
+		}
+	}
+	if len(ai.Requests()) == 0 {
+		t.Error("expected scheduler-driven update to call the AI service")
+	}
+}
+
+func TestSchedulerService_SummarizesNewItemsOnLaterTicks(t *testing.T) {
+	feedSrv := newFeedServer(t, rssXML(numberedItems(1)...))
+	store := newFakeStore()
+	ai := okAIServer(t)
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	rssService := newTestRssService(ai, store)
+	// 解析缓存按同一时间源过期
+	rssService.SetClock(fake)
+	scheduler := service.NewSchedulerService(rssService, service.SchedulerConfig{
+		UpdateInterval: time.Hour,
+		MaxRetries:     1,
+		RetryDelay:     time.Minute,
+	})
+	scheduler.SetClock(fake)
+	events := scheduler.Events()
+
+	if err := scheduler.StartJob(context.Background(), conf.Feed{Name: "blog", RssFeed: feedSrv.URL}); err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+	defer scheduler.StopAllJobs()
+	expectEvent(t, events, service.EventStarted)
+	expectEvent(t, events, service.EventSucceeded)
+	requests := len(ai.Requests())
+
+	// 上游出现新条目后，下一次定时更新同样生成摘要
+	feedSrv.SetBody(rssXML(numberedItems(2)...))
+	fake.BlockUntil(1)
+	fake.Advance(time.Hour)
+	expectEvent(t, events, service.EventStarted)
+	expectEvent(t, events, service.EventSucceeded)
+
+	keys := store.Keys("feeds/blog/")
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 stored items after the second tick, got %v", keys)
+	}
+	for _, key := range keys {
+		var item gofeed.Item
+		readStoredItem(t, store, key, &item)
+		if item.Custom["summary"] != "summary" {
+			t.Errorf("expected a summary for %s after a scheduled tick, got %q", key, item.Custom["summary"])
+		}
+	}
+	if len(ai.Requests()) <= requests {
+		t.Error("expected the scheduled tick to summarize the new item")
+	}
+}
+
+func TestSchedulerService_StartAllJobsStaggersBoot(t *testing.T) {
+	var mu sync.Mutex
+	var hits []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits = append(hits, time.Now())
+		mu.Unlock()
+		w.Write([]byte(rssXML(numberedItems(1)...)))
+	}))
+	defer srv.Close()
+
+	const stagger = 300 * time.Millisecond
+	scheduler := service.NewSchedulerService(newTestRssService(okAIServer(t), newFakeStore()), service.SchedulerConfig{
+		UpdateInterval:  time.Hour,
+		MaxRetries:      1,
+		RetryDelay:      time.Millisecond,
+		BootConcurrency: 2,
+		BootStagger:     stagger,
+	})
+	var feeds []conf.Feed
+	for i := 0; i < 4; i++ {
+		// 不同的查询参数避免共享解析缓存
+		feeds = append(feeds, conf.Feed{Name: fmt.Sprintf("feed-%d", i), RssFeed: fmt.Sprintf("%s/?n=%d", srv.URL, i)})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	start := time.Now()
+	if err := scheduler.StartAllJobs(ctx, feeds); err != nil {
+		t.Fatalf("start jobs: %v", err)
+	}
+	defer scheduler.StopAllJobs()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		n := len(hits)
+		mu.Unlock()
+		if n == 4 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(hits) != 4 {
+		t.Fatalf("expected 4 initial fetches, got %d", len(hits))
+	}
+	// 首个任务立即执行，最后一个在 3/4 窗口后执行
+	if first := hits[0].Sub(start); first > stagger/4 {
+		t.Errorf("expected first fetch right away, got %v", first)
+	}
+	if spread := hits[3].Sub(hits[0]); spread < stagger/2 {
+		t.Errorf("expected initial fetches to be spread over the stagger window, got %v", spread)
+	}
+}
+
+// expectEvent 等待下一个事件并校验类型
+func expectEvent(t *testing.T, events <-chan service.Event, typ service.EventType) service.Event {
+	t.Helper()
+	select {
+	case ev := <-events:
+		if ev.Type != typ {
+			t.Fatalf("expected %s event, got %+v", typ, ev)
+		}
+		return ev
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for %s event", typ)
+	}
+	return service.Event{}
+}
+
+// expectNoEvent 确认当前没有待处理的事件
+func expectNoEvent(t *testing.T, events <-chan service.Event) {
+	t.Helper()
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected event %+v", ev)
+	default:
+	}
+}
+
+func TestSchedulerService_FakeClockTicks(t *testing.T) {
+	feedSrv := newFeedServer(t, rssXML(numberedItems(1)...))
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	scheduler := service.NewSchedulerService(newTestRssService(okAIServer(t), newFakeStore()), service.SchedulerConfig{
+		UpdateInterval: time.Hour,
+		MaxRetries:     1,
+		RetryDelay:     time.Minute,
+	})
+	scheduler.SetClock(fake)
+	events := scheduler.Events()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := scheduler.StartJob(ctx, conf.Feed{Name: "blog", RssFeed: feedSrv.URL}); err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+	defer scheduler.StopAllJobs()
+
+	expectEvent(t, events, service.EventStarted)
+	expectEvent(t, events, service.EventSucceeded)
+
+	// 等待下一次更新的定时器就绪后推进时间
+	fake.BlockUntil(1)
+	fake.Advance(time.Hour - time.Second)
+	expectNoEvent(t, events)
+
+	fake.Advance(time.Second)
+	ev := expectEvent(t, events, service.EventStarted)
+	if !ev.Time.Equal(start.Add(time.Hour)) {
+		t.Errorf("expected second cycle at %v, got %v", start.Add(time.Hour), ev.Time)
+	}
+	expectEvent(t, events, service.EventSucceeded)
+}
+
+func TestSchedulerService_FakeClockRetries(t *testing.T) {
+	feedSrv := newFeedServer(t, "not a feed")
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	scheduler := service.NewSchedulerService(newTestRssService(okAIServer(t), newFakeStore()), service.SchedulerConfig{
+		UpdateInterval: time.Hour,
+		MaxRetries:     2,
+		RetryDelay:     time.Minute,
+		FailureBackoff: 10 * time.Minute,
+	})
+	scheduler.SetClock(fake)
+	events := scheduler.Events()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := scheduler.StartJob(ctx, conf.Feed{Name: "broken", RssFeed: feedSrv.URL}); err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+	defer scheduler.StopAllJobs()
+
+	expectEvent(t, events, service.EventStarted)
+
+	// 每次失败后等待 RetryDelay，两次尝试后周期失败
+	for attempt := 1; attempt <= 2; attempt++ {
+		fake.BlockUntil(1)
+		expectNoEvent(t, events)
+		fake.Advance(time.Minute)
+	}
+	expectEvent(t, events, service.EventFailed)
+
+	// 失败后按 FailureBackoff 重试，而不是等待整个更新间隔
+	fake.BlockUntil(1)
+	fake.Advance(10*time.Minute - time.Second)
+	expectNoEvent(t, events)
+	fake.Advance(time.Second)
+	expectEvent(t, events, service.EventStarted)
+}
+
+func TestSchedulerService_StopDuringRetryDelay(t *testing.T) {
+	feedSrv := newFeedServer(t, "not a feed")
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	scheduler := service.NewSchedulerService(newTestRssService(okAIServer(t), newFakeStore()), service.SchedulerConfig{
+		UpdateInterval: time.Hour,
+		MaxRetries:     3,
+		RetryDelay:     time.Hour,
+	})
+	scheduler.SetClock(fake)
+	events := scheduler.Events()
+
+	if err := scheduler.StartJob(context.Background(), conf.Feed{Name: "broken", RssFeed: feedSrv.URL}); err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+	expectEvent(t, events, service.EventStarted)
+
+	// 第一次失败后进入重试等待，停止任务不需要等到重试间隔结束
+	fake.BlockUntil(1)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := scheduler.StopAllJobsWithTimeout(ctx); err != nil {
+		t.Fatalf("expected stop to finish without waiting for retry delay, got %v", err)
+	}
+	if hits := feedSrv.Hits(); hits != 1 {
+		t.Errorf("expected no retry after stop, got %d fetches", hits)
+	}
+}
+
+func TestSchedulerService_RetryMetrics(t *testing.T) {
+	feedSrv := newFeedServer(t, "not a feed")
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	scheduler := service.NewSchedulerService(newTestRssService(okAIServer(t), newFakeStore()), service.SchedulerConfig{
+		UpdateInterval: time.Hour,
+		MaxRetries:     3,
+		RetryDelay:     time.Minute,
+		FailureBackoff: 10 * time.Minute,
+	})
+	scheduler.SetClock(fake)
+	events := scheduler.Events()
+
+	retries := metrics.FeedRetries.WithLabelValues("retry-metrics", "scheduler_update")
+	exhausted := metrics.FeedRetriesExhausted.WithLabelValues("retry-metrics")
+	retriesBefore := counterValue(t, retries)
+	exhaustedBefore := counterValue(t, exhausted)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := scheduler.StartJob(ctx, conf.Feed{Name: "retry-metrics", RssFeed: feedSrv.URL}); err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+	defer scheduler.StopAllJobs()
+
+	expectEvent(t, events, service.EventStarted)
+	for attempt := 1; attempt <= 3; attempt++ {
+		fake.BlockUntil(1)
+		if got := counterValue(t, retries) - retriesBefore; got != float64(attempt) {
+			t.Errorf("attempt %d: expected %d retry increments, got %v", attempt, attempt, got)
+		}
+		if got := counterValue(t, exhausted) - exhaustedBefore; got != 0 {
+			t.Errorf("attempt %d: retries reported exhausted too early: %v", attempt, got)
+		}
+		fake.Advance(time.Minute)
+	}
+	expectEvent(t, events, service.EventFailed)
+
+	if got := counterValue(t, retries) - retriesBefore; got != 3 {
+		t.Errorf("expected 3 retry increments, got %v", got)
+	}
+	if got := counterValue(t, exhausted) - exhaustedBefore; got != 1 {
+		t.Errorf("expected 1 exhausted cycle, got %v", got)
+	}
+}
+
+func TestSchedulerService_PerFeedUpdateInterval(t *testing.T) {
+	feedSrv := newFeedServer(t, rssXML(numberedItems(1)...))
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	scheduler := service.NewSchedulerService(newTestRssService(okAIServer(t), newFakeStore()), service.SchedulerConfig{
+		UpdateInterval: time.Hour,
+		MaxRetries:     1,
+	})
+	scheduler.SetClock(fake)
+	events := scheduler.Events()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	feeds := []conf.Feed{
+		{Name: "news", RssFeed: feedSrv.URL + "/?feed=news", UpdateInterval: 10 * time.Minute},
+		{Name: "weekly", RssFeed: feedSrv.URL + "/?feed=weekly", UpdateInterval: 30 * time.Minute},
+		{Name: "default", RssFeed: feedSrv.URL + "/?feed=default"},
+	}
+	for _, feed := range feeds {
+		if err := scheduler.StartJob(ctx, feed); err != nil {
+			t.Fatalf("start job %s: %v", feed.Name, err)
+		}
+	}
+	defer scheduler.StopAllJobs()
+
+	// 首次更新立即执行
+	cycles := waitForCycles(t, events, len(feeds))
+
+	// 每次推进 10 分钟，共 1 小时
+	for step := 0; step < 6; step++ {
+		fake.BlockUntil(len(feeds))
+		fake.Advance(10 * time.Minute)
+		elapsed := time.Duration(step+1) * 10 * time.Minute
+		due := 1
+		if elapsed%(30*time.Minute) == 0 {
+			due++
+		}
+		if elapsed == time.Hour {
+			due++
+		}
+		for name, n := range waitForCycles(t, events, due) {
+			cycles[name] += n
+		}
+	}
+
+	want := map[string]int{"news": 7, "weekly": 3, "default": 2}
+	for name, n := range want {
+		if cycles[name] != n {
+			t.Errorf("%s: expected %d cycles in the first hour, got %d", name, n, cycles[name])
+		}
+	}
+}
+
+func TestSchedulerService_PausesWhileDependencyDown(t *testing.T) {
+	feedSrv := newFeedServer(t, rssXML(numberedItems(1)...))
+	var down atomic.Bool
+	down.Store(true)
+	storageErr := fmt.Errorf("storage unavailable")
+	store := newFakeStore()
+	store.listHook = func(ctx context.Context, prefix string) error {
+		if down.Load() {
+			return storageErr
+		}
+		return nil
+	}
+	store.putErr = func(objectName string) error {
+		if down.Load() {
+			return storageErr
+		}
+		return nil
+	}
+
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	scheduler := service.NewSchedulerService(newTestRssService(okAIServer(t), store), service.SchedulerConfig{
+		UpdateInterval:    time.Hour,
+		MaxRetries:        1,
+		RetryDelay:        time.Second,
+		FailureBackoff:    time.Minute,
+		DependencyBackoff: time.Minute,
+	})
+	scheduler.SetClock(fake)
+	events := scheduler.Events()
+	paused := counterValue(t, metrics.SchedulerPausedCycles)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := scheduler.StartJob(ctx, conf.Feed{Name: "first", RssFeed: feedSrv.URL + "/?feed=first"}); err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+	defer scheduler.StopAllJobs()
+
+	// 首个失败的周期检测到存储不可用
+	expectEvent(t, events, service.EventStarted)
+	fake.BlockUntil(1)
+	fake.Advance(time.Second)
+	expectEvent(t, events, service.EventFailed)
+
+	// 之后启动的任务不再拉取上游
+	if err := scheduler.StartJob(ctx, conf.Feed{Name: "second", RssFeed: feedSrv.URL + "/?feed=second"}); err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+	expectEvent(t, events, service.EventPaused)
+	hits := feedSrv.Hits()
+
+	// 重新检查仍失败，两个任务都继续暂停
+	fake.BlockUntil(2)
+	fake.Advance(time.Minute)
+	expectEvent(t, events, service.EventPaused)
+	expectEvent(t, events, service.EventPaused)
+	if got := feedSrv.Hits(); got != hits {
+		t.Errorf("expected no upstream fetches while storage is down, got %d more", got-hits)
+	}
+	if got := counterValue(t, metrics.SchedulerPausedCycles) - paused; got != 3 {
+		t.Errorf("expected 3 paused cycles, got %v", got)
+	}
+
+	// 依赖恢复后按退避间隔重新检查并恢复更新
+	down.Store(false)
+	fake.BlockUntil(2)
+	fake.Advance(2*time.Minute - time.Second)
+	expectNoEvent(t, events)
+	fake.Advance(time.Second)
+	cycles := waitForCycles(t, events, 2)
+	if cycles["first"] != 1 || cycles["second"] != 1 {
+		t.Errorf("expected both feeds to resume, got %v", cycles)
+	}
+}
+
+// waitForCycles 等待 n 个成功的更新周期，返回每个 Feed 完成的周期数
+func waitForCycles(t *testing.T, events <-chan service.Event, n int) map[string]int {
+	t.Helper()
+	cycles := make(map[string]int)
+	for done := 0; done < n; {
+		select {
+		case ev := <-events:
+			switch ev.Type {
+			case service.EventSucceeded:
+				cycles[ev.FeedName]++
+				done++
+			case service.EventFailed:
+				t.Fatalf("unexpected failed cycle for %s: %v", ev.FeedName, ev.Err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %d cycles, got %v", n, cycles)
+		}
+	}
+	return cycles
+}
+
+func TestSchedulerService_StopAllJobsWaitsForInFlightUpdates(t *testing.T) {
+	feedSrv := newFeedServer(t, rssXML(numberedItems(1)...))
+	store := newFakeStore()
+	writing := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	store.putErr = func(objectName string) error {
+		// 模拟耗时的 S3 写入
+		once.Do(func() { close(writing) })
+		<-release
+		return nil
+	}
+	scheduler := service.NewSchedulerService(newTestRssService(okAIServer(t), store), service.SchedulerConfig{
+		UpdateInterval: time.Hour,
+		MaxRetries:     1,
+		RetryDelay:     time.Millisecond,
+	})
+	if err := scheduler.StartJob(context.Background(), conf.Feed{Name: "blog", RssFeed: feedSrv.URL}); err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+	select {
+	case <-writing:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the update to start writing")
+	}
+
+	// 超时前写入未完成，返回超时错误
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := scheduler.StopAllJobsWithTimeout(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the wait to time out while the write is blocked, got %v", err)
+	}
+
+	// 写入完成前一直阻塞，完成后返回
+	done := make(chan error, 1)
+	go func() { done <- scheduler.StopAllJobsWithTimeout(context.Background()) }()
+	select {
+	case err := <-done:
+		t.Fatalf("expected shutdown to wait for the in-flight write, returned %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected shutdown to finish once the write completed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for shutdown")
+	}
+	if len(store.Keys("feeds/blog/")) == 0 {
+		t.Error("expected the in-flight update to finish storing its items")
+	}
+}
+
+func TestSchedulerService_ConcurrentStatusReads(t *testing.T) {
+	feedSrv := newFeedServer(t, rssXML(numberedItems(1)...))
+	scheduler := service.NewSchedulerService(newTestRssService(okAIServer(t), newFakeStore()), service.SchedulerConfig{
+		UpdateInterval: time.Millisecond,
+		MaxRetries:     1,
+		RetryDelay:     time.Millisecond,
+	})
+	events := scheduler.Events()
+	if err := scheduler.StartJob(context.Background(), conf.Feed{Name: "blog", RssFeed: feedSrv.URL}); err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+	defer scheduler.StopAllJobs()
+
+	// 在 -race 下并发读取状态，与更新循环的写入交错
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				job, err := scheduler.GetJobStatus("blog")
+				if err != nil {
+					t.Errorf("get job status: %v", err)
+					return
+				}
+				_ = job.LastRun.IsZero()
+				_ = job.Error != nil
+				for _, job := range scheduler.GetAllJobs() {
+					scheduler.Health(job)
+				}
+				scheduler.Stats()
+			}
+		}()
+	}
+
+	// 等待多个更新周期完成
+	for cycles := 0; cycles < 5; {
+		select {
+		case ev := <-events:
+			if ev.Type == service.EventSucceeded {
+				cycles++
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out after %d update cycles", cycles)
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
@@ -0,0 +1,181 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/minio/minio-go/v7/pkg/tags"
+	"go.orx.me/apps/unifeed/internal/conf"
+	"go.orx.me/apps/unifeed/internal/dao"
+	"go.orx.me/apps/unifeed/internal/service"
+)
+
+func TestRssService_StorageProfiles(t *testing.T) {
+	defaultStore, archiveStore := newFakeStore(), newFakeStore()
+	aiService := service.NewAIService(conf.AIConfig{Disabled: true})
+	svc := service.NewRssService(aiService, defaultStore, service.RssConfig{})
+	svc.SetFeedStore("archive", archiveStore)
+
+	ctx := context.Background()
+	for _, name := range []string{"news", "archive"} {
+		src := newFeedServer(t, rssXML(numberedItems(1)...))
+		if err := svc.UpdateFeed(ctx, conf.Feed{Name: name, RssFeed: src.URL}); err != nil {
+			t.Fatalf("update %s: %v", name, err)
+		}
+	}
+
+	if len(defaultStore.Keys("feeds/news/")) != 1 || len(defaultStore.Keys("feeds/archive/")) != 0 {
+		t.Errorf("expected default store to hold only news, got %v", defaultStore.Keys("feeds/"))
+	}
+	if len(archiveStore.Keys("feeds/archive/")) != 1 || len(archiveStore.Keys("feeds/news/")) != 0 {
+		t.Errorf("expected archive profile to hold only archive, got %v", archiveStore.Keys("feeds/"))
+	}
+
+	// 读取时同样使用 Feed 对应的存储
+	items, err := svc.GetFeedItems(ctx, "archive")
+	if err != nil {
+		t.Fatalf("get archive items: %v", err)
+	}
+	if len(items) != 1 {
+		t.Errorf("expected 1 archive item from profile store, got %d", len(items))
+	}
+}
+
+func TestConfigValidate_StorageProfiles(t *testing.T) {
+	s3 := conf.S3Config{Endpoint: "s3", AccessKeyID: "id", SecretAccessKey: "secret", BucketName: "bucket"}
+	cfg := conf.Config{
+		Feeds: []conf.Feed{{Name: "archive", RssFeed: "https://example.com/feed.xml", StorageProfile: "cold"}},
+		S3:    s3,
+		AI:    conf.AIConfig{Disabled: true},
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected unknown storage profile to be rejected")
+	}
+
+	cfg.Storage.Profiles = map[string]conf.S3Config{"cold": {Endpoint: "cold-s3"}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected incomplete storage profile to be rejected")
+	}
+
+	cold := s3
+	cold.BucketName = "cold"
+	cfg.Storage.Profiles["cold"] = cold
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected valid storage profile config, got %v", err)
+	}
+}
+
+func TestRssService_StoredObjectTags(t *testing.T) {
+	store := newFakeStore()
+	src := newFeedServer(t, rssXML(numberedItems(2)...))
+	svc := service.NewRssService(service.NewAIService(conf.AIConfig{Disabled: true}), store, service.RssConfig{})
+	if err := svc.UpdateFeed(context.Background(), conf.Feed{Name: "tagged", RssFeed: src.URL}); err != nil {
+		t.Fatalf("update feed: %v", err)
+	}
+
+	keys := store.Keys("feeds/tagged/items/")
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 stored items, got %v", keys)
+	}
+	today := time.Now().UTC().Format("2006-01-02")
+	for _, key := range keys {
+		opts, ok := store.Options(key)
+		if !ok {
+			t.Fatalf("no put options recorded for %s", key)
+		}
+		if opts.ContentType != "application/json" {
+			t.Errorf("%s: expected application/json, got %q", key, opts.ContentType)
+		}
+		want := map[string]string{dao.TagFeed: "tagged", dao.TagType: "item", dao.TagCreated: today}
+		for k, v := range want {
+			if opts.Tags[k] != v {
+				t.Errorf("%s: expected tag %s=%s, got %q", key, k, v, opts.Tags[k])
+			}
+		}
+	}
+}
+
+func TestTagValue(t *testing.T) {
+	cases := map[string]string{
+		"tagged":                 "tagged",
+		"少数派":                    "___",
+		"blog #1 (news), weekly": "blog _1 _news__ weekly",
+		"a+b-c.d_e:f/g@h i=j":    "a+b-c.d_e:f/g@h i=j",
+		strings.Repeat("长", 300): strings.Repeat("_", 256),
+	}
+	for in, want := range cases {
+		if got := dao.TagValue(in); got != want {
+			t.Errorf("TagValue(%q): expected %q, got %q", in, want, got)
+		}
+	}
+}
+
+func TestRssService_StoredObjectTagsNonASCIIFeed(t *testing.T) {
+	store := newFakeStore()
+	src := newFeedServer(t, rssXML(numberedItems(1)...))
+	svc := service.NewRssService(service.NewAIService(conf.AIConfig{Disabled: true}), store, service.RssConfig{})
+	if err := svc.UpdateFeed(context.Background(), conf.Feed{Name: "少数派", RssFeed: src.URL}); err != nil {
+		t.Fatalf("update feed: %v", err)
+	}
+
+	keys := store.Keys("feeds/")
+	if len(keys) == 0 {
+		t.Fatal("expected stored items")
+	}
+	// 标签需通过 S3 的校验，否则对象写入时会丢失全部标签
+	for _, key := range keys {
+		opts, _ := store.Options(key)
+		if opts.Tags[dao.TagFeed] != "___" {
+			t.Errorf("%s: expected sanitized feed tag, got %q", key, opts.Tags[dao.TagFeed])
+		}
+		if _, err := tags.NewTags(opts.Tags, true); err != nil {
+			t.Errorf("%s: expected valid S3 tags, got %v", key, err)
+		}
+	}
+}
+
+func TestRssService_LongFeedNameKeys(t *testing.T) {
+	longID := strings.Repeat("x", 2000)
+	src := newFeedServer(t, rssXML(
+		rssItem{GUID: longID + "1", Title: "One", Description: "first"},
+		rssItem{GUID: longID + "2", Title: "Two", Description: "second"},
+	))
+	store := newFakeStore()
+	svc := service.NewRssService(service.NewAIService(conf.AIConfig{Disabled: true}), store, service.RssConfig{})
+
+	// 两个 Feed 名称仅在截断位置之后不同
+	base := strings.Repeat("很长的名称", 200)
+	names := []string{base + "-a", base + "-b"}
+	ctx := context.Background()
+	for i, name := range names {
+		if err := svc.UpdateFeed(ctx, conf.Feed{Name: name, RssFeed: src.URL}); err != nil {
+			t.Fatalf("update feed %d: %v", i, err)
+		}
+	}
+
+	keys := store.Keys("feeds/")
+	if len(keys) != 4 {
+		t.Fatalf("expected 4 distinct keys, got %d", len(keys))
+	}
+	for _, key := range keys {
+		if len(key) > 1024 {
+			t.Errorf("key exceeds 1024 bytes: %d", len(key))
+		}
+		if !utf8.ValidString(key) {
+			t.Errorf("key is not valid UTF-8: %q", key)
+		}
+	}
+
+	for _, name := range names {
+		items, err := svc.GetFeedItems(ctx, name)
+		if err != nil {
+			t.Fatalf("get items: %v", err)
+		}
+		if len(items) != 2 {
+			t.Errorf("expected each feed to read back its own 2 items, got %d", len(items))
+		}
+	}
+}